@@ -1,10 +1,12 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -14,6 +16,9 @@ import (
 	"github.com/narroworb/pr-review-service/internal/middleware"
 )
 
+// shutdownTimeout bounds how long in-flight requests may run on shutdown.
+const shutdownTimeout = 10 * time.Second
+
 func main() {
 	dsn := os.Getenv("POSTGRES_DSN")
 	if dsn == "" {
@@ -31,34 +36,193 @@ func main() {
 	}
 	log.Println("Migrations to Postgres applied")
 
+	idempotencyTTL := 24 * time.Hour
+	if v := os.Getenv("IDEMPOTENCY_TTL"); v != "" {
+		idempotencyTTL, err = time.ParseDuration(v)
+		if err != nil || idempotencyTTL <= 0 {
+			log.Fatalf("invalid environment variable IDEMPOTENCY_TTL=%q", v)
+		}
+	}
+
+	queueInterval := 10 * time.Second
+	if v := os.Getenv("REVIEW_QUEUE_INTERVAL"); v != "" {
+		queueInterval, err = time.ParseDuration(v)
+		if err != nil || queueInterval <= 0 {
+			log.Fatalf("invalid environment variable REVIEW_QUEUE_INTERVAL=%q", v)
+		}
+	}
+	var queueByPriority bool
+	switch v := os.Getenv("REVIEW_QUEUE_ORDER"); v {
+	case "", "fifo":
+	case "priority":
+		queueByPriority = true
+	default:
+		log.Fatalf("invalid environment variable REVIEW_QUEUE_ORDER=%q", v)
+	}
+
+	// Reviews not accepted within ACK_DEADLINE of their assignment are handed
+	// over to someone else; without it they wait for the reviewer.
+	var ackDeadline time.Duration
+	if v := os.Getenv("ACK_DEADLINE"); v != "" {
+		ackDeadline, err = time.ParseDuration(v)
+		if err != nil || ackDeadline <= 0 {
+			log.Fatalf("invalid environment variable ACK_DEADLINE=%q", v)
+		}
+	}
+
 	h := handlers.NewHandlersRepo(db)
 
 	r := chi.NewRouter()
 
 	r.Use(middleware.TimeoutMiddleware(3 * time.Second))
+	r.Use(middleware.IdempotencyMiddleware(db, idempotencyTTL))
 
 	r.Post("/team/add", h.AddTeam)
 	r.Get("/team/get", h.GetTeam)
+	r.Get("/team/list", h.ListTeams)
 	r.Post("/team/deactivate", h.DeactivateAllUsersInTeam)
+	r.Post("/team/addMembers", h.AddTeamMembers)
+	r.Post("/team/removeMember", h.RemoveTeamMember)
+	r.Post("/team/updateMember", h.UpdateTeamMember)
+	r.Post("/team/rename", h.RenameTeam)
+	r.Post("/team/archive", h.ArchiveTeam)
+	r.Post("/team/delete", h.DeleteTeam)
+	r.Post("/team/setParent", h.SetTeamParent)
+	r.Post("/team/setCapacity", h.SetTeamCapacity)
+	r.Post("/team/setHolidays", h.SetTeamHolidays)
+	r.Get("/team/getHolidays", h.GetTeamHolidays)
+	r.Post("/team/setReviewPolicy", h.SetReviewPolicy)
+	r.Get("/team/getReviewPolicy", h.GetReviewPolicy)
+	r.Post("/team/setSLA", h.SetSLAPolicy)
+	r.Get("/team/getSLA", h.GetSLAPolicy)
 
 	r.Post("/users/setIsActive", h.SetUserIsActive)
 	r.Get("/users/getReview", h.GetReview)
+	r.Get("/users/list", h.ListUsers)
+	r.Get("/users/inbox", h.GetInbox)
+	r.Get("/users/inboxCount", h.GetInboxCount)
 	r.Post("/users/deactivate", h.DeactivateUsersByID)
+	r.Post("/users/moveTeam", h.MoveUserTeam)
+	r.Get("/users/teamHistory", h.GetUserTeamHistory)
+	r.Post("/users/setSkills", h.SetUserSkills)
+	r.Post("/users/setCapacity", h.SetUserCapacity)
+	r.Get("/users/getSkills", h.GetUserSkills)
+	r.Post("/users/addAbsence", h.AddAbsence)
+	r.Post("/users/deleteAbsence", h.DeleteAbsence)
+	r.Post("/users/importAbsences", h.ImportAbsences)
+	r.Get("/users/getAbsences", h.GetUserAbsences)
+	r.Post("/users/setWorkingHours", h.SetWorkingHours)
+	r.Get("/users/getWorkingHours", h.GetWorkingHours)
+	r.Post("/users/setSeniority", h.SetSeniority)
 
 	r.Post("/pullRequest/create", h.CreatePR)
 	r.Post("/pullRequest/merge", h.MergePR)
 	r.Post("/pullRequest/reassign", h.ReassignPR)
+	r.Post("/pullRequest/addReviewer", h.AddReviewer)
+	r.Post("/pullRequest/removeReviewer", h.RemoveReviewer)
+	r.Post("/pullRequest/close", h.ClosePR)
+	r.Post("/pullRequest/assignPreview", h.AssignPreview)
+	r.Get("/pullRequest/explain", h.ExplainPR)
+	r.Post("/pullRequest/ack", h.AckReview)
+	r.Get("/pullRequest/get", h.GetPR)
+	r.Get("/pullRequest/list", h.ListPRs)
+
+	r.Post("/labelRules/set", h.SetLabelRule)
+	r.Post("/labelRules/delete", h.DeleteLabelRule)
+	r.Get("/labelRules/list", h.GetLabelRules)
+	r.Post("/pairRules/set", h.SetPairRule)
+	r.Post("/pairRules/delete", h.DeletePairRule)
+	r.Get("/pairRules/list", h.GetPairRules)
+
+	r.Post("/codeowners/upload", h.UploadCodeowners)
+	r.Get("/codeowners/get", h.GetCodeowners)
 
 	r.Get("/stats/users", h.GetStatsByUsers)
 	r.Get("/stats/teams", h.GetStatsByTeams)
 	r.Get("/stats/pullRequests", h.GetStatsByPRs)
+	r.Get("/stats/declines", h.GetStatsByDeclines)
+
+	r.Get("/sla/breaches", h.GetSLABreaches)
+
+	r.Post("/loadWeighting/set", h.SetLoadWeighting)
+	r.Get("/loadWeighting/get", h.GetLoadWeighting)
+
+	r.Get("/events", h.GetEvents)
+
+	// Background goroutines stop once ctx is cancelled on shutdown.
+	ctx, cancel := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if _, err := db.DeleteExpiredIdempotencyKeys(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("error in delete expired idempotency keys: %v", err)
+			}
+		}
+	}()
+
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		ticker := time.NewTicker(queueInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-h.CapacityFreed():
+			}
+			handovers, err := db.HandOverAbsentReviews(ctx)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("error in hand over reviews of absent users: %v", err)
+			}
+			for _, e := range handovers {
+				log.Printf("event %d %s for pull request %s: %s", e.ID, e.Type, e.PRID, e.Payload)
+			}
+			if ackDeadline > 0 {
+				expired, err := db.ReassignUnacceptedReviews(ctx, ackDeadline)
+				if err != nil && ctx.Err() == nil {
+					log.Printf("error in reassign unaccepted reviews: %v", err)
+				}
+				for _, e := range expired {
+					log.Printf("event %d %s for pull request %s: %s", e.ID, e.Type, e.PRID, e.Payload)
+				}
+			}
+			escalations, err := db.EscalateReviewSLAs(ctx)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("error in escalate review slas: %v", err)
+			}
+			for _, e := range escalations {
+				log.Printf("event %d %s for pull request %s: %s", e.ID, e.Type, e.PRID, e.Payload)
+			}
+			events, err := db.AssignPendingReviewers(ctx, queueByPriority)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("error in assign pending reviewers: %v", err)
+				continue
+			}
+			for _, e := range events {
+				log.Printf("event %d %s for pull request %s: %s", e.ID, e.Type, e.PRID, e.Payload)
+			}
+		}
+	}()
 
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 
+	srv := &http.Server{Addr: ":8080", Handler: r}
 	go func() {
 		log.Println("listening on :8080")
-		if err := http.ListenAndServe(":8080", r); err != nil && err != http.ErrServerClosed {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("error in server work: %v", err)
 		}
 	}()
@@ -66,5 +230,12 @@ func main() {
 	<-stop
 
 	log.Println("shutting down: stopping to accept new requests...")
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer shutdownCancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("error in server shutdown: %v", err)
+	}
+	cancel()
+	workers.Wait()
 	db.Close()
 }
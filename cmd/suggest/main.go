@@ -0,0 +1,157 @@
+// Command suggest ranks reviewers for a commit range of a local git clone by
+// blame and log history and can create the pull request with them as
+// preferred reviewers:
+//
+//	suggest -repo . -range origin/main..HEAD -emails emails.txt
+//	suggest -repo . -range origin/main..HEAD -emails emails.txt \
+//		-server http://localhost:8080 -pr-id pr-42 -pr-name "Fix auth" -author-id u001
+//
+// The email file maps commit emails to user ids, one "email user_id" pair per
+// line. Only the service call needs the network; the analysis runs on the local
+// clone.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/narroworb/pr-review-service/internal/models"
+	"github.com/narroworb/pr-review-service/internal/suggest"
+)
+
+func main() {
+	var (
+		repo       = flag.String("repo", ".", "path to the local git repository")
+		commits    = flag.String("range", "", "commit range base..head")
+		emailsPath = flag.String("emails", "", "file mapping commit emails to user ids")
+		limit      = flag.Int("limit", 5, "number of preferred reviewers to print or send")
+		window     = flag.Duration("window", suggest.DefaultOptions.Window, "how far back before head recent commits count")
+		server     = flag.String("server", "", "service URL; when set, the pull request is created")
+		prID       = flag.String("pr-id", "", "pull request id")
+		prName     = flag.String("pr-name", "", "pull request name")
+		authorID   = flag.String("author-id", "", "pull request author user id")
+		teamName   = flag.String("team-name", "", "team of the pull request, the author's primary team by default")
+		repository = flag.String("repository", "", "repository name, sends changed_files for CODEOWNERS")
+		labels     = flag.String("labels", "", "comma separated pull request labels")
+		timeout    = flag.Duration("timeout", 10*time.Second, "service request timeout")
+	)
+	flag.Parse()
+	log.SetFlags(0)
+
+	base, head, err := suggest.ParseRange(*commits)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	emails := map[string]string{}
+	if *emailsPath != "" {
+		f, err := os.Open(*emailsPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		emails, err = suggest.ReadEmails(f)
+		_ = f.Close()
+		if err != nil {
+			log.Fatalf("%s: %v", *emailsPath, err)
+		}
+	}
+
+	opts := suggest.DefaultOptions
+	opts.Window = *window
+	res, err := suggest.Analyze(context.Background(), *repo, base, head, emails, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *server == "" {
+		printResult(os.Stdout, res)
+		return
+	}
+
+	if *prID == "" || *prName == "" || *authorID == "" {
+		log.Fatal("-pr-id, -pr-name and -author-id are required with -server")
+	}
+	req := models.CreatePRRequest{
+		PRID:       *prID,
+		PRName:     *prName,
+		AuthorID:   *authorID,
+		TeamName:   *teamName,
+		Repository: *repository,
+		Preferred:  res.UserIDs(*limit),
+	}
+	if *repository != "" {
+		req.ChangedFiles = res.ChangedFiles
+	}
+	if *labels != "" {
+		req.Labels = strings.Split(*labels, ",")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	resp, err := createPR(ctx, *server, req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("created %s, reviewers: %s, preferred: %s\n", resp.PR.PRID,
+		strings.Join(resp.PR.Reviewers, ","), strings.Join(resp.PR.PreferredReviewers, ","))
+}
+
+func printResult(w io.Writer, res *suggest.Result) {
+	fmt.Fprintf(w, "range %.12s..%.12s, %d changed files\n", res.Base, res.Head, len(res.ChangedFiles))
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "USER_ID\tEMAIL\tSCORE\tLINES\tCOMMITS")
+	for _, c := range res.Candidates {
+		userID := c.UserID
+		if userID == "" {
+			userID = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%.2f\t%d\t%d\n", userID, c.Email, c.Score, c.Lines, c.Commits)
+	}
+	_ = tw.Flush()
+}
+
+func createPR(ctx context.Context, server string, req models.CreatePRRequest) (models.CreatePRResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return models.CreatePRResponse{}, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(server, "/")+"/pullRequest/create", bytes.NewReader(body))
+	if err != nil {
+		return models.CreatePRResponse{}, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	// A retried CI job must not create the pull request twice.
+	httpReq.Header.Set("Idempotency-Key", "suggest-"+req.PRID)
+
+	httpResp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return models.CreatePRResponse{}, err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusCreated {
+		var errResp models.ErrorResponse
+		if err := json.NewDecoder(httpResp.Body).Decode(&errResp); err != nil {
+			return models.CreatePRResponse{}, fmt.Errorf("create pull request: %s", httpResp.Status)
+		}
+		return models.CreatePRResponse{}, fmt.Errorf("create pull request: %s %s: %s", httpResp.Status, errResp.Error.Code, errResp.Error.Message)
+	}
+
+	var resp models.CreatePRResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return models.CreatePRResponse{}, err
+	}
+	return resp, nil
+}
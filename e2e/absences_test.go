@@ -0,0 +1,131 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAbsenceHandsOverReviews(t *testing.T) {
+	suffix := "abs_" + now
+	a, b, c, d := "a_"+suffix, "b_"+suffix, "c_"+suffix, "d_"+suffix
+	createTeam(t, "team_"+suffix, []string{a, b, c, d})
+
+	resp := postJSON(t, baseURL+"/pullRequest/create", map[string]string{"pull_request_id": "pr_" + suffix, "pull_request_name": "absence", "author_id": a})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created struct {
+		PR struct {
+			Reviewers []string `json:"assigned_reviewers"`
+		} `json:"pr"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&created)
+	assert.Len(t, created.PR.Reviewers, 2)
+	if len(created.PR.Reviewers) != 2 {
+		return
+	}
+	absent := created.PR.Reviewers[0]
+
+	resp = postJSON(t, baseURL+"/users/addAbsence", map[string]interface{}{
+		"user_id":          absent,
+		"starts_at":        time.Now().Add(-time.Minute).UTC().Format(time.RFC3339),
+		"ends_at":          time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+		"reason":           "vacation",
+		"reassign_reviews": true,
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	var added struct {
+		Absence struct {
+			ID int64 `json:"absence_id"`
+		} `json:"absence"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&added)
+
+	var reviews struct {
+		PRs []struct {
+			PRID string `json:"pull_request_id"`
+		} `json:"pull_requests"`
+	}
+	for deadline := time.Now().Add(15 * time.Second); time.Now().Before(deadline); time.Sleep(200 * time.Millisecond) {
+		resp = getJSON(t, baseURL+"/users/getReview?user_id="+absent)
+		reviews.PRs = nil
+		_ = json.NewDecoder(resp.Body).Decode(&reviews)
+		if len(reviews.PRs) == 0 {
+			break
+		}
+	}
+	assert.Empty(t, reviews.PRs)
+
+	var availability struct {
+		IsActive    bool `json:"is_active"`
+		IsAvailable bool `json:"is_available"`
+		Absences    []struct {
+			HandedOverAt *time.Time `json:"handed_over_at"`
+		} `json:"absences"`
+	}
+	resp = getJSON(t, baseURL+"/users/getAbsences?user_id="+absent)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	_ = json.NewDecoder(resp.Body).Decode(&availability)
+	assert.True(t, availability.IsActive)
+	assert.False(t, availability.IsAvailable)
+	if assert.Len(t, availability.Absences, 1) {
+		assert.NotNil(t, availability.Absences[0].HandedOverAt)
+	}
+
+	resp = postJSON(t, baseURL+"/users/deleteAbsence", map[string]int64{"absence_id": added.Absence.ID})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = postJSON(t, baseURL+"/users/deleteAbsence", map[string]int64{"absence_id": added.Absence.ID})
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = getJSON(t, baseURL+"/users/getAbsences?user_id="+absent)
+	_ = json.NewDecoder(resp.Body).Decode(&availability)
+	assert.True(t, availability.IsAvailable)
+}
+
+func TestImportAbsences(t *testing.T) {
+	suffix := "ics_" + now
+	a := "a_" + suffix
+	createTeam(t, "team_"+suffix, []string{a})
+
+	start := time.Now().AddDate(0, 0, 7).UTC()
+	calendar := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nUID:vac-" + suffix + "\r\nSUMMARY:Vacation\\, sea\r\n" +
+		"DTSTART;VALUE=DATE:" + start.Format("20060102") + "\r\nDTEND;VALUE=DATE:" + start.AddDate(0, 0, 5).Format("20060102") + "\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:sync-" + suffix + "\r\nRRULE:FREQ=WEEKLY\r\nDTSTART:20250101T090000Z\r\nDURATION:PT1H\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:past-" + suffix + "\r\nDTSTART:20200101T090000Z\r\nDTEND:20200101T100000Z\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	type importResponse struct {
+		Imported int `json:"imported"`
+		Skipped  int `json:"skipped"`
+		Absences []struct {
+			Reason string `json:"reason"`
+		} `json:"absences"`
+	}
+	for i := 0; i < 2; i++ {
+		resp := postJSON(t, baseURL+"/users/importAbsences", map[string]interface{}{"user_id": a, "calendar": calendar})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var imported importResponse
+		_ = json.NewDecoder(resp.Body).Decode(&imported)
+		assert.Equal(t, 1, imported.Imported)
+		assert.Equal(t, 2, imported.Skipped)
+		if assert.Len(t, imported.Absences, 1) {
+			assert.Equal(t, "Vacation, sea", imported.Absences[0].Reason)
+		}
+	}
+
+	resp := getJSON(t, baseURL+"/users/getAbsences?user_id="+a)
+	var availability struct {
+		IsAvailable bool       `json:"is_available"`
+		Absences    []struct{} `json:"absences"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&availability)
+	assert.True(t, availability.IsAvailable)
+	assert.Len(t, availability.Absences, 1)
+
+	resp = postJSON(t, baseURL+"/users/importAbsences", map[string]interface{}{"user_id": a, "calendar": "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:x\r\n"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
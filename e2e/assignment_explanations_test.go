@@ -0,0 +1,88 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type explanation struct {
+	Action         string `json:"action"`
+	ReplacedUserID string `json:"replaced_user_id"`
+	Reviewers      []struct {
+		UserID string `json:"user_id"`
+		Source string `json:"source"`
+	} `json:"reviewers"`
+	Candidates []struct {
+		UserID   string   `json:"user_id"`
+		Eligible bool     `json:"eligible"`
+		Reasons  []string `json:"ineligible_reasons"`
+		Rank     int      `json:"rank"`
+		Chosen   bool     `json:"chosen"`
+	} `json:"candidates"`
+}
+
+func TestAssignPreviewAndExplain(t *testing.T) {
+	suffix := "expl_" + now
+	a, u1, u2, u3, blocked := "a_"+suffix, "u1_"+suffix, "u2_"+suffix, "u3_"+suffix, "b_"+suffix
+	createTeam(t, "team_"+suffix, []string{a, u1, u2, u3, blocked})
+
+	resp := postJSON(t, baseURL+"/pairRules/set", map[string]string{"reviewer_id": blocked, "author_id": a, "kind": "never"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body := map[string]string{"pull_request_id": "pr_" + suffix, "pull_request_name": "explain", "author_id": a}
+	resp = postJSON(t, baseURL+"/pullRequest/assignPreview", body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var preview struct {
+		PR struct {
+			Reviewers []string `json:"assigned_reviewers"`
+		} `json:"pr"`
+		Explanation explanation `json:"explanation"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&preview)
+	assert.Len(t, preview.PR.Reviewers, 2)
+	assert.Equal(t, "create", preview.Explanation.Action)
+	assert.Len(t, preview.Explanation.Candidates, 5)
+	for _, c := range preview.Explanation.Candidates {
+		switch c.UserID {
+		case a:
+			assert.Equal(t, []string{"author"}, c.Reasons)
+		case blocked:
+			assert.Equal(t, []string{"excluded_by_rule"}, c.Reasons)
+		default:
+			assert.True(t, c.Eligible)
+			assert.NotZero(t, c.Rank)
+		}
+	}
+
+	resp = getJSON(t, baseURL+"/pullRequest/explain?pull_request_id=pr_"+suffix)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = postJSON(t, baseURL+"/pullRequest/create", body)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created struct {
+		PR struct {
+			Reviewers []string `json:"assigned_reviewers"`
+		} `json:"pr"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&created)
+	assert.Equal(t, preview.PR.Reviewers, created.PR.Reviewers)
+
+	resp = postJSON(t, baseURL+"/pullRequest/reassign", map[string]string{"pull_request_id": "pr_" + suffix, "old_reviewer_id": created.PR.Reviewers[0]})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = getJSON(t, baseURL+"/pullRequest/explain?pull_request_id=pr_"+suffix)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var explained struct {
+		Explanations []explanation `json:"explanations"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&explained)
+	if assert.Len(t, explained.Explanations, 2) {
+		assert.Equal(t, "create", explained.Explanations[0].Action)
+		assert.Equal(t, "team", explained.Explanations[0].Reviewers[0].Source)
+		assert.Equal(t, "reassign", explained.Explanations[1].Action)
+		assert.Equal(t, created.PR.Reviewers[0], explained.Explanations[1].ReplacedUserID)
+	}
+}
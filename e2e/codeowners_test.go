@@ -0,0 +1,58 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodeownersAssignment(t *testing.T) {
+	suffix := "co_" + now
+	a, b, c, o, d := "a_"+suffix, "b_"+suffix, "c_"+suffix, "o_"+suffix, "d_"+suffix
+	repo := "acme/" + suffix
+	createTeam(t, "team_"+suffix, []string{a, b, c})
+	createTeam(t, "owners_"+suffix, []string{o})
+	createTeam(t, "docs_"+suffix, []string{d})
+
+	resp := postJSON(t, baseURL+"/codeowners/upload", map[string]string{
+		"repository": repo,
+		"content":    "!secret @x\n",
+	})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = postJSON(t, baseURL+"/codeowners/upload", map[string]string{
+		"repository": repo,
+		"content":    "# owners\n*.go @acme/owners_" + suffix + "\n/docs/ @" + d + "\n/docs/generated/\n",
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = postJSON(t, baseURL+"/pullRequest/create", map[string]interface{}{
+		"pull_request_id":   "pr_" + suffix,
+		"pull_request_name": "owned",
+		"author_id":         a,
+		"repository":        repo,
+		"changed_files":     []string{"cmd/main.go", "internal/x.go", "docs/readme.md", "docs/generated/api.md"},
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created struct {
+		PR struct {
+			Reviewers          []string `json:"assigned_reviewers"`
+			CodeownerReviewers []struct {
+				UserID  string `json:"user_id"`
+				Owner   string `json:"owner"`
+				Pattern string `json:"pattern"`
+				Line    int    `json:"line"`
+			} `json:"codeowner_reviewers"`
+		} `json:"pr"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&created)
+	assert.ElementsMatch(t, []string{o, d}, created.PR.Reviewers)
+	if assert.Equal(t, 2, len(created.PR.CodeownerReviewers)) {
+		assert.Equal(t, o, created.PR.CodeownerReviewers[0].UserID)
+		assert.Equal(t, "*.go", created.PR.CodeownerReviewers[0].Pattern)
+		assert.Equal(t, 2, created.PR.CodeownerReviewers[0].Line)
+		assert.Equal(t, "@"+d, created.PR.CodeownerReviewers[1].Owner)
+	}
+}
@@ -0,0 +1,199 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const workers = 16
+
+func createTeam(t *testing.T, teamName string, userIDs []string) {
+	members := make([]map[string]interface{}, 0, len(userIDs))
+	for _, id := range userIDs {
+		members = append(members, map[string]interface{}{"user_id": id, "username": id, "is_active": true})
+	}
+
+	resp := postJSON(t, baseURL+"/team/add", map[string]interface{}{"team_name": teamName, "members": members})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+}
+
+func mergedReviewers(t *testing.T, prID string) []string {
+	resp := postJSON(t, baseURL+"/pullRequest/merge", map[string]string{"pull_request_id": prID})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var prResp struct {
+		PR struct {
+			Reviewers []string `json:"assigned_reviewers"`
+		} `json:"pr"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&prResp)
+	return prResp.PR.Reviewers
+}
+
+func assertReviewerInvariants(t *testing.T, reviewers []string, authorID string, team map[string]bool) {
+	seen := make(map[string]bool)
+	assert.True(t, len(reviewers) <= 2)
+	for _, id := range reviewers {
+		assert.False(t, seen[id], "duplicate reviewer %s", id)
+		assert.NotEqual(t, authorID, id)
+		assert.True(t, team[id], "reviewer %s is not in the author's team", id)
+		seen[id] = true
+	}
+}
+
+func TestConcurrentCreateSamePR(t *testing.T) {
+	suffix := "cc_" + now
+	users := []string{"a_" + suffix, "b_" + suffix, "c_" + suffix}
+	createTeam(t, "team_"+suffix, users)
+
+	var created, exists int
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp := postJSON(t, baseURL+"/pullRequest/create", map[string]string{
+				"pull_request_id":   "pr_" + suffix,
+				"pull_request_name": "concurrent create",
+				"author_id":         users[0],
+			})
+			mu.Lock()
+			defer mu.Unlock()
+			switch resp.StatusCode {
+			case http.StatusCreated:
+				created++
+			case http.StatusBadRequest:
+				exists++
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, created)
+	assert.Equal(t, workers-1, exists)
+}
+
+func TestConcurrentReassignInvariants(t *testing.T) {
+	suffix := "cr_" + now
+	users := make([]string, 0, 6)
+	team := make(map[string]bool)
+	for i := 0; i < 6; i++ {
+		id := fmt.Sprintf("u%d_%s", i, suffix)
+		users = append(users, id)
+		team[id] = true
+	}
+	createTeam(t, "team_"+suffix, users)
+
+	prID := "pr_" + suffix
+	resp := postJSON(t, baseURL+"/pullRequest/create", map[string]string{
+		"pull_request_id":   prID,
+		"pull_request_name": "concurrent reassign",
+		"author_id":         users[0],
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		for _, oldID := range users[1:] {
+			wg.Add(1)
+			go func(oldID string) {
+				defer wg.Done()
+				resp := postJSON(t, baseURL+"/pullRequest/reassign", map[string]string{
+					"pull_request_id": prID,
+					"old_reviewer_id": oldID,
+				})
+				assert.Contains(t, []int{http.StatusOK, http.StatusConflict}, resp.StatusCode)
+			}(oldID)
+		}
+	}
+	wg.Wait()
+
+	reviewers := mergedReviewers(t, prID)
+	assert.Equal(t, 2, len(reviewers))
+	assertReviewerInvariants(t, reviewers, users[0], team)
+}
+
+func TestConcurrentMergeAndReassign(t *testing.T) {
+	suffix := "cm_" + now
+	users := []string{"a_" + suffix, "b_" + suffix, "c_" + suffix, "d_" + suffix, "e_" + suffix}
+	team := make(map[string]bool)
+	for _, id := range users {
+		team[id] = true
+	}
+	createTeam(t, "team_"+suffix, users)
+
+	prID := "pr_" + suffix
+	resp := postJSON(t, baseURL+"/pullRequest/create", map[string]string{
+		"pull_request_id":   prID,
+		"pull_request_name": "concurrent merge",
+		"author_id":         users[0],
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			resp := postJSON(t, baseURL+"/pullRequest/merge", map[string]string{"pull_request_id": prID})
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+		}()
+		go func(oldID string) {
+			defer wg.Done()
+			resp := postJSON(t, baseURL+"/pullRequest/reassign", map[string]string{
+				"pull_request_id": prID,
+				"old_reviewer_id": oldID,
+			})
+			assert.Contains(t, []int{http.StatusOK, http.StatusConflict}, resp.StatusCode)
+		}(users[1+i%(len(users)-1)])
+	}
+	wg.Wait()
+
+	reviewers := mergedReviewers(t, prID)
+	assertReviewerInvariants(t, reviewers, users[0], team)
+
+	for _, oldID := range reviewers {
+		resp := postJSON(t, baseURL+"/pullRequest/reassign", map[string]string{
+			"pull_request_id": prID,
+			"old_reviewer_id": oldID,
+		})
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	}
+	assert.Equal(t, reviewers, mergedReviewers(t, prID))
+}
+
+func TestConcurrentRenameToSameName(t *testing.T) {
+	suffix := "crn_" + now
+	teams := []string{"t1_" + suffix, "t2_" + suffix, "t3_" + suffix}
+	for i, name := range teams {
+		createTeam(t, name, []string{fmt.Sprintf("u%d_%s", i, suffix)})
+	}
+
+	var (
+		mu      sync.Mutex
+		renamed int
+		wg      sync.WaitGroup
+	)
+	for _, name := range teams {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			resp := postJSON(t, baseURL+"/team/rename", map[string]string{"team_name": name, "new_team_name": "same_" + suffix})
+			assert.Contains(t, []int{http.StatusOK, http.StatusBadRequest}, resp.StatusCode)
+			if resp.StatusCode == http.StatusOK {
+				mu.Lock()
+				renamed++
+				mu.Unlock()
+			}
+		}(name)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, renamed)
+}
@@ -0,0 +1,67 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func postJSONWithKey(t *testing.T, url, key string, payload interface{}) (*http.Response, []byte) {
+	body, _ := json.Marshal(payload)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", key)
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	respBody, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	return resp, respBody
+}
+
+func TestIdempotentCreateAndReassign(t *testing.T) {
+	suffix := "idem_" + now
+	users := []string{"a_" + suffix, "b_" + suffix, "c_" + suffix, "d_" + suffix}
+	createTeam(t, "team_"+suffix, users)
+
+	create := map[string]string{
+		"pull_request_id":   "pr_" + suffix,
+		"pull_request_name": "idempotent create",
+		"author_id":         users[0],
+	}
+	first, firstBody := postJSONWithKey(t, baseURL+"/pullRequest/create", "create-"+suffix, create)
+	assert.Equal(t, http.StatusCreated, first.StatusCode)
+
+	retry, retryBody := postJSONWithKey(t, baseURL+"/pullRequest/create", "create-"+suffix, create)
+	assert.Equal(t, http.StatusCreated, retry.StatusCode)
+	assert.Equal(t, "true", retry.Header.Get("Idempotent-Replayed"))
+	assert.Equal(t, firstBody, retryBody)
+
+	create["pull_request_name"] = "another name"
+	reused, _ := postJSONWithKey(t, baseURL+"/pullRequest/create", "create-"+suffix, create)
+	assert.Equal(t, http.StatusUnprocessableEntity, reused.StatusCode)
+
+	var prResp struct {
+		PR struct {
+			Reviewers []string `json:"assigned_reviewers"`
+		} `json:"pr"`
+	}
+	_ = json.Unmarshal(firstBody, &prResp)
+	assert.NotEmpty(t, prResp.PR.Reviewers)
+
+	reassign := map[string]string{
+		"pull_request_id": "pr_" + suffix,
+		"old_reviewer_id": prResp.PR.Reviewers[0],
+	}
+	first, firstBody = postJSONWithKey(t, baseURL+"/pullRequest/reassign", "reassign-"+suffix, reassign)
+	assert.Equal(t, http.StatusOK, first.StatusCode)
+
+	retry, retryBody = postJSONWithKey(t, baseURL+"/pullRequest/reassign", "reassign-"+suffix, reassign)
+	assert.Equal(t, http.StatusOK, retry.StatusCode)
+	assert.Equal(t, firstBody, retryBody)
+}
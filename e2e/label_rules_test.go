@@ -0,0 +1,58 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabelRuleRequiresCrossTeamReviewer(t *testing.T) {
+	suffix := "lr_" + now
+	a, b, c, d1, d2 := "a_"+suffix, "b_"+suffix, "c_"+suffix, "d1_"+suffix, "d2_"+suffix
+	label := "db_" + suffix
+	createTeam(t, "team_"+suffix, []string{a, b, c})
+	createTeam(t, "dba_"+suffix, []string{d1, d2})
+
+	resp := postJSON(t, baseURL+"/labelRules/set", map[string]interface{}{"label": label, "team_name": "dba_" + suffix, "reviewers_count": 1})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	type prResponse struct {
+		PR struct {
+			Reviewers         []string            `json:"assigned_reviewers"`
+			RequiredReviewers []map[string]string `json:"required_reviewers"`
+		} `json:"pr"`
+	}
+
+	resp = postJSON(t, baseURL+"/pullRequest/create", map[string]interface{}{
+		"pull_request_id":   "pr_" + suffix,
+		"pull_request_name": "migration",
+		"author_id":         a,
+		"labels":            []string{label},
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created prResponse
+	_ = json.NewDecoder(resp.Body).Decode(&created)
+	assert.Equal(t, 3, len(created.PR.Reviewers))
+	if !assert.Equal(t, 1, len(created.PR.RequiredReviewers)) {
+		return
+	}
+	required := created.PR.RequiredReviewers[0]["user_id"]
+	assert.Contains(t, []string{d1, d2}, required)
+	assert.Equal(t, "dba_"+suffix, created.PR.RequiredReviewers[0]["team_name"])
+
+	resp = postJSON(t, baseURL+"/pullRequest/reassign", map[string]string{"pull_request_id": "pr_" + suffix, "old_reviewer_id": required})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var reassigned prResponse
+	_ = json.NewDecoder(resp.Body).Decode(&reassigned)
+	if assert.Equal(t, 1, len(reassigned.PR.RequiredReviewers)) {
+		assert.Contains(t, []string{d1, d2}, reassigned.PR.RequiredReviewers[0]["user_id"])
+		assert.NotEqual(t, required, reassigned.PR.RequiredReviewers[0]["user_id"])
+	}
+
+	resp = postJSON(t, baseURL+"/labelRules/delete", map[string]string{"label": label, "team_name": "dba_" + suffix})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = postJSON(t, baseURL+"/labelRules/delete", map[string]string{"label": label, "team_name": "dba_" + suffix})
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
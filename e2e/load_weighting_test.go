@@ -0,0 +1,83 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type loadWeightBucket struct {
+	MaxLines int     `json:"max_lines"`
+	Weight   float64 `json:"weight"`
+}
+
+type loadWeighting struct {
+	Mode         string             `json:"mode"`
+	LogBaseLines int                `json:"log_base_lines,omitempty"`
+	Buckets      []loadWeightBucket `json:"buckets,omitempty"`
+}
+
+func TestLoadWeighting(t *testing.T) {
+	suffix := "weight_" + now
+	a, u1, u2, u3, u4 := "a_"+suffix, "u1_"+suffix, "u2_"+suffix, "u3_"+suffix, "u4_"+suffix
+	createTeam(t, "team_"+suffix, []string{a, u1, u2, u3, u4})
+
+	for _, bad := range []loadWeighting{
+		{Mode: "size"},
+		{Mode: "buckets"},
+		{Mode: "buckets", Buckets: []loadWeightBucket{{MaxLines: 10, Weight: 0}}},
+		{Mode: "buckets", Buckets: []loadWeightBucket{{MaxLines: 10, Weight: 1}, {MaxLines: 10, Weight: 2}}},
+		{Mode: "log", LogBaseLines: -1},
+		{Mode: "count", Buckets: []loadWeightBucket{{MaxLines: 10, Weight: 1}}},
+	} {
+		resp := postJSON(t, baseURL+"/loadWeighting/set", bad)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	}
+
+	weighting := loadWeighting{Mode: "buckets", Buckets: []loadWeightBucket{{MaxLines: 100000, Weight: 10}, {MaxLines: 10, Weight: 1}}}
+	resp := postJSON(t, baseURL+"/loadWeighting/set", weighting)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	defer postJSON(t, baseURL+"/loadWeighting/set", loadWeighting{Mode: "count"})
+
+	resp = getJSON(t, baseURL+"/loadWeighting/get")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var got loadWeighting
+	_ = json.NewDecoder(resp.Body).Decode(&got)
+	assert.Equal(t, loadWeighting{Mode: "buckets", Buckets: []loadWeightBucket{{MaxLines: 10, Weight: 1}, {MaxLines: 100000, Weight: 10}}}, got)
+
+	create := func(id string, lines int) []string {
+		resp := postJSON(t, baseURL+"/pullRequest/create", map[string]any{"pull_request_id": id, "pull_request_name": "weight", "author_id": a, "lines_added": lines})
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		var created reviewersResponse
+		_ = json.NewDecoder(resp.Body).Decode(&created)
+		return created.PR.Reviewers
+	}
+	big := create("pr1_"+suffix, 2000)
+	assert.ElementsMatch(t, []string{u1, u2}, big)
+	small := create("pr2_"+suffix, 1)
+	assert.ElementsMatch(t, []string{u3, u4}, small)
+	assert.ElementsMatch(t, small, create("pr3_"+suffix, 1))
+
+	resp = getJSON(t, baseURL+"/stats/users")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var stats struct {
+		Stats []struct {
+			UserID       string  `json:"user_id"`
+			Reviews      int64   `json:"count_pr_reviewer"`
+			WeightedLoad float64 `json:"weighted_load"`
+		} `json:"statistic"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&stats)
+	for _, s := range stats.Stats {
+		switch s.UserID {
+		case u1:
+			assert.Equal(t, int64(1), s.Reviews)
+			assert.Equal(t, 10.0, s.WeightedLoad)
+		case u3:
+			assert.Equal(t, int64(2), s.Reviews)
+			assert.Equal(t, 2.0, s.WeightedLoad)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type reviewersResponse struct {
+	PR struct {
+		Reviewers []string `json:"assigned_reviewers"`
+	} `json:"pr"`
+	ReplacedBy string `json:"replaced_by"`
+}
+
+func errorCode(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	var errResp struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&errResp)
+	return errResp.Error.Code
+}
+
+func TestManualReviewers(t *testing.T) {
+	suffix := "manual_" + now
+	a, u1, u2, u3, u4 := "a_"+suffix, "u1_"+suffix, "u2_"+suffix, "u3_"+suffix, "u4_"+suffix
+	createTeam(t, "team_"+suffix, []string{a, u1, u2, u3, u4})
+	outsider := "out_" + suffix
+	createTeam(t, "other_"+suffix, []string{outsider})
+
+	resp := postJSON(t, baseURL+"/pullRequest/create", map[string]string{"pull_request_id": "pr_" + suffix, "pull_request_name": "manual", "author_id": a})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created reviewersResponse
+	_ = json.NewDecoder(resp.Body).Decode(&created)
+	assert.Len(t, created.PR.Reviewers, 2)
+
+	free := make([]string, 0, 2)
+	for _, u := range []string{u1, u2, u3, u4} {
+		if !slices.Contains(created.PR.Reviewers, u) {
+			free = append(free, u)
+		}
+	}
+
+	for user, code := range map[string]string{a: "REVIEWER_IS_AUTHOR", created.PR.Reviewers[0]: "ALREADY_ASSIGNED", outsider: "NOT_IN_REVIEWER_POOL"} {
+		resp = postJSON(t, baseURL+"/pullRequest/addReviewer", map[string]string{"pull_request_id": "pr_" + suffix, "reviewer_id": user})
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+		assert.Equal(t, code, errorCode(t, resp))
+	}
+
+	resp = postJSON(t, baseURL+"/pullRequest/addReviewer", map[string]string{"pull_request_id": "pr_" + suffix, "reviewer_id": free[0]})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var added reviewersResponse
+	_ = json.NewDecoder(resp.Body).Decode(&added)
+	assert.Len(t, added.PR.Reviewers, 3)
+	assert.Contains(t, added.PR.Reviewers, free[0])
+
+	resp = postJSON(t, baseURL+"/pullRequest/reassign", map[string]string{"pull_request_id": "pr_" + suffix, "old_reviewer_id": free[0], "new_reviewer_id": free[1]})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var reassigned reviewersResponse
+	_ = json.NewDecoder(resp.Body).Decode(&reassigned)
+	assert.Equal(t, free[1], reassigned.ReplacedBy)
+	assert.NotContains(t, reassigned.PR.Reviewers, free[0])
+
+	resp = postJSON(t, baseURL+"/pullRequest/removeReviewer", map[string]string{"pull_request_id": "pr_" + suffix, "reviewer_id": free[1]})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var removed reviewersResponse
+	_ = json.NewDecoder(resp.Body).Decode(&removed)
+	assert.ElementsMatch(t, created.PR.Reviewers, removed.PR.Reviewers)
+
+	resp = postJSON(t, baseURL+"/pullRequest/removeReviewer", map[string]string{"pull_request_id": "pr_" + suffix, "reviewer_id": free[1]})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "NOT_ASSIGNED", errorCode(t, resp))
+
+	resp = postJSON(t, baseURL+"/pullRequest/merge", map[string]string{"pull_request_id": "pr_" + suffix})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = postJSON(t, baseURL+"/pullRequest/addReviewer", map[string]string{"pull_request_id": "pr_" + suffix, "reviewer_id": free[0]})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "PR_MERGED", errorCode(t, resp))
+}
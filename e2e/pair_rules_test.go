@@ -0,0 +1,64 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPairRules(t *testing.T) {
+	suffix := "pair_" + now
+	a, mentor, manager, o1, o2 := "a_"+suffix, "m_"+suffix, "x_"+suffix, "o1_"+suffix, "o2_"+suffix
+	createTeam(t, "team_"+suffix, []string{a, mentor, manager, o1, o2})
+
+	resp := postJSON(t, baseURL+"/pairRules/set", map[string]string{"reviewer_id": a, "author_id": a, "kind": "never"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = postJSON(t, baseURL+"/pairRules/set", map[string]string{"reviewer_id": manager, "author_id": a, "kind": "sometimes"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = postJSON(t, baseURL+"/pairRules/set", map[string]string{"reviewer_id": "ghost_" + suffix, "author_id": a, "kind": "never"})
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = postJSON(t, baseURL+"/pairRules/set", map[string]string{"reviewer_id": manager, "author_id": a, "kind": "never"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = postJSON(t, baseURL+"/pairRules/set", map[string]string{"reviewer_id": mentor, "author_id": a, "kind": "prefer"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = getJSON(t, baseURL+"/pairRules/list?user_id="+a)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var list struct {
+		Rules []struct {
+			ReviewerID string `json:"reviewer_id"`
+			Kind       string `json:"kind"`
+		} `json:"rules"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&list)
+	assert.Len(t, list.Rules, 2)
+
+	resp = postJSON(t, baseURL+"/pullRequest/create", map[string]string{"pull_request_id": "pr_" + suffix, "pull_request_name": "pair", "author_id": a})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created struct {
+		PR struct {
+			Reviewers []string `json:"assigned_reviewers"`
+		} `json:"pr"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&created)
+	assert.Len(t, created.PR.Reviewers, 2)
+	assert.Contains(t, created.PR.Reviewers, mentor)
+	assert.NotContains(t, created.PR.Reviewers, manager)
+
+	resp = postJSON(t, baseURL+"/pullRequest/reassign", map[string]string{"pull_request_id": "pr_" + suffix, "old_reviewer_id": mentor})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var reassigned struct {
+		ReplacedBy string `json:"replaced_by"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&reassigned)
+	assert.NotEqual(t, manager, reassigned.ReplacedBy)
+	assert.Contains(t, []string{o1, o2}, reassigned.ReplacedBy)
+
+	resp = postJSON(t, baseURL+"/pairRules/delete", map[string]string{"reviewer_id": manager, "author_id": a})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = postJSON(t, baseURL+"/pairRules/delete", map[string]string{"reviewer_id": manager, "author_id": a})
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,104 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type prListPage struct {
+	PRs []struct {
+		PRID      string   `json:"pull_request_id"`
+		Labels    []string `json:"labels"`
+		Reviewers []string `json:"assigned_reviewers"`
+	} `json:"pull_requests"`
+	NextCursor string `json:"next_cursor"`
+}
+
+func listPRs(t *testing.T, query url.Values) prListPage {
+	resp := getJSON(t, baseURL+"/pullRequest/list?"+query.Encode())
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var page prListPage
+	_ = json.NewDecoder(resp.Body).Decode(&page)
+	return page
+}
+
+func (p prListPage) ids() []string {
+	ids := make([]string, 0, len(p.PRs))
+	for _, pr := range p.PRs {
+		ids = append(ids, pr.PRID)
+	}
+	return ids
+}
+
+func TestPRList(t *testing.T) {
+	suffix := "list_" + now
+	a := "a_" + suffix
+	createTeam(t, "team_"+suffix, []string{a, "u1_" + suffix, "u2_" + suffix})
+
+	for _, pr := range []struct {
+		id, name string
+		labels   []string
+	}{
+		{"pr1_" + suffix, "Alpha " + suffix, []string{"db"}},
+		{"pr2_" + suffix, "Beta " + suffix, nil},
+		{"pr3_" + suffix, "Gamma " + suffix, []string{"DB", "ui"}},
+	} {
+		resp := postJSON(t, baseURL+"/pullRequest/create", map[string]any{"pull_request_id": pr.id, "pull_request_name": pr.name, "author_id": a, "labels": pr.labels})
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	query := url.Values{"author_id": {a}, "sort": {"name"}, "limit": {"2"}}
+	page := listPRs(t, query)
+	assert.Equal(t, []string{"pr1_" + suffix, "pr2_" + suffix}, page.ids())
+	if assert.NotEmpty(t, page.NextCursor) {
+		query.Set("cursor", page.NextCursor)
+		next := listPRs(t, query)
+		assert.Equal(t, []string{"pr3_" + suffix}, next.ids())
+		assert.Empty(t, next.NextCursor)
+
+		query.Set("order", "desc")
+		resp := getJSON(t, baseURL+"/pullRequest/list?"+query.Encode())
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "INVALID_CURSOR", errorCode(t, resp))
+	}
+
+	page = listPRs(t, url.Values{"author_id": {a}, "label": {"Db"}, "sort": {"name"}, "order": {"desc"}})
+	assert.Equal(t, []string{"pr3_" + suffix, "pr1_" + suffix}, page.ids())
+	page = listPRs(t, url.Values{"team_name": {"team_" + suffix}, "name": {"beta " + suffix}})
+	assert.Equal(t, []string{"pr2_" + suffix}, page.ids())
+	if assert.Len(t, page.PRs, 1) && assert.NotEmpty(t, page.PRs[0].Reviewers) {
+		page = listPRs(t, url.Values{"reviewer_id": {page.PRs[0].Reviewers[0]}, "name": {suffix}})
+		assert.Contains(t, page.ids(), "pr2_"+suffix)
+	}
+	page = listPRs(t, url.Values{"author_id": {a}, "status": {"MERGED"}})
+	assert.Empty(t, page.PRs)
+
+	for _, bad := range []string{"status=DRAFT", "sort=age", "limit=0", "created_from=yesterday", "cursor=garbage"} {
+		resp := getJSON(t, baseURL+"/pullRequest/list?"+bad)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	}
+	resp := getJSON(t, baseURL+"/pullRequest/list?team_name=missing_"+suffix)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = getJSON(t, baseURL+"/pullRequest/get?pull_request_id=pr3_"+suffix)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var got struct {
+		PR struct {
+			PRID      string   `json:"pull_request_id"`
+			TeamName  string   `json:"team_name"`
+			Labels    []string `json:"labels"`
+			Reviewers []string `json:"assigned_reviewers"`
+		} `json:"pr"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&got)
+	assert.Equal(t, "team_"+suffix, got.PR.TeamName)
+	assert.Equal(t, []string{"db", "ui"}, got.PR.Labels)
+	assert.Len(t, got.PR.Reviewers, 2)
+
+	resp = getJSON(t, baseURL+"/pullRequest/get?pull_request_id=missing_"+suffix)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,93 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type prMetadata struct {
+	PRID         string    `json:"pull_request_id"`
+	Reviewers    []string  `json:"assigned_reviewers"`
+	Repository   string    `json:"repository"`
+	SourceBranch string    `json:"source_branch"`
+	TargetBranch string    `json:"target_branch"`
+	URL          string    `json:"url"`
+	Description  string    `json:"description"`
+	LinesAdded   int       `json:"lines_added"`
+	LinesRemoved int       `json:"lines_removed"`
+	FilesCount   int       `json:"files_count"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func TestPRMetadata(t *testing.T) {
+	suffix := "meta_" + now
+	createTeam(t, "team_"+suffix, []string{"a_" + suffix, "u1_" + suffix, "u2_" + suffix})
+
+	for _, bad := range []map[string]any{
+		{"url": "example.com/pr/1"},
+		{"url": "ftp://example.com/pr/1"},
+		{"lines_added": -1},
+		{"files_count": -5},
+	} {
+		bad["pull_request_id"] = "bad_" + suffix
+		bad["pull_request_name"] = "meta"
+		bad["author_id"] = "a_" + suffix
+		resp := postJSON(t, baseURL+"/pullRequest/create", bad)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	}
+
+	want := prMetadata{
+		PRID:         "pr_" + suffix,
+		Repository:   "org/repo_" + suffix,
+		SourceBranch: "feature/meta",
+		TargetBranch: "main",
+		URL:          "https://example.com/org/repo/pull/1",
+		Description:  "adds metadata",
+		LinesAdded:   120,
+		LinesRemoved: 30,
+		FilesCount:   2,
+		CreatedAt:    time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	resp := postJSON(t, baseURL+"/pullRequest/create", map[string]any{
+		"pull_request_id":   want.PRID,
+		"pull_request_name": "meta",
+		"author_id":         "a_" + suffix,
+		"repository":        want.Repository,
+		"source_branch":     want.SourceBranch,
+		"target_branch":     want.TargetBranch,
+		"url":               want.URL,
+		"description":       want.Description,
+		"lines_added":       want.LinesAdded,
+		"lines_removed":     want.LinesRemoved,
+		"changed_files":     []string{"a.go", "b.go"},
+		"created_at":        want.CreatedAt,
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created struct {
+		PR prMetadata `json:"pr"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&created)
+	want.Reviewers = created.PR.Reviewers
+	created.PR.CreatedAt = created.PR.CreatedAt.UTC()
+	assert.Equal(t, want, created.PR)
+
+	if assert.NotEmpty(t, created.PR.Reviewers) {
+		resp = getJSON(t, baseURL+"/users/getReview?user_id="+created.PR.Reviewers[0])
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var reviews struct {
+			PRs []prMetadata `json:"pull_requests"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&reviews)
+		idx := slices.IndexFunc(reviews.PRs, func(pr prMetadata) bool { return pr.PRID == want.PRID })
+		if assert.NotEqual(t, -1, idx) {
+			want.Reviewers = nil
+			reviews.PRs[idx].CreatedAt = reviews.PRs[idx].CreatedAt.UTC()
+			assert.Equal(t, want, reviews.PRs[idx])
+		}
+	}
+}
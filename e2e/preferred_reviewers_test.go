@@ -0,0 +1,47 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreferredReviewers(t *testing.T) {
+	suffix := "pref_" + now
+	a, b, c, d := "a_"+suffix, "b_"+suffix, "c_"+suffix, "d_"+suffix
+	createTeam(t, "team_"+suffix, []string{a, b, c, d})
+	createTeam(t, "other_"+suffix, []string{"x_" + suffix})
+
+	type prResponse struct {
+		PR struct {
+			Reviewers          []string `json:"assigned_reviewers"`
+			PreferredReviewers []string `json:"preferred_reviewers"`
+		} `json:"pr"`
+	}
+
+	resp := postJSON(t, baseURL+"/pullRequest/create", map[string]interface{}{
+		"pull_request_id":     "pr1_" + suffix,
+		"pull_request_name":   "preferred",
+		"author_id":           a,
+		"preferred_reviewers": []string{a, "x_" + suffix, "missing_" + suffix, d, c, b},
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created prResponse
+	_ = json.NewDecoder(resp.Body).Decode(&created)
+	assert.Equal(t, []string{d, c}, created.PR.Reviewers)
+	assert.Equal(t, []string{d, c}, created.PR.PreferredReviewers)
+
+	resp = postJSON(t, baseURL+"/pullRequest/create", map[string]interface{}{
+		"pull_request_id":     "pr2_" + suffix,
+		"pull_request_name":   "partly preferred",
+		"author_id":           a,
+		"preferred_reviewers": []string{"x_" + suffix, c},
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	created = prResponse{}
+	_ = json.NewDecoder(resp.Body).Decode(&created)
+	assert.Equal(t, []string{c, b}, created.PR.Reviewers)
+	assert.Equal(t, []string{c}, created.PR.PreferredReviewers)
+}
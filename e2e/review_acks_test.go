@@ -0,0 +1,165 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type declineReason struct {
+	Reason string `json:"reason"`
+	Count  int    `json:"count"`
+}
+
+func TestReviewAcks(t *testing.T) {
+	suffix := "acks_" + now
+	a, u1, u2, u3 := "a_"+suffix, "u1_"+suffix, "u2_"+suffix, "u3_"+suffix
+	createTeam(t, "team_"+suffix, []string{a, u1, u2, u3})
+	reason := "on call " + suffix
+
+	resp := postJSON(t, baseURL+"/pullRequest/create", map[string]string{"pull_request_id": "pr_" + suffix, "pull_request_name": "acks", "author_id": a})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created reviewersResponse
+	_ = json.NewDecoder(resp.Body).Decode(&created)
+	assert.Len(t, created.PR.Reviewers, 2)
+	kept, declining := created.PR.Reviewers[0], created.PR.Reviewers[1]
+
+	resp = postJSON(t, baseURL+"/pullRequest/ack", map[string]string{"pull_request_id": "pr_" + suffix, "reviewer_id": declining, "decision": "decline"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = postJSON(t, baseURL+"/pullRequest/ack", map[string]string{"pull_request_id": "pr_" + suffix, "reviewer_id": a, "decision": "accept"})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "NOT_ASSIGNED", errorCode(t, resp))
+
+	resp = postJSON(t, baseURL+"/pullRequest/ack", map[string]string{"pull_request_id": "missing_" + suffix, "reviewer_id": declining, "decision": "decline", "reason": reason})
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "PR_NOT_FOUND", errorCode(t, resp))
+	resp = postJSON(t, baseURL+"/pullRequest/ack", map[string]string{"pull_request_id": "pr_" + suffix, "reviewer_id": "missing_" + suffix, "decision": "decline", "reason": reason})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "NOT_ASSIGNED", errorCode(t, resp))
+
+	resp = postJSON(t, baseURL+"/pullRequest/ack", map[string]string{"pull_request_id": "pr_" + suffix, "reviewer_id": kept, "decision": "accept"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var accepted struct {
+		AcceptedAt string `json:"accepted_at"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&accepted)
+	assert.NotEmpty(t, accepted.AcceptedAt)
+
+	resp = postJSON(t, baseURL+"/pullRequest/ack", map[string]string{"pull_request_id": "pr_" + suffix, "reviewer_id": declining, "decision": "decline", "reason": reason})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var declined reviewersResponse
+	_ = json.NewDecoder(resp.Body).Decode(&declined)
+	free := slices.DeleteFunc([]string{u1, u2, u3}, func(u string) bool { return slices.Contains(created.PR.Reviewers, u) })
+	assert.Equal(t, free[0], declined.ReplacedBy)
+	assert.ElementsMatch(t, []string{kept, free[0]}, declined.PR.Reviewers)
+
+	resp = postJSON(t, baseURL+"/pullRequest/addReviewer", map[string]string{"pull_request_id": "pr_" + suffix, "reviewer_id": declining})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "REVIEWER_DECLINED", errorCode(t, resp))
+
+	resp = getJSON(t, baseURL+"/stats/declines")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var stats struct {
+		Reasons []declineReason `json:"statistic"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&stats)
+	idx := slices.IndexFunc(stats.Reasons, func(s declineReason) bool { return s.Reason == reason })
+	if assert.NotEqual(t, -1, idx) {
+		assert.Equal(t, 1, stats.Reasons[idx].Count)
+	}
+}
+
+// assignedAt returns when the user was assigned to the PR, from their inbox.
+func assignedAt(t *testing.T, userID, prID string) time.Time {
+	t.Helper()
+	resp := getJSON(t, baseURL+"/users/inbox?user_id="+userID)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var page struct {
+		Reviews []struct {
+			PRID       string    `json:"pull_request_id"`
+			AssignedAt time.Time `json:"assigned_at"`
+		} `json:"reviews"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&page)
+	for _, r := range page.Reviews {
+		if r.PRID == prID {
+			return r.AssignedAt
+		}
+	}
+	t.Fatalf("%s is not assigned to %s", userID, prID)
+	return time.Time{}
+}
+
+// TestAckDeadline needs the server started with the same short ACK_DEADLINE
+// (e.g. 2s) and REVIEW_QUEUE_INTERVAL as the test.
+func TestAckDeadline(t *testing.T) {
+	deadline, err := time.ParseDuration(os.Getenv("ACK_DEADLINE"))
+	if err != nil {
+		t.Skip("ACK_DEADLINE is not set")
+	}
+	interval := 10 * time.Second
+	if v, err := time.ParseDuration(os.Getenv("REVIEW_QUEUE_INTERVAL")); err == nil {
+		interval = v
+	}
+
+	suffix := "ackdeadline_" + now
+	a, u1, u2, u3 := "a_"+suffix, "u1_"+suffix, "u2_"+suffix, "u3_"+suffix
+	createTeam(t, "team_"+suffix, []string{a, u1, u2, u3})
+
+	resp := postJSON(t, baseURL+"/pullRequest/create", map[string]string{"pull_request_id": "pr_" + suffix, "pull_request_name": "ack deadline", "author_id": a})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created reviewersResponse
+	_ = json.NewDecoder(resp.Body).Decode(&created)
+	if !assert.Len(t, created.PR.Reviewers, 2) {
+		return
+	}
+	free := slices.DeleteFunc([]string{u1, u2, u3}, func(u string) bool { return slices.Contains(created.PR.Reviewers, u) })[0]
+	assigned := map[string]time.Time{}
+	for _, r := range created.PR.Reviewers {
+		assigned[r] = assignedAt(t, r, "pr_"+suffix)
+	}
+
+	// Both reviews expire together: one goes to the free member, the other has
+	// nobody left to take it and stays with its reviewer.
+	var reviewers []string
+	for end := time.Now().Add(deadline + 3*interval); time.Now().Before(end) && !slices.Contains(reviewers, free); time.Sleep(200 * time.Millisecond) {
+		resp = getJSON(t, baseURL+"/pullRequest/get?pull_request_id=pr_"+suffix)
+		var pr reviewersResponse
+		_ = json.NewDecoder(resp.Body).Decode(&pr)
+		reviewers = pr.PR.Reviewers
+	}
+	if !assert.Len(t, reviewers, 2) || !assert.Contains(t, reviewers, free) {
+		return
+	}
+	kept := slices.DeleteFunc(slices.Clone(reviewers), func(u string) bool { return u == free })[0]
+	assert.Contains(t, created.PR.Reviewers, kept)
+
+	// Restarting the deadline of the kept review does not move its assignment.
+	time.Sleep(interval)
+	assert.True(t, assigned[kept].Equal(assignedAt(t, kept, "pr_"+suffix)))
+
+	resp = getJSON(t, baseURL+"/stats/users")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var stats struct {
+		Stats []struct {
+			UserID     string `json:"user_id"`
+			AckExpired int    `json:"count_ack_expired"`
+		} `json:"statistic"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&stats)
+	expired := map[string]int{}
+	for _, s := range stats.Stats {
+		expired[s.UserID] = s.AckExpired
+	}
+	for _, r := range created.PR.Reviewers {
+		if r != kept {
+			assert.Equal(t, 1, expired[r])
+		}
+	}
+	assert.Equal(t, 0, expired[kept])
+}
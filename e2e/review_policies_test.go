@@ -0,0 +1,141 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReviewPolicy(t *testing.T) {
+	suffix := "pol_" + now
+	a, junior, mid, senior, bot := "a_"+suffix, "j_"+suffix, "m_"+suffix, "s_"+suffix, "bot_"+suffix
+	createTeam(t, "team_"+suffix, []string{a, junior, mid, senior, bot})
+
+	for user, level := range map[string]string{junior: "junior", senior: "senior", bot: "bot"} {
+		resp := postJSON(t, baseURL+"/users/setSeniority", map[string]string{"user_id": user, "seniority": level})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	resp := postJSON(t, baseURL+"/users/setSeniority", map[string]string{"user_id": mid, "seniority": "guru"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = postJSON(t, baseURL+"/team/setReviewPolicy", map[string]interface{}{
+		"team_name":      "team_" + suffix,
+		"min_seniors":    1,
+		"no_sole_junior": true,
+		"exclude_bots":   true,
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = getJSON(t, baseURL+"/team/getReviewPolicy?team_name=team_"+suffix)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var policy struct {
+		MinSeniors   int  `json:"min_seniors"`
+		NoSoleJunior bool `json:"no_sole_junior"`
+		ExcludeBots  bool `json:"exclude_bots"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&policy)
+	assert.Equal(t, 1, policy.MinSeniors)
+	assert.True(t, policy.NoSoleJunior)
+	assert.True(t, policy.ExcludeBots)
+
+	resp = postJSON(t, baseURL+"/pullRequest/create", map[string]string{"pull_request_id": "pr_" + suffix, "pull_request_name": "policy", "author_id": a})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created struct {
+		PR struct {
+			Reviewers []string `json:"assigned_reviewers"`
+		} `json:"pr"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&created)
+	assert.Len(t, created.PR.Reviewers, 2)
+	assert.Contains(t, created.PR.Reviewers, senior)
+	assert.NotContains(t, created.PR.Reviewers, bot)
+
+	resp = postJSON(t, baseURL+"/pullRequest/reassign", map[string]string{"pull_request_id": "pr_" + suffix, "old_reviewer_id": senior})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	var errResp struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&errResp)
+	assert.Equal(t, "POLICY_MIN_SENIORS", errResp.Error.Code)
+}
+
+func TestReviewPolicySoleJunior(t *testing.T) {
+	suffix := "polj_" + now
+	a, junior := "a_"+suffix, "j_"+suffix
+	createTeam(t, "team_"+suffix, []string{a, junior})
+
+	resp := postJSON(t, baseURL+"/users/setSeniority", map[string]string{"user_id": junior, "seniority": "junior"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = postJSON(t, baseURL+"/team/setReviewPolicy", map[string]interface{}{"team_name": "team_" + suffix, "no_sole_junior": true})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = postJSON(t, baseURL+"/pullRequest/create", map[string]string{"pull_request_id": "pr_" + suffix, "pull_request_name": "junior", "author_id": a})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	var errResp struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&errResp)
+	assert.Equal(t, "POLICY_SOLE_JUNIOR", errResp.Error.Code)
+}
+
+func TestReviewPolicyQueuesCappedSeniors(t *testing.T) {
+	suffix := "polq_" + now
+	a, senior, m1, m2 := "a_"+suffix, "s_"+suffix, "m1_"+suffix, "m2_"+suffix
+	createTeam(t, "team_"+suffix, []string{a, senior, m1, m2})
+
+	resp := postJSON(t, baseURL+"/users/setSeniority", map[string]string{"user_id": senior, "seniority": "senior"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = postJSON(t, baseURL+"/users/setCapacity", map[string]interface{}{"user_id": senior, "max_open_reviews": 1})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = postJSON(t, baseURL+"/team/setReviewPolicy", map[string]interface{}{"team_name": "team_" + suffix, "min_seniors": 1})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	type prResponse struct {
+		PR struct {
+			Reviewers        []string `json:"assigned_reviewers"`
+			AssignmentStatus string   `json:"assignment_status"`
+			PendingReviewers int      `json:"pending_reviewers"`
+		} `json:"pr"`
+	}
+
+	resp = postJSON(t, baseURL+"/pullRequest/create", map[string]string{"pull_request_id": "pr1_" + suffix, "pull_request_name": "first", "author_id": a})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	var first prResponse
+	_ = json.NewDecoder(resp.Body).Decode(&first)
+	assert.Contains(t, first.PR.Reviewers, senior)
+
+	// The only senior is at their cap, so the PR waits for them.
+	resp = postJSON(t, baseURL+"/pullRequest/create", map[string]string{"pull_request_id": "pr2_" + suffix, "pull_request_name": "second", "author_id": a})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	var second prResponse
+	_ = json.NewDecoder(resp.Body).Decode(&second)
+	assert.Empty(t, second.PR.Reviewers)
+	assert.Equal(t, "PENDING_REVIEWERS", second.PR.AssignmentStatus)
+	assert.Equal(t, 2, second.PR.PendingReviewers)
+
+	resp = postJSON(t, baseURL+"/pullRequest/merge", map[string]string{"pull_request_id": "pr1_" + suffix})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var reviewers []string
+	for deadline := time.Now().Add(15 * time.Second); time.Now().Before(deadline) && len(reviewers) < 2; time.Sleep(200 * time.Millisecond) {
+		resp = getJSON(t, baseURL+"/pullRequest/get?pull_request_id=pr2_"+suffix)
+		var pr prResponse
+		_ = json.NewDecoder(resp.Body).Decode(&pr)
+		reviewers = pr.PR.Reviewers
+	}
+	assert.Len(t, reviewers, 2)
+	assert.Contains(t, reviewers, senior)
+
+	// Without any senior in the team the policy still fails the PR.
+	resp = postJSON(t, baseURL+"/users/setSeniority", map[string]string{"user_id": senior, "seniority": "mid"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = postJSON(t, baseURL+"/pullRequest/create", map[string]string{"pull_request_id": "pr3_" + suffix, "pull_request_name": "third", "author_id": a})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
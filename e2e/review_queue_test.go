@@ -0,0 +1,69 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCapacityQueue(t *testing.T) {
+	suffix := "cap_" + now
+	a, b, c := "a_"+suffix, "b_"+suffix, "c_"+suffix
+	createTeam(t, "team_"+suffix, []string{a, b, c})
+
+	resp := postJSON(t, baseURL+"/team/setCapacity", map[string]interface{}{"team_name": "team_" + suffix, "max_open_reviews": 1})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	type prResponse struct {
+		PR struct {
+			Reviewers        []string `json:"assigned_reviewers"`
+			AssignmentStatus string   `json:"assignment_status"`
+			PendingReviewers int      `json:"pending_reviewers"`
+		} `json:"pr"`
+	}
+
+	resp = postJSON(t, baseURL+"/pullRequest/create", map[string]string{"pull_request_id": "pr1_" + suffix, "pull_request_name": "first", "author_id": a})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	var first prResponse
+	_ = json.NewDecoder(resp.Body).Decode(&first)
+	assert.ElementsMatch(t, []string{b, c}, first.PR.Reviewers)
+	assert.Empty(t, first.PR.AssignmentStatus)
+
+	resp = postJSON(t, baseURL+"/pullRequest/create", map[string]string{"pull_request_id": "pr2_" + suffix, "pull_request_name": "second", "author_id": a})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	var second prResponse
+	_ = json.NewDecoder(resp.Body).Decode(&second)
+	assert.Empty(t, second.PR.Reviewers)
+	assert.Equal(t, "PENDING_REVIEWERS", second.PR.AssignmentStatus)
+	assert.Equal(t, 2, second.PR.PendingReviewers)
+
+	resp = postJSON(t, baseURL+"/pullRequest/close", map[string]string{"pull_request_id": "pr1_" + suffix})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = postJSON(t, baseURL+"/pullRequest/merge", map[string]string{"pull_request_id": "pr1_" + suffix})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	var events struct {
+		Events []struct {
+			Type    string `json:"event_type"`
+			PRID    string `json:"pull_request_id"`
+			Payload struct {
+				Reviewers []string `json:"assigned_reviewers"`
+			} `json:"payload"`
+		} `json:"events"`
+	}
+	assigned := []string{}
+	for deadline := time.Now().Add(15 * time.Second); time.Now().Before(deadline) && len(assigned) < 2; time.Sleep(200 * time.Millisecond) {
+		assigned = assigned[:0]
+		resp = getJSON(t, baseURL+"/events?limit=1000")
+		_ = json.NewDecoder(resp.Body).Decode(&events)
+		for _, e := range events.Events {
+			if e.Type == "pr.reviewers_assigned" && e.PRID == "pr2_"+suffix {
+				assigned = append(assigned, e.Payload.Reviewers...)
+			}
+		}
+	}
+	assert.ElementsMatch(t, []string{b, c}, assigned)
+}
@@ -0,0 +1,216 @@
+package e2e
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+type slaPolicy struct {
+	TeamName               string `json:"team_name"`
+	ResponseMinutes        int    `json:"response_minutes"`
+	RemindAfterMinutes     int    `json:"remind_after_minutes"`
+	ReassignAfterMinutes   int    `json:"reassign_after_minutes"`
+	NotifyLeadAfterMinutes int    `json:"notify_lead_after_minutes"`
+}
+
+func TestReviewSLA(t *testing.T) {
+	suffix := "sla_" + now
+	team := "team_" + suffix
+	createTeam(t, team, []string{"a_" + suffix, "u1_" + suffix, "u2_" + suffix})
+
+	for _, bad := range []slaPolicy{
+		{TeamName: team, ResponseMinutes: -1},
+		{TeamName: team, RemindAfterMinutes: 60},
+		{TeamName: team, ResponseMinutes: 60, ReassignAfterMinutes: 120, NotifyLeadAfterMinutes: 90},
+	} {
+		resp := postJSON(t, baseURL+"/team/setSLA", bad)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	}
+
+	policy := slaPolicy{TeamName: team, ResponseMinutes: 240, RemindAfterMinutes: 240, NotifyLeadAfterMinutes: 960}
+	resp := postJSON(t, baseURL+"/team/setSLA", policy)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = getJSON(t, baseURL+"/team/getSLA?team_name="+team)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var got slaPolicy
+	_ = json.NewDecoder(resp.Body).Decode(&got)
+	assert.Equal(t, policy, got)
+
+	resp = postJSON(t, baseURL+"/pullRequest/create", map[string]string{"pull_request_id": "pr_" + suffix, "pull_request_name": "sla", "author_id": "a_" + suffix})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp = getJSON(t, baseURL+"/sla/breaches?team_name="+team)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var breaches struct {
+		Breaches []json.RawMessage `json:"breaches"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&breaches)
+	assert.Empty(t, breaches.Breaches)
+
+	resp = getJSON(t, baseURL+"/sla/breaches?team_name=missing_"+suffix)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = postJSON(t, baseURL+"/team/setSLA", slaPolicy{TeamName: team})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = getJSON(t, baseURL+"/team/getSLA?team_name="+team)
+	got = slaPolicy{}
+	_ = json.NewDecoder(resp.Body).Decode(&got)
+	assert.Equal(t, slaPolicy{TeamName: team}, got)
+}
+
+type slaEvent struct {
+	ID      int64  `json:"event_id"`
+	Type    string `json:"event_type"`
+	PRID    string `json:"pull_request_id"`
+	Payload struct {
+		ReviewerID    string   `json:"reviewer_id"`
+		Step          string   `json:"step"`
+		AgeMinutes    int      `json:"age_minutes"`
+		NewReviewerID string   `json:"new_reviewer_id"`
+		LeadIDs       []string `json:"lead_ids"`
+	} `json:"payload"`
+}
+
+// eventsAfter returns the events after the ID, all of them if there are more
+// than one page.
+func eventsAfter(t *testing.T, afterID int64) []slaEvent {
+	t.Helper()
+	all := make([]slaEvent, 0)
+	for {
+		resp := getJSON(t, baseURL+"/events?limit=1000&after_id="+strconv.FormatInt(afterID, 10))
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var page struct {
+			Events []slaEvent `json:"events"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&page)
+		all = append(all, page.Events...)
+		if len(page.Events) < 1000 {
+			return all
+		}
+		afterID = page.Events[len(page.Events)-1].ID
+	}
+}
+
+// TestReviewSLAEscalation backdates the assignments through E2E_POSTGRES_DSN,
+// the database of the server under test, and waits for the queue worker to
+// escalate them. It is skipped with ACK_DEADLINE set, as the worker would
+// hand the backdated reviews over first.
+func TestReviewSLAEscalation(t *testing.T) {
+	dsn := os.Getenv("E2E_POSTGRES_DSN")
+	if dsn == "" || os.Getenv("ACK_DEADLINE") != "" {
+		t.Skip("E2E_POSTGRES_DSN is not set or ACK_DEADLINE is set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer db.Close()
+
+	var lastID int64
+	if events := eventsAfter(t, 0); len(events) > 0 {
+		lastID = events[len(events)-1].ID
+	}
+
+	suffix := "slaesc_" + now
+	policy := slaPolicy{ResponseMinutes: 1, RemindAfterMinutes: 1, ReassignAfterMinutes: 2, NotifyLeadAfterMinutes: 3}
+	backdated := func(team, lead string, userIDs []string) []string {
+		members := []map[string]any{{"user_id": lead, "username": lead, "is_active": true, "role": "lead"}}
+		for _, id := range userIDs {
+			members = append(members, map[string]any{"user_id": id, "username": id, "is_active": true})
+		}
+		resp := postJSON(t, baseURL+"/team/add", map[string]any{"team_name": team, "members": members})
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		policy.TeamName = team
+		resp = postJSON(t, baseURL+"/team/setSLA", policy)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = postJSON(t, baseURL+"/pullRequest/create", map[string]string{"pull_request_id": "pr_" + team, "pull_request_name": "sla escalation", "author_id": lead})
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		var created reviewersResponse
+		_ = json.NewDecoder(resp.Body).Decode(&created)
+		return created.PR.Reviewers
+	}
+
+	// Nobody can take over the reviews of the first team, so their ladders go
+	// on to the lead. The second team gains a member who takes the review over.
+	stuckTeam, movedTeam := "stuck_"+suffix, "moved_"+suffix
+	a, u1, u2 := "a_"+suffix, "u1_"+suffix, "u2_"+suffix
+	b, v1, v2 := "b_"+suffix, "v1_"+suffix, "v2_"+suffix
+	assert.ElementsMatch(t, []string{u1, u2}, backdated(stuckTeam, a, []string{u1, u2}))
+	assert.Equal(t, []string{v1}, backdated(movedTeam, b, []string{v1}))
+	resp := postJSON(t, baseURL+"/team/addMembers", map[string]any{
+		"team_name": movedTeam,
+		"members":   []map[string]any{{"user_id": v2, "username": v2, "is_active": true}},
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	_, err = db.Exec("UPDATE pull_requests_reviewers SET assigned_at=NOW() - INTERVAL '10 minutes' WHERE pr_id = ANY($1)",
+		pq.Array([]string{"pr_" + stuckTeam, "pr_" + movedTeam}))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	steps := map[string][]string{}
+	for end := time.Now().Add(30 * time.Second); time.Now().Before(end) && (len(steps[u1]) < 3 || len(steps[u2]) < 3 || len(steps[v1]) < 2); time.Sleep(500 * time.Millisecond) {
+		clear(steps)
+		for _, e := range eventsAfter(t, lastID) {
+			switch {
+			case e.Type == "pr.sla_breached":
+				assert.GreaterOrEqual(t, e.Payload.AgeMinutes, 10)
+			case e.Type != "pr.sla_escalated":
+			case e.PRID == "pr_"+stuckTeam:
+				steps[e.Payload.ReviewerID] = append(steps[e.Payload.ReviewerID], e.Payload.Step)
+				assert.Empty(t, e.Payload.NewReviewerID)
+				if e.Payload.Step == "notify_lead" {
+					assert.Equal(t, []string{a}, e.Payload.LeadIDs)
+				}
+			case e.PRID == "pr_"+movedTeam:
+				steps[e.Payload.ReviewerID] = append(steps[e.Payload.ReviewerID], e.Payload.Step)
+				if e.Payload.Step == "reassign" {
+					assert.Equal(t, v2, e.Payload.NewReviewerID)
+				}
+			}
+		}
+	}
+	assert.Equal(t, []string{"remind", "reassign", "notify_lead"}, steps[u1])
+	assert.Equal(t, []string{"remind", "reassign", "notify_lead"}, steps[u2])
+	assert.Equal(t, []string{"remind", "reassign"}, steps[v1])
+
+	var breaches struct {
+		Breaches []struct {
+			ReviewerID string `json:"reviewer_id"`
+			AgeMinutes int    `json:"age_minutes"`
+			Escalation string `json:"escalation"`
+		} `json:"breaches"`
+	}
+	resp = getJSON(t, baseURL+"/sla/breaches?team_name="+stuckTeam)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	_ = json.NewDecoder(resp.Body).Decode(&breaches)
+	if assert.Len(t, breaches.Breaches, 2) {
+		for _, br := range breaches.Breaches {
+			assert.GreaterOrEqual(t, br.AgeMinutes, 10)
+			assert.Equal(t, "notify_lead", br.Escalation)
+		}
+	}
+
+	// The new reviewer's SLA starts over, and a response ends a breach.
+	resp = getJSON(t, baseURL+"/sla/breaches?team_name="+movedTeam)
+	_ = json.NewDecoder(resp.Body).Decode(&breaches)
+	assert.Empty(t, breaches.Breaches)
+	resp = postJSON(t, baseURL+"/pullRequest/ack", map[string]string{"pull_request_id": "pr_" + stuckTeam, "reviewer_id": u1, "decision": "accept"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = getJSON(t, baseURL+"/sla/breaches?team_name="+stuckTeam)
+	_ = json.NewDecoder(resp.Body).Decode(&breaches)
+	if assert.Len(t, breaches.Breaches, 1) {
+		assert.Equal(t, u2, breaches.Breaches[0].ReviewerID)
+	}
+}
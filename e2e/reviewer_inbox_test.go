@@ -0,0 +1,92 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type inboxPage struct {
+	Reviews []struct {
+		PRID     string `json:"pull_request_id"`
+		AuthorID string `json:"author_id"`
+		Priority int    `json:"priority"`
+		AckState string `json:"ack_state"`
+		Score    int    `json:"score"`
+	} `json:"reviews"`
+	NextCursor string `json:"next_cursor"`
+}
+
+func getInbox(t *testing.T, query string) inboxPage {
+	resp := getJSON(t, baseURL+"/users/inbox?"+query)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var page inboxPage
+	_ = json.NewDecoder(resp.Body).Decode(&page)
+	return page
+}
+
+func TestReviewerInbox(t *testing.T) {
+	suffix := "inbox_" + now
+	a, u1, u2 := "a_"+suffix, "u1_"+suffix, "u2_"+suffix
+	createTeam(t, "team_"+suffix, []string{a, u1, u2})
+
+	for id, priority := range map[string]int{"pr1_" + suffix: 0, "pr2_" + suffix: 5} {
+		resp := postJSON(t, baseURL+"/pullRequest/create", map[string]any{"pull_request_id": id, "pull_request_name": "inbox", "author_id": a, "priority": priority})
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	page := getInbox(t, "user_id="+u1)
+	assert.Empty(t, page.NextCursor)
+	if assert.Len(t, page.Reviews, 2) {
+		assert.Equal(t, "pr2_"+suffix, page.Reviews[0].PRID)
+		assert.Equal(t, 5, page.Reviews[0].Priority)
+		assert.GreaterOrEqual(t, page.Reviews[0].Score, 300)
+		assert.Equal(t, a, page.Reviews[1].AuthorID)
+		assert.Equal(t, "pending", page.Reviews[1].AckState)
+	}
+
+	resp := postJSON(t, baseURL+"/pullRequest/ack", map[string]string{"pull_request_id": "pr1_" + suffix, "reviewer_id": u1, "decision": "accept"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = getJSON(t, baseURL+"/users/inboxCount?user_id="+u1)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var count struct {
+		Open        int `json:"open"`
+		Unaccepted  int `json:"unaccepted"`
+		SLABreached int `json:"sla_breached"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&count)
+	assert.Equal(t, 2, count.Open)
+	assert.Equal(t, 1, count.Unaccepted)
+	assert.Equal(t, 0, count.SLABreached)
+
+	resp = postJSON(t, baseURL+"/pullRequest/merge", map[string]string{"pull_request_id": "pr2_" + suffix})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	page = getInbox(t, "user_id="+u1)
+	if assert.Len(t, page.Reviews, 1) {
+		assert.Equal(t, "pr1_"+suffix, page.Reviews[0].PRID)
+		assert.Equal(t, "accepted", page.Reviews[0].AckState)
+	}
+	page = getInbox(t, "user_id="+u1+"&include_merged=true&limit=1")
+	if assert.Len(t, page.Reviews, 1) && assert.NotEmpty(t, page.NextCursor) {
+		assert.Equal(t, "pr1_"+suffix, page.Reviews[0].PRID)
+		page = getInbox(t, "user_id="+u1+"&include_merged=true&limit=1&cursor="+page.NextCursor)
+		assert.Empty(t, page.NextCursor)
+		if assert.Len(t, page.Reviews, 1) {
+			assert.Equal(t, "pr2_"+suffix, page.Reviews[0].PRID)
+			assert.Equal(t, 0, page.Reviews[0].Score)
+		}
+	}
+
+	resp = getJSON(t, baseURL+"/users/inbox?user_id="+u1+"&cursor=bogus")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "INVALID_CURSOR", errorCode(t, resp))
+
+	resp = getJSON(t, baseURL+"/users/inbox?user_id="+u1+"&limit=0")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = getJSON(t, baseURL+"/users/inbox?user_id=missing_"+suffix)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,76 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSkillBasedSelection(t *testing.T) {
+	suffix := "sk_" + now
+	a, b, c, d := "a_"+suffix, "b_"+suffix, "c_"+suffix, "d_"+suffix
+	createTeam(t, "team_"+suffix, []string{a, b, c, d})
+
+	resp := postJSON(t, baseURL+"/users/setSkills", map[string]interface{}{"user_id": c, "skills": []string{"SQL", "go", "go"}})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = postJSON(t, baseURL+"/users/setSkills", map[string]interface{}{"user_id": d, "skills": []string{"go"}})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = postJSON(t, baseURL+"/users/setSkills", map[string]interface{}{"user_id": "missing_" + suffix, "skills": []string{"go"}})
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = getJSON(t, baseURL+"/users/getSkills?user_id="+c)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var skills struct {
+		Skills []string `json:"skills"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&skills)
+	assert.Equal(t, []string{"go", "sql"}, skills.Skills)
+
+	type skillMatch struct {
+		UserID      string   `json:"user_id"`
+		MatchedTags []string `json:"matched_tags"`
+		Score       float64  `json:"score"`
+	}
+	resp = postJSON(t, baseURL+"/pullRequest/create", map[string]interface{}{
+		"pull_request_id":   "pr_" + suffix,
+		"pull_request_name": "query planner",
+		"author_id":         a,
+		"tags":              []string{"Go", "sql"},
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created struct {
+		PR struct {
+			Reviewers    []string     `json:"assigned_reviewers"`
+			SkillMatches []skillMatch `json:"skill_matches"`
+		} `json:"pr"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&created)
+	assert.Equal(t, []string{c, d}, created.PR.Reviewers)
+	assert.Equal(t, []skillMatch{
+		{UserID: c, MatchedTags: []string{"go", "sql"}, Score: 1},
+		{UserID: d, MatchedTags: []string{"go"}, Score: 0.5},
+	}, created.PR.SkillMatches)
+
+	resp = getJSON(t, baseURL+"/stats/users?include_archived=true")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var stats struct {
+		Stats []struct {
+			UserID      string  `json:"user_id"`
+			Tagged      int64   `json:"count_tagged_pr_reviewer"`
+			TagCoverage float64 `json:"tag_coverage"`
+		} `json:"statistic"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&stats)
+	for _, s := range stats.Stats {
+		switch s.UserID {
+		case c:
+			assert.Equal(t, int64(1), s.Tagged)
+			assert.Equal(t, 1.0, s.TagCoverage)
+		case d:
+			assert.Equal(t, int64(1), s.Tagged)
+			assert.Equal(t, 0.5, s.TagCoverage)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatsWindows(t *testing.T) {
+	suffix := "statswin_" + now
+	a := "a_" + suffix
+	team := "team_" + suffix
+	createTeam(t, team, []string{a, "u1_" + suffix, "u2_" + suffix})
+
+	for id, createdAt := range map[string]string{
+		"pr1_" + suffix: "2024-01-10T10:00:00Z",
+		"pr2_" + suffix: "2024-02-10T10:00:00Z",
+		"pr3_" + suffix: time.Now().UTC().Format(time.RFC3339),
+	} {
+		resp := postJSON(t, baseURL+"/pullRequest/create", map[string]any{"pull_request_id": id, "pull_request_name": "stats", "author_id": a, "created_at": createdAt})
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	resp := getJSON(t, baseURL+"/stats/users?"+url.Values{"user_id": {a}, "from": {"2024-01-01T00:00:00Z"}, "to": {"2024-03-01T00:00:00Z"}, "group_by": {"month"}}.Encode())
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	type userStats struct {
+		UserID      string `json:"user_id"`
+		AuthorCount int    `json:"count_pr_author"`
+	}
+	var users struct {
+		Stats   []userStats `json:"statistic"`
+		Buckets []struct {
+			Start time.Time   `json:"bucket_start"`
+			Stats []userStats `json:"statistic"`
+		} `json:"buckets"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&users)
+	if assert.Len(t, users.Stats, 1) {
+		assert.Equal(t, 2, users.Stats[0].AuthorCount)
+	}
+	if assert.Len(t, users.Buckets, 2) {
+		assert.True(t, users.Buckets[0].Start.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+		assert.True(t, users.Buckets[1].Start.Equal(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)))
+		for _, b := range users.Buckets {
+			if assert.Len(t, b.Stats, 1) {
+				assert.Equal(t, a, b.Stats[0].UserID)
+				assert.Equal(t, 1, b.Stats[0].AuthorCount)
+			}
+		}
+	}
+
+	resp = getJSON(t, baseURL+"/stats/teams?"+url.Values{"team_name": {team}, "from": {"2024-02-01T00:00:00Z"}}.Encode())
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var teams struct {
+		Stats []struct {
+			TeamName string `json:"team_name"`
+			AllCount int    `json:"all_pr_count"`
+		} `json:"statistic"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&teams)
+	if assert.Len(t, teams.Stats, 1) {
+		assert.Equal(t, team, teams.Stats[0].TeamName)
+		assert.Equal(t, 2, teams.Stats[0].AllCount)
+	}
+
+	resp = getJSON(t, baseURL+"/stats/pullRequests?"+url.Values{"user_id": {a}, "from": {"2024-03-01T00:00:00Z"}, "group_by": {"day"}}.Encode())
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var prs struct {
+		Stats   map[string]int `json:"statistic_count_reviewers"`
+		Buckets []struct {
+			Stats map[string]int `json:"statistic_count_reviewers"`
+		} `json:"buckets"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&prs)
+	assert.Len(t, prs.Stats, 3)
+	assert.Equal(t, 2, prs.Stats["pr1_"+suffix])
+	assert.NotEmpty(t, prs.Buckets)
+
+	resp = getJSON(t, baseURL+"/stats/declines?team_name="+team)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var declines struct {
+		Declined int `json:"count_declined"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&declines)
+	assert.Equal(t, 0, declines.Declined)
+
+	for _, bad := range []string{"group_by=year", "from=yesterday", "from=2024-02-01T00:00:00Z&to=2024-01-01T00:00:00Z"} {
+		resp := getJSON(t, baseURL+"/stats/users?"+bad)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	}
+	resp = getJSON(t, baseURL+"/stats/teams?team_name=missing_"+suffix)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp = getJSON(t, baseURL+"/stats/declines?user_id=missing_"+suffix)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,142 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type handoverResponse struct {
+	Handovers []struct {
+		PRID          string `json:"pull_request_id"`
+		OldReviewerID string `json:"old_reviewer_id"`
+		NewReviewerID string `json:"new_reviewer_id"`
+	} `json:"reassigned_reviews"`
+}
+
+func TestTeamMembershipHandover(t *testing.T) {
+	suffix := "tm_" + now
+	a, b, c, d, e := "a_"+suffix, "b_"+suffix, "c_"+suffix, "d_"+suffix, "e_"+suffix
+	createTeam(t, "team1_"+suffix, []string{a, b, c})
+	createTeam(t, "team2_"+suffix, []string{d})
+
+	resp := postJSON(t, baseURL+"/pullRequest/create", map[string]string{
+		"pull_request_id":   "pr_" + suffix,
+		"pull_request_name": "membership",
+		"author_id":         a,
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp = postJSON(t, baseURL+"/users/moveTeam", map[string]string{"user_id": b, "team_name": "team2_" + suffix})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var moved handoverResponse
+	_ = json.NewDecoder(resp.Body).Decode(&moved)
+	assert.Equal(t, 1, len(moved.Handovers))
+	assert.Equal(t, "", moved.Handovers[0].NewReviewerID)
+
+	resp = postJSON(t, baseURL+"/team/addMembers", map[string]interface{}{
+		"team_name": "team1_" + suffix,
+		"members":   []map[string]interface{}{{"user_id": e, "username": "Eve", "is_active": true}},
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = postJSON(t, baseURL+"/team/removeMember", map[string]string{"team_name": "team1_" + suffix, "user_id": c})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var removed handoverResponse
+	_ = json.NewDecoder(resp.Body).Decode(&removed)
+	assert.Equal(t, 1, len(removed.Handovers))
+	assert.Equal(t, e, removed.Handovers[0].NewReviewerID)
+
+	for _, status := range []int{http.StatusOK, http.StatusBadRequest} {
+		resp = postJSON(t, baseURL+"/team/addMembers", map[string]interface{}{
+			"team_name": "team1_" + suffix,
+			"members":   []map[string]interface{}{{"user_id": d, "username": "Dan", "is_active": true}},
+		})
+		assert.Equal(t, status, resp.StatusCode)
+	}
+
+	resp = getJSON(t, baseURL+"/users/teamHistory?user_id="+b)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var history struct {
+		History []map[string]interface{} `json:"history"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&history)
+	assert.Equal(t, 2, len(history.History))
+	assert.Equal(t, "team2_"+suffix, history.History[1]["team_name"])
+}
+
+func TestMultiTeamMembership(t *testing.T) {
+	suffix := "mt_" + now
+	a, b, c := "a_"+suffix, "b_"+suffix, "c_"+suffix
+	createTeam(t, "team1_"+suffix, []string{a, b})
+	createTeam(t, "team2_"+suffix, []string{c})
+
+	resp := postJSON(t, baseURL+"/team/addMembers", map[string]interface{}{
+		"team_name": "team2_" + suffix,
+		"members":   []map[string]interface{}{{"user_id": a, "username": "Alice", "is_active": true, "role": "lead"}},
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = postJSON(t, baseURL+"/pullRequest/create", map[string]string{
+		"pull_request_id":   "pr1_" + suffix,
+		"pull_request_name": "primary team",
+		"author_id":         a,
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created struct {
+		PR struct {
+			TeamName  string   `json:"team_name"`
+			Reviewers []string `json:"assigned_reviewers"`
+		} `json:"pr"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&created)
+	assert.Equal(t, "team1_"+suffix, created.PR.TeamName)
+	assert.Equal(t, []string{b}, created.PR.Reviewers)
+
+	resp = postJSON(t, baseURL+"/pullRequest/create", map[string]string{
+		"pull_request_id":   "pr2_" + suffix,
+		"pull_request_name": "second team",
+		"author_id":         a,
+		"team_name":         "team2_" + suffix,
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	_ = json.NewDecoder(resp.Body).Decode(&created)
+	assert.Equal(t, "team2_"+suffix, created.PR.TeamName)
+	assert.Equal(t, []string{c}, created.PR.Reviewers)
+
+	resp = postJSON(t, baseURL+"/pullRequest/create", map[string]string{
+		"pull_request_id":   "pr3_" + suffix,
+		"pull_request_name": "foreign team",
+		"author_id":         b,
+		"team_name":         "team2_" + suffix,
+	})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	resp = postJSON(t, baseURL+"/team/updateMember", map[string]interface{}{
+		"team_name":  "team2_" + suffix,
+		"user_id":    a,
+		"is_primary": true,
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = postJSON(t, baseURL+"/users/setIsActive", map[string]interface{}{"user_id": a, "is_active": true})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var user struct {
+		User struct {
+			TeamName string `json:"team_name"`
+			Teams    []struct {
+				TeamName  string `json:"team_name"`
+				Role      string `json:"role"`
+				IsPrimary bool   `json:"is_primary"`
+			} `json:"teams"`
+		} `json:"user"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&user)
+	assert.Equal(t, "team2_"+suffix, user.User.TeamName)
+	if assert.Equal(t, 2, len(user.User.Teams)) {
+		assert.Equal(t, "lead", user.User.Teams[0].Role)
+		assert.False(t, user.User.Teams[1].IsPrimary)
+	}
+}
@@ -0,0 +1,144 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTeamList(t *testing.T) {
+	prefix := "tlist_" + now
+	createTeam(t, prefix+"_big", []string{"a_" + prefix, "b_" + prefix, "c_" + prefix})
+	createTeam(t, prefix+"_small", []string{"d_" + prefix})
+	resp := postJSON(t, baseURL+"/users/setIsActive", map[string]interface{}{"user_id": "c_" + prefix, "is_active": false})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	type teamPage struct {
+		Teams []struct {
+			TeamName     string `json:"team_name"`
+			MembersCount int    `json:"members_count"`
+			ActiveCount  int    `json:"active_count"`
+		} `json:"teams"`
+		NextCursor string `json:"next_cursor"`
+	}
+	listTeams := func(query url.Values) teamPage {
+		resp := getJSON(t, baseURL+"/team/list?"+query.Encode())
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var page teamPage
+		_ = json.NewDecoder(resp.Body).Decode(&page)
+		return page
+	}
+
+	query := url.Values{"name_prefix": {"TLIST_" + now}, "sort": {"members"}, "limit": {"1"}}
+	page := listTeams(query)
+	if assert.Len(t, page.Teams, 1) && assert.NotEmpty(t, page.NextCursor) {
+		assert.Equal(t, prefix+"_big", page.Teams[0].TeamName)
+		assert.Equal(t, 3, page.Teams[0].MembersCount)
+		assert.Equal(t, 2, page.Teams[0].ActiveCount)
+
+		query.Set("cursor", page.NextCursor)
+		next := listTeams(query)
+		if assert.Len(t, next.Teams, 1) {
+			assert.Equal(t, prefix+"_small", next.Teams[0].TeamName)
+			assert.Equal(t, 1, next.Teams[0].MembersCount)
+		}
+		assert.Empty(t, next.NextCursor)
+
+		query.Set("sort", "name")
+		resp = getJSON(t, baseURL+"/team/list?"+query.Encode())
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "INVALID_CURSOR", errorCode(t, resp))
+	}
+
+	resp = postJSON(t, baseURL+"/team/archive", map[string]string{"team_name": prefix + "_small"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	page = listTeams(url.Values{"name_prefix": {prefix}})
+	assert.Len(t, page.Teams, 1)
+	page = listTeams(url.Values{"name_prefix": {prefix}, "include_archived": {"true"}, "order": {"desc"}})
+	if assert.Len(t, page.Teams, 2) {
+		assert.Equal(t, prefix+"_small", page.Teams[0].TeamName)
+	}
+
+	for _, bad := range []string{"sort=size", "order=up", "limit=201", "include_archived=maybe"} {
+		resp := getJSON(t, baseURL+"/team/list?"+bad)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	}
+}
+
+func TestUserList(t *testing.T) {
+	suffix := "ulist_" + now
+	a, b, c := "a_"+suffix, "b_"+suffix, "c_"+suffix
+	createTeam(t, "team_"+suffix, []string{a, b, c})
+	createTeam(t, "other_"+suffix, []string{"d_" + suffix})
+	resp := postJSON(t, baseURL+"/team/addMembers", map[string]interface{}{
+		"team_name": "other_" + suffix,
+		"members":   []map[string]interface{}{{"user_id": c, "username": c, "is_active": true}},
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = postJSON(t, baseURL+"/team/updateMember", map[string]interface{}{"team_name": "team_" + suffix, "user_id": b, "role": "lead"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = postJSON(t, baseURL+"/users/setIsActive", map[string]interface{}{"user_id": c, "is_active": false})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	type userPage struct {
+		Users []struct {
+			UserID   string `json:"user_id"`
+			IsActive bool   `json:"is_active"`
+			Teams    []struct {
+				TeamName string `json:"team_name"`
+				Role     string `json:"role"`
+			} `json:"teams"`
+		} `json:"users"`
+		NextCursor string `json:"next_cursor"`
+	}
+	listUsers := func(query url.Values) (page userPage, ids []string) {
+		resp := getJSON(t, baseURL+"/users/list?"+query.Encode())
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		_ = json.NewDecoder(resp.Body).Decode(&page)
+		for _, u := range page.Users {
+			ids = append(ids, u.UserID)
+		}
+		return page, ids
+	}
+
+	query := url.Values{"team_name": {"team_" + suffix}, "limit": {"2"}}
+	page, ids := listUsers(query)
+	assert.Equal(t, []string{a, b}, ids)
+	if assert.NotEmpty(t, page.NextCursor) {
+		query.Set("cursor", page.NextCursor)
+		page, ids = listUsers(query)
+		assert.Equal(t, []string{c}, ids)
+		assert.Empty(t, page.NextCursor)
+		if assert.Len(t, page.Users, 1) {
+			assert.False(t, page.Users[0].IsActive)
+			assert.Len(t, page.Users[0].Teams, 2)
+		}
+	}
+
+	_, ids = listUsers(url.Values{"team_name": {"team_" + suffix}, "is_active": {"true"}, "order": {"desc"}})
+	assert.Equal(t, []string{b, a}, ids)
+	_, ids = listUsers(url.Values{"name_prefix": {"B_" + suffix}, "role": {"lead"}})
+	assert.Equal(t, []string{b}, ids)
+	_, ids = listUsers(url.Values{"team_name": {"other_" + suffix}, "role": {"lead"}})
+	assert.Empty(t, ids)
+
+	// The seniority level is independent of the team role.
+	resp = postJSON(t, baseURL+"/users/setSeniority", map[string]string{"user_id": a, "seniority": "senior"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	_, ids = listUsers(url.Values{"team_name": {"team_" + suffix}, "seniority": {"senior"}})
+	assert.Equal(t, []string{a}, ids)
+	_, ids = listUsers(url.Values{"team_name": {"team_" + suffix}, "seniority": {"lead"}})
+	assert.Empty(t, ids)
+	_, ids = listUsers(url.Values{"team_name": {"team_" + suffix}, "seniority": {"mid"}, "role": {"lead"}})
+	assert.Equal(t, []string{b}, ids)
+
+	for _, bad := range []string{"role=owner", "seniority=guru", "is_active=yes", "sort=age", "cursor=garbage"} {
+		resp := getJSON(t, baseURL+"/users/list?"+bad)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	}
+	resp = getJSON(t, baseURL+"/users/list?team_name=missing_"+suffix)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
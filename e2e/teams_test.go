@@ -0,0 +1,104 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTeamRenameArchiveDelete(t *testing.T) {
+	suffix := "tl_" + now
+	a, b, c := "a_"+suffix, "b_"+suffix, "c_"+suffix
+	createTeam(t, "team_"+suffix, []string{a, b, c})
+
+	resp := postJSON(t, baseURL+"/team/rename", map[string]string{"team_name": "team_" + suffix, "new_team_name": "renamed_" + suffix})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = getJSON(t, baseURL+"/team/get?team_name=team_"+suffix)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = postJSON(t, baseURL+"/pullRequest/create", map[string]string{
+		"pull_request_id":   "pr_" + suffix,
+		"pull_request_name": "lifecycle",
+		"author_id":         a,
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp = postJSON(t, baseURL+"/team/archive", map[string]string{"team_name": "renamed_" + suffix})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var stats struct {
+		Stats []map[string]interface{} `json:"statistic"`
+	}
+	resp = getJSON(t, baseURL+"/stats/teams")
+	_ = json.NewDecoder(resp.Body).Decode(&stats)
+	for _, s := range stats.Stats {
+		assert.NotEqual(t, "renamed_"+suffix, s["team_name"])
+	}
+
+	resp = postJSON(t, baseURL+"/team/delete", map[string]interface{}{"team_name": "renamed_" + suffix})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	resp = postJSON(t, baseURL+"/team/delete", map[string]interface{}{"team_name": "renamed_" + suffix, "force": true})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var deleted struct {
+		Released []map[string]interface{} `json:"released_reviews"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&deleted)
+	assert.Equal(t, 2, len(deleted.Released))
+
+	resp = postJSON(t, baseURL+"/pullRequest/merge", map[string]string{"pull_request_id": "pr_" + suffix})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestTeamHierarchyFallback(t *testing.T) {
+	suffix := "th_" + now
+	a, b, p, s := "a_"+suffix, "b_"+suffix, "p_"+suffix, "s_"+suffix
+	createTeam(t, "parent_"+suffix, []string{p})
+	createTeam(t, "child_"+suffix, []string{a, b})
+	createTeam(t, "sibling_"+suffix, []string{s})
+
+	resp := postJSON(t, baseURL+"/team/setParent", map[string]interface{}{"team_name": "child_" + suffix, "parent_team_name": "parent_" + suffix})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = postJSON(t, baseURL+"/team/setParent", map[string]interface{}{"team_name": "sibling_" + suffix, "parent_team_name": "parent_" + suffix})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = postJSON(t, baseURL+"/team/setParent", map[string]interface{}{"team_name": "parent_" + suffix, "parent_team_name": "child_" + suffix})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	type prResponse struct {
+		PR struct {
+			Reviewers         []string            `json:"assigned_reviewers"`
+			FallbackReviewers []map[string]string `json:"fallback_reviewers"`
+		} `json:"pr"`
+	}
+
+	resp = postJSON(t, baseURL+"/pullRequest/create", map[string]string{
+		"pull_request_id":   "pr_" + suffix,
+		"pull_request_name": "fallback",
+		"author_id":         a,
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created prResponse
+	_ = json.NewDecoder(resp.Body).Decode(&created)
+	assert.ElementsMatch(t, []string{b, p}, created.PR.Reviewers)
+	assert.Equal(t, []map[string]string{{"user_id": p, "team_name": "parent_" + suffix}}, created.PR.FallbackReviewers)
+
+	resp = postJSON(t, baseURL+"/pullRequest/reassign", map[string]string{"pull_request_id": "pr_" + suffix, "old_reviewer_id": b})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	resp = postJSON(t, baseURL+"/team/setParent", map[string]interface{}{
+		"team_name":            "child_" + suffix,
+		"parent_team_name":     "parent_" + suffix,
+		"fallback_to_siblings": true,
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = postJSON(t, baseURL+"/pullRequest/reassign", map[string]string{"pull_request_id": "pr_" + suffix, "old_reviewer_id": b})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var reassigned prResponse
+	_ = json.NewDecoder(resp.Body).Decode(&reassigned)
+	assert.ElementsMatch(t, []string{s, p}, reassigned.PR.Reviewers)
+	assert.Equal(t, 2, len(reassigned.PR.FallbackReviewers))
+}
@@ -0,0 +1,107 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkingHoursSelection(t *testing.T) {
+	suffix := "wh_" + now
+	a, b, c, d := "a_"+suffix, "b_"+suffix, "c_"+suffix, "d_"+suffix
+	createTeam(t, "team_"+suffix, []string{a, b, c, d})
+
+	// b works for an hour starting in two hours, so they are asleep now.
+	utc := time.Now().UTC()
+	start := (utc.Hour()*60 + utc.Minute() + 120) % (24 * 60)
+	if start > 23*60 {
+		start = 23 * 60
+	}
+	clock := func(m int) string { return fmt.Sprintf("%02d:%02d", m/60, m%60) }
+
+	resp := postJSON(t, baseURL+"/users/setWorkingHours", map[string]interface{}{
+		"user_id":   b,
+		"time_zone": "UTC",
+		"work_days": []int{1, 2, 3, 4, 5, 6, 7},
+		"start":     clock(start),
+		"end":       clock(start + 60),
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var hours struct {
+		TimeZone      string     `json:"time_zone"`
+		IsWorkingNow  bool       `json:"is_working_now"`
+		NextWorkingAt *time.Time `json:"next_working_at"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&hours)
+	assert.Equal(t, "UTC", hours.TimeZone)
+	assert.False(t, hours.IsWorkingNow)
+	if assert.NotNil(t, hours.NextWorkingAt) {
+		assert.True(t, hours.NextWorkingAt.After(time.Now()))
+	}
+
+	resp = postJSON(t, baseURL+"/users/setWorkingHours", map[string]interface{}{"user_id": c, "time_zone": "Mars/Olympus", "start": "09:00", "end": "18:00"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = postJSON(t, baseURL+"/users/setWorkingHours", map[string]interface{}{"user_id": c, "time_zone": "UTC", "start": "18:00", "end": "09:00"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = postJSON(t, baseURL+"/pullRequest/create", map[string]string{"pull_request_id": "pr_" + suffix, "pull_request_name": "night", "author_id": a})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created struct {
+		PR struct {
+			Reviewers []string `json:"assigned_reviewers"`
+		} `json:"pr"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&created)
+	assert.ElementsMatch(t, []string{c, d}, created.PR.Reviewers)
+}
+
+func TestTeamHolidays(t *testing.T) {
+	suffix := "hol_" + now
+	a := "a_" + suffix
+	createTeam(t, "team_"+suffix, []string{a})
+
+	today := time.Now().UTC()
+	calendar := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:h-" + suffix + "\r\nSUMMARY:Offsite\r\n" +
+		"DTSTART;VALUE=DATE:" + today.AddDate(0, 0, 1).Format("20060102") + "\r\n" +
+		"DTEND;VALUE=DATE:" + today.AddDate(0, 0, 3).Format("20060102") + "\r\n" +
+		"END:VEVENT\r\nEND:VCALENDAR\r\n"
+	resp := postJSON(t, baseURL+"/team/setHolidays", map[string]interface{}{
+		"team_name": "team_" + suffix,
+		"holidays":  []map[string]string{{"date": today.Format(time.DateOnly), "name": "Founders day"}},
+		"calendar":  calendar,
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = getJSON(t, baseURL+"/team/getHolidays?team_name=team_"+suffix)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var holidays struct {
+		Holidays []struct {
+			Date string `json:"date"`
+			Name string `json:"name"`
+		} `json:"holidays"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&holidays)
+	if assert.Len(t, holidays.Holidays, 3) {
+		assert.Equal(t, today.Format(time.DateOnly), holidays.Holidays[0].Date)
+		assert.Equal(t, "Offsite", holidays.Holidays[2].Name)
+	}
+
+	resp = getJSON(t, baseURL+"/users/getWorkingHours?user_id="+a)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var hours struct {
+		IsWorkingNow  bool       `json:"is_working_now"`
+		NextWorkingAt *time.Time `json:"next_working_at"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&hours)
+	assert.False(t, hours.IsWorkingNow)
+	if assert.NotNil(t, hours.NextWorkingAt) {
+		assert.Equal(t, today.AddDate(0, 0, 3).Format(time.DateOnly), hours.NextWorkingAt.Format(time.DateOnly))
+	}
+
+	resp = postJSON(t, baseURL+"/team/setHolidays", map[string]interface{}{"team_name": "team_" + suffix, "holidays": []map[string]string{{"date": "2025-13-01"}}})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
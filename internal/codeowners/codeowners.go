@@ -0,0 +1,155 @@
+// Package codeowners parses CODEOWNERS files and matches paths against them
+// with GitHub's semantics: patterns follow .gitignore rules (without negation
+// and character ranges) and the last matching line wins.
+package codeowners
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Rule is one non-empty line of a CODEOWNERS file. A rule without owners
+// removes ownership from the paths it matches.
+type Rule struct {
+	Line    int
+	Pattern string
+	Owners  []string
+
+	re *regexp.Regexp
+}
+
+// ParseError points to the line of the file that could not be parsed.
+type ParseError struct {
+	Line int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// File is a parsed CODEOWNERS file.
+type File struct {
+	Rules []Rule
+}
+
+func Parse(content string) (*File, error) {
+	f := &File{}
+
+	sc := bufio.NewScanner(strings.NewReader(content))
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if i := strings.Index(text, " #"); i != -1 {
+			text = strings.TrimSpace(text[:i])
+		}
+
+		fields := strings.Fields(text)
+		pattern := strings.ReplaceAll(fields[0], `\#`, "#")
+		if strings.HasPrefix(pattern, "!") {
+			return nil, &ParseError{Line: line, Msg: "negated patterns are not supported"}
+		}
+		if strings.ContainsAny(pattern, "[]") {
+			return nil, &ParseError{Line: line, Msg: "character ranges are not supported"}
+		}
+
+		owners := fields[1:]
+		for _, o := range owners {
+			if !strings.Contains(o, "@") {
+				return nil, &ParseError{Line: line, Msg: fmt.Sprintf("invalid owner %s", o)}
+			}
+		}
+
+		re, err := compile(pattern)
+		if err != nil {
+			return nil, &ParseError{Line: line, Msg: err.Error()}
+		}
+		f.Rules = append(f.Rules, Rule{Line: line, Pattern: pattern, Owners: owners, re: re})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Match returns the rule that owns the path, the last matching one.
+func (f *File) Match(path string) (Rule, bool) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "./"), "/")
+	for i := len(f.Rules) - 1; i >= 0; i-- {
+		if f.Rules[i].re.MatchString(path) {
+			return f.Rules[i], true
+		}
+	}
+	return Rule{}, false
+}
+
+// compile turns a gitignore-style pattern into a regexp matching repository
+// relative file paths.
+func compile(pattern string) (*regexp.Regexp, error) {
+	p := pattern
+	dirOnly := strings.HasSuffix(p, "/")
+	p = strings.TrimSuffix(p, "/")
+	// A slash at the start or in the middle anchors the pattern to the root,
+	// otherwise it matches at any depth.
+	anchored := strings.Contains(p, "/")
+	p = strings.TrimPrefix(p, "/")
+	if p == "" {
+		return nil, fmt.Errorf("empty pattern")
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+
+	segments := strings.Split(p, "/")
+	for i, seg := range segments {
+		last := i == len(segments)-1
+		if seg == "**" {
+			if last {
+				b.WriteString(".*")
+			} else {
+				b.WriteString("(?:.*/)?")
+			}
+			continue
+		}
+		for _, r := range seg {
+			switch r {
+			case '*':
+				b.WriteString("[^/]*")
+			case '?':
+				b.WriteString("[^/]")
+			default:
+				b.WriteString(regexp.QuoteMeta(string(r)))
+			}
+		}
+		if !last {
+			b.WriteString("/")
+		}
+	}
+
+	switch {
+	case dirOnly:
+		b.WriteString("/.*")
+	case strings.HasSuffix(p, "/*"):
+		// GitHub deviates from gitignore here: "docs/*" owns the files
+		// directly in docs, not the ones in its subdirectories.
+	case !strings.HasSuffix(p, "**"):
+		b.WriteString("(?:/.*)?")
+	}
+	b.WriteString("$")
+
+	return regexp.Compile(b.String())
+}
+
+// IsTeam reports whether the owner is a team (@org/team) rather than a user or
+// an email address.
+func IsTeam(owner string) bool {
+	return strings.HasPrefix(owner, "@") && strings.Contains(owner, "/")
+}
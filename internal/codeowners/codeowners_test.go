@@ -0,0 +1,119 @@
+package codeowners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		path     string
+		wantLine int // zero when no rule matches
+	}{
+		{"extension at root", "*.js @js", "app.js", 1},
+		{"extension at any depth", "*.js @js", "web/src/app.js", 1},
+		{"extension does not match other files", "*.js @js", "app.json", 0},
+		{"question mark is one character", "?.txt @t", "a.txt", 1},
+		{"question mark is not two characters", "?.txt @t", "ab.txt", 0},
+		{"question mark is not a slash", "a?b @t", "a/b", 0},
+
+		{"unanchored name at root", "logs @l", "logs/today.txt", 1},
+		{"unanchored name at any depth", "logs @l", "srv/logs/today.txt", 1},
+		{"unanchored name as file", "Makefile @m", "tools/Makefile", 1},
+		{"anchored path at root", "src/app.go @a", "src/app.go", 1},
+		{"anchored path not deeper", "src/app.go @a", "lib/src/app.go", 0},
+		{"leading slash anchors", "/build @b", "build/out.bin", 1},
+		{"leading slash not deeper", "/build @b", "tools/build/out.bin", 0},
+		{"anchored name owns its contents", "/docs @d", "docs/a/b.md", 1},
+
+		{"directory pattern owns contents", "/docs/ @d", "docs/guide/intro.md", 1},
+		{"directory pattern not the file itself", "/docs/ @d", "docs", 0},
+		{"unanchored directory at any depth", "apps/ @a", "x/apps/main.go", 1},
+		{"directory star owns direct files", "docs/* @d", "docs/a.md", 1},
+		{"directory star skips subdirectories", "docs/* @d", "docs/sub/a.md", 0},
+
+		{"leading double star at root", "**/logs @l", "logs/a", 1},
+		{"leading double star deeper", "**/logs @l", "a/b/logs/c", 1},
+		{"trailing double star", "/scripts/** @s", "scripts/ci/run.sh", 1},
+		{"trailing double star not the directory", "/scripts/** @s", "scripts", 0},
+		{"inner double star with no directories", "a/**/b @ab", "a/b", 1},
+		{"inner double star with directories", "a/**/b @ab", "a/x/y/b/c.go", 1},
+		{"inner double star anchored", "a/**/b @ab", "z/a/x/b", 0},
+
+		{"dot slash prefix is ignored", "/docs/ @d", "./docs/a.md", 1},
+		{"slash prefix is ignored", "/docs/ @d", "/docs/a.md", 1},
+		{"metacharacters are literal", "a+b.go @a", "a+b.go", 1},
+		{"metacharacters do not match", "a+b.go @a", "aab.go", 0},
+
+		{"last matching rule wins", "* @all\n*.go @go", "main.go", 2},
+		{"later broad rule overrides", "*.go @go\n* @all", "main.go", 2},
+		{"earlier rule matches when later does not", "* @all\n*.go @go", "main.rs", 1},
+		{"comments and blanks keep line numbers", "# owners\n\n*.go @go", "main.go", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse(tt.content)
+			require.NoError(t, err)
+			rule, ok := f.Match(tt.path)
+			assert.Equal(t, tt.wantLine != 0, ok)
+			assert.Equal(t, tt.wantLine, rule.Line)
+		})
+	}
+}
+
+func TestMatchRuleWithoutOwners(t *testing.T) {
+	f, err := Parse("* @all\n/vendor/")
+	require.NoError(t, err)
+
+	rule, ok := f.Match("vendor/lib.go")
+	assert.True(t, ok)
+	assert.Equal(t, 2, rule.Line)
+	assert.Empty(t, rule.Owners)
+}
+
+func TestParse(t *testing.T) {
+	f, err := Parse("  *.go   @go @org/backend  # inline comment\n\\#notes.md dev@example.com\n")
+	require.NoError(t, err)
+	require.Len(t, f.Rules, 2)
+	assert.Equal(t, "*.go", f.Rules[0].Pattern)
+	assert.Equal(t, []string{"@go", "@org/backend"}, f.Rules[0].Owners)
+	assert.Equal(t, "#notes.md", f.Rules[1].Pattern)
+	assert.Equal(t, []string{"dev@example.com"}, f.Rules[1].Owners)
+	assert.Equal(t, 2, f.Rules[1].Line)
+
+	rule, ok := f.Match("#notes.md")
+	assert.True(t, ok)
+	assert.Equal(t, 2, rule.Line)
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		wantLine int
+	}{
+		{"negation", "*.go @go\n!vendor/ @v", 2},
+		{"character range", "[ab].go @go", 1},
+		{"owner without at sign", "*.go golang", 1},
+		{"empty pattern", "# c\n/ @root", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.content)
+			var parseErr *ParseError
+			if assert.ErrorAs(t, err, &parseErr) {
+				assert.Equal(t, tt.wantLine, parseErr.Line)
+			}
+		})
+	}
+}
+
+func TestIsTeam(t *testing.T) {
+	assert.True(t, IsTeam("@org/backend"))
+	assert.False(t, IsTeam("@alice"))
+	assert.False(t, IsTeam("alice@example.com"))
+}
@@ -0,0 +1,268 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"slices"
+
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+// userAvailable holds for the user u while they are active and not inside one of
+// their absences. is_active stays the manual switch: an inactive user is never
+// picked, whatever their absences say.
+const userAvailable = `(u.is_active AND NOT EXISTS (SELECT 1 FROM user_absences ua
+		WHERE ua.user_id=u.user_id AND ua.starts_at <= NOW() AND ua.ends_at > NOW()))`
+
+const absenceColumns = `absence_id, user_id, starts_at, ends_at, reason, reassign_reviews, COALESCE(calendar_uid, ''), handed_over_at`
+
+func scanAbsence(s interface{ Scan(...any) error }) (models.Absence, error) {
+	var a models.Absence
+	err := s.Scan(&a.ID, &a.UserID, &a.StartsAt, &a.EndsAt, &a.Reason, &a.ReassignReviews, &a.CalendarUID, &a.HandedOverAt)
+	return a, err
+}
+
+// AddAbsence stores the absence, or returns sql.ErrNoRows if there is no such
+// user.
+func (p *PostgresDB) AddAbsence(ctx context.Context, a models.Absence) (models.Absence, error) {
+	r := p.db.QueryRowContext(ctx, `INSERT INTO user_absences (user_id, starts_at, ends_at, reason, reassign_reviews)
+		SELECT user_id, $2, $3, $4, $5 FROM users WHERE user_id=$1
+		RETURNING `+absenceColumns, a.UserID, a.StartsAt, a.EndsAt, a.Reason, a.ReassignReviews)
+	return scanAbsence(r)
+}
+
+// DeleteAbsence returns sql.ErrNoRows if there is no such absence.
+func (p *PostgresDB) DeleteAbsence(ctx context.Context, absenceID int64) error {
+	res, err := p.db.ExecContext(ctx, "DELETE FROM user_absences WHERE absence_id=$1", absenceID)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetUserAvailability reports whether the user is active and whether they can be
+// picked as a reviewer right now. It returns sql.ErrNoRows if there is no such
+// user.
+func (p *PostgresDB) GetUserAvailability(ctx context.Context, userID string) (bool, bool, error) {
+	var isActive, isAvailable bool
+	err := p.db.QueryRowContext(ctx, "SELECT u.is_active, "+userAvailable+" FROM users u WHERE u.user_id=$1", userID).Scan(&isActive, &isAvailable)
+	return isActive, isAvailable, err
+}
+
+// GetUserAbsences returns the user's absences that have not ended yet, earliest
+// first.
+func (p *PostgresDB) GetUserAbsences(ctx context.Context, userID string) ([]models.Absence, error) {
+	r, err := p.db.QueryContext(ctx, "SELECT "+absenceColumns+` FROM user_absences
+		WHERE user_id=$1 AND ends_at > NOW() ORDER BY starts_at, absence_id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	absences := make([]models.Absence, 0)
+	for r.Next() {
+		a, err := scanAbsence(r)
+		if err != nil {
+			return nil, err
+		}
+		absences = append(absences, a)
+	}
+
+	return absences, r.Err()
+}
+
+// ImportAbsences stores absences read from the user's calendar. An absence whose
+// calendar UID was imported before replaces the stored one; if it now starts at
+// another time, its open reviews are handed over again when it starts. It
+// returns sql.ErrNoRows if there is no such user.
+func (p *PostgresDB) ImportAbsences(ctx context.Context, userID string, absences []models.Absence) ([]models.Absence, error) {
+	t, err := p.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := lockUser(ctx, t, userID); err != nil {
+		_ = t.Rollback()
+		return nil, err
+	}
+
+	imported := make([]models.Absence, 0, len(absences))
+	for _, a := range absences {
+		r := t.QueryRowContext(ctx, `INSERT INTO user_absences (user_id, starts_at, ends_at, reason, reassign_reviews, calendar_uid)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (user_id, calendar_uid) WHERE calendar_uid IS NOT NULL DO UPDATE SET
+			starts_at=EXCLUDED.starts_at, ends_at=EXCLUDED.ends_at, reason=EXCLUDED.reason,
+			reassign_reviews=EXCLUDED.reassign_reviews,
+			handed_over_at=CASE WHEN user_absences.starts_at=EXCLUDED.starts_at THEN user_absences.handed_over_at END
+			RETURNING `+absenceColumns, userID, a.StartsAt, a.EndsAt, a.Reason, a.ReassignReviews, a.CalendarUID)
+		stored, err := scanAbsence(r)
+		if err != nil {
+			_ = t.Rollback()
+			return nil, err
+		}
+		imported = append(imported, stored)
+	}
+
+	return imported, t.Commit()
+}
+
+// HandOverAbsentReviews hands over the open reviews of users whose absences with
+// reassign_reviews have started. Every review is given to a replacement picked
+// the way the reassign flow picks one, or queued for a reviewer who is at their
+// cap; reviews nobody can take stay with the absent user. Every handover emits a
+// models.EventReviewerReplaced event; the new events are returned.
+func (p *PostgresDB) HandOverAbsentReviews(ctx context.Context) ([]models.Event, error) {
+	t, err := p.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := t.QueryContext(ctx, `SELECT absence_id, user_id FROM user_absences
+		WHERE reassign_reviews AND handed_over_at IS NULL AND starts_at <= NOW() AND ends_at > NOW()
+		ORDER BY starts_at, absence_id LIMIT $1
+		FOR UPDATE`, maxQueueBatch)
+	if err != nil {
+		_ = t.Rollback()
+		return nil, err
+	}
+
+	type dueAbsence struct {
+		id     int64
+		userID string
+	}
+	due := make([]dueAbsence, 0, 2)
+	for r.Next() {
+		var a dueAbsence
+		if err := r.Scan(&a.id, &a.userID); err != nil {
+			_ = r.Close()
+			_ = t.Rollback()
+			return nil, err
+		}
+		due = append(due, a)
+	}
+	_ = r.Close()
+	if err := r.Err(); err != nil {
+		_ = t.Rollback()
+		return nil, err
+	}
+
+	events := make([]models.Event, 0)
+	for _, a := range due {
+		handovers, err := handOverAbsentReviews(ctx, t, a.userID)
+		if err != nil {
+			_ = t.Rollback()
+			return nil, err
+		}
+
+		for _, h := range handovers {
+			payload := struct {
+				models.ReviewHandover
+				AbsenceID int64 `json:"absence_id"`
+			}{h, a.id}
+			e, err := recordEvent(ctx, t, models.EventReviewerReplaced, h.PRID, payload)
+			if err != nil {
+				_ = t.Rollback()
+				return nil, err
+			}
+			events = append(events, e)
+		}
+
+		if _, err := t.ExecContext(ctx, "UPDATE user_absences SET handed_over_at=NOW() WHERE absence_id=$1", a.id); err != nil {
+			_ = t.Rollback()
+			return nil, err
+		}
+	}
+
+	return events, t.Commit()
+}
+
+// handOverAbsentReviews moves the user's open reviews on every team to someone
+// else. A review is given to a replacement, or, for an ordinary slot, queued
+// with an empty NewReviewerID; reviews that can be neither are kept and left out
+// of the result, and get no assignment explanation.
+func handOverAbsentReviews(ctx context.Context, t *sql.Tx, userID string) ([]models.ReviewHandover, error) {
+	reviews, err := lockOpenReviews(ctx, t, userID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	handovers := make([]models.ReviewHandover, 0, len(reviews))
+	for _, rv := range reviews {
+		reviewers, err := reviewersByPRID(ctx, t, rv.prID)
+		if err != nil {
+			return nil, err
+		}
+
+		slot, err := reviewerSlotOf(ctx, t, rv.prID, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		sel, err := prSelection(ctx, t, rv.prID, rv.authorID)
+		if err != nil {
+			return nil, err
+		}
+
+		handover := models.ReviewHandover{PRID: rv.prID, OldReviewerID: userID}
+		policy, err := teamReviewPolicy(ctx, t, rv.teamID)
+		if err != nil {
+			return nil, err
+		}
+
+		remaining := slices.DeleteFunc(slices.Clone(reviewers), func(id string) bool { return id == userID })
+		newReviewer, err := pickPolicyReplacement(ctx, t, rv.teamID, policy, sel, slot, append([]string{rv.authorID}, reviewers...), remaining)
+		if isPolicyError(err) {
+			err = models.ErrNoCandidate
+		}
+		pr := models.PullRequest{ID: rv.prID, AuthorID: rv.authorID, TeamID: rv.teamID}
+		var explanation models.AssignmentExplanation
+		switch {
+		case err == nil:
+			explanation, err = explainReplacement(ctx, t, models.AssignmentHandover, pr, sel, policy, reviewers, userID, newReviewer)
+			if err == nil {
+				err = replaceReviewer(ctx, t, rv.prID, userID, newReviewer)
+			}
+			handover.NewReviewerID = newReviewer.userID
+		case err == models.ErrNoCandidate && slot == (reviewerSlot{}):
+			explanation, err = explainReplacement(ctx, t, models.AssignmentHandover, pr, sel, policy, reviewers, userID, pickedReviewer{})
+			if err == nil {
+				err = queueReassignment(ctx, t, pr, userID, reviewers)
+			}
+		}
+		if err == models.ErrNoCandidate {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := recordAssignment(ctx, t, rv.prID, explanation); err != nil {
+			return nil, err
+		}
+
+		handovers = append(handovers, handover)
+	}
+
+	return handovers, nil
+}
+
+// recordEvent stores an event and returns it with its ID.
+func recordEvent(ctx context.Context, q querier, eventType, pRID string, payload any) (models.Event, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return models.Event{}, err
+	}
+
+	e := models.Event{Type: eventType, PRID: pRID, Payload: body}
+	err = q.QueryRowContext(ctx, "INSERT INTO events (event_type, pr_id, payload) VALUES ($1, NULLIF($2, ''), $3) RETURNING event_id, created_at",
+		eventType, pRID, body).Scan(&e.ID, &e.CreatedAt)
+	return e, err
+}
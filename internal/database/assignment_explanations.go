@@ -0,0 +1,190 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+// explainAssignment describes the reviewers picked for a PR of the author and
+// every member of the team as a candidate, ranked the way poolCandidates ranks
+// them for the selection. assigned are the reviewers the PR already has.
+// Members of other teams, such as label rule or fallback pool reviewers, only
+// appear among the reviewers.
+func explainAssignment(ctx context.Context, q querier, action string, teamID int64, authorID string, sel selection, policy models.ReviewPolicy, assigned []string, picked []pickedReviewer) (models.AssignmentExplanation, error) {
+	explanation := models.AssignmentExplanation{
+		Action:     action,
+		Reviewers:  make([]models.AssignedReviewer, 0, len(picked)),
+		Candidates: make([]models.AssignmentCandidate, 0),
+	}
+	pickedIDs := make([]string, 0, len(picked))
+	for _, p := range picked {
+		explanation.Reviewers = append(explanation.Reviewers, models.AssignedReviewer{UserID: p.userID, Source: p.source()})
+		pickedIDs = append(pickedIDs, p.userID)
+	}
+	if teamID == 0 {
+		return explanation, nil
+	}
+
+	r, err := q.QueryContext(ctx, `WITH `+reviewLoad+`,
+	coverage AS (SELECT user_id, COUNT(*) AS cnt FROM user_skills WHERE skill = ANY($2) GROUP BY user_id)
+	SELECT u.user_id, u.is_active, `+userAvailable+`, `+underCapacity+`, u.seniority, COALESCE(rl.weighted, 0), COALESCE(cv.cnt, 0) FROM team_members tm
+	INNER JOIN users u ON u.user_id=tm.user_id
+	LEFT JOIN review_load rl ON rl.reviewer_id=u.user_id
+	LEFT JOIN coverage cv ON cv.user_id=u.user_id
+	WHERE tm.team_id=$1
+	ORDER BY COALESCE(rl.weighted, 0), u.user_id`, teamID, pq.Array(sel.tags))
+	if err != nil {
+		return models.AssignmentExplanation{}, err
+	}
+
+	candidates := make([]rankedCandidate, 0)
+	reasons := make(map[string][]string)
+	for r.Next() {
+		var (
+			c                           rankedCandidate
+			active, available, underCap bool
+			seniority                   models.Seniority
+		)
+		if err := r.Scan(&c.userID, &active, &available, &underCap, &seniority, &c.load, &c.coverage); err != nil {
+			_ = r.Close()
+			return models.AssignmentExplanation{}, err
+		}
+
+		var why []string
+		switch {
+		case !active:
+			why = append(why, models.IneligibleInactive)
+		case !available:
+			why = append(why, models.IneligibleAbsent)
+		}
+		if c.userID == authorID {
+			why = append(why, models.IneligibleAuthor)
+		}
+		if slices.Contains(assigned, c.userID) {
+			why = append(why, models.IneligibleAssigned)
+		}
+		if slices.Contains(sel.blocked, c.userID) || policy.ExcludeBots && seniority == models.SeniorityBot {
+			why = append(why, models.IneligibleExcludedByRule)
+		}
+		if slices.Contains(sel.declined, c.userID) {
+			why = append(why, models.IneligibleDeclined)
+		}
+		if !underCap {
+			why = append(why, models.IneligibleOverCapacity)
+		}
+		reasons[c.userID] = why
+		candidates = append(candidates, c)
+	}
+	_ = r.Close()
+	if err := r.Err(); err != nil {
+		return models.AssignmentExplanation{}, err
+	}
+
+	if err := rankCandidates(ctx, q, candidates, sel); err != nil {
+		return models.AssignmentExplanation{}, err
+	}
+
+	eligible, ineligible := make([]models.AssignmentCandidate, 0), make([]models.AssignmentCandidate, 0)
+	for _, c := range candidates {
+		ac := models.AssignmentCandidate{
+			UserID:      c.userID,
+			Eligible:    len(reasons[c.userID]) == 0,
+			Reasons:     append([]string{}, reasons[c.userID]...),
+			Load:        c.load,
+			TagCoverage: c.coverage,
+			WaitMinutes: int(c.wait / time.Minute),
+			Mentor:      c.mentor,
+			Chosen:      slices.Contains(pickedIDs, c.userID),
+		}
+		if ac.Eligible {
+			ac.Rank = len(eligible) + 1
+			eligible = append(eligible, ac)
+		} else {
+			ineligible = append(ineligible, ac)
+		}
+	}
+	explanation.Candidates = append(eligible, ineligible...)
+
+	return explanation, nil
+}
+
+func recordAssignment(ctx context.Context, q querier, pRID string, explanation models.AssignmentExplanation) error {
+	reviewers, err := json.Marshal(explanation.Reviewers)
+	if err != nil {
+		return err
+	}
+	candidates, err := json.Marshal(explanation.Candidates)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.ExecContext(ctx, "INSERT INTO assignment_explanations (pr_id, action, replaced_user_id, reviewers, candidates) VALUES ($1, $2, NULLIF($3, ''), $4, $5)",
+		pRID, explanation.Action, explanation.ReplacedUserID, reviewers, candidates)
+	return err
+}
+
+func (p *PostgresDB) GetAssignmentExplanations(ctx context.Context, pRID string) ([]models.AssignmentExplanation, error) {
+	return assignmentExplanations(ctx, p.db, pRID)
+}
+
+func assignmentExplanations(ctx context.Context, q querier, pRID string) ([]models.AssignmentExplanation, error) {
+	r, err := q.QueryContext(ctx, `SELECT action, COALESCE(replaced_user_id, ''), reviewers, candidates, recorded_at FROM assignment_explanations
+		WHERE pr_id=$1 ORDER BY explanation_id`, pRID)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	explanations := make([]models.AssignmentExplanation, 0)
+	for r.Next() {
+		var (
+			e                     models.AssignmentExplanation
+			reviewers, candidates []byte
+			recordedAt            time.Time
+		)
+		if err := r.Scan(&e.Action, &e.ReplacedUserID, &reviewers, &candidates, &recordedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(reviewers, &e.Reviewers); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(candidates, &e.Candidates); err != nil {
+			return nil, err
+		}
+		e.RecordedAt = &recordedAt
+		explanations = append(explanations, e)
+	}
+
+	return explanations, r.Err()
+}
+
+// recordReplacement records why newReviewer took the place of oldReviewerID
+// among the reviewers of the PR. An empty newReviewer records that nobody could.
+func recordReplacement(ctx context.Context, q querier, action string, pr models.PullRequest, sel selection, policy models.ReviewPolicy, reviewers []string, oldReviewerID string, newReviewer pickedReviewer) error {
+	explanation, err := explainReplacement(ctx, q, action, pr, sel, policy, reviewers, oldReviewerID, newReviewer)
+	if err != nil {
+		return err
+	}
+	return recordAssignment(ctx, q, pr.ID, explanation)
+}
+
+// explainReplacement is recordReplacement without storing the explanation, for
+// callers that only know afterwards whether the replacement took place.
+func explainReplacement(ctx context.Context, q querier, action string, pr models.PullRequest, sel selection, policy models.ReviewPolicy, reviewers []string, oldReviewerID string, newReviewer pickedReviewer) (models.AssignmentExplanation, error) {
+	var picked []pickedReviewer
+	if newReviewer.userID != "" {
+		picked = append(picked, newReviewer)
+	}
+
+	explanation, err := explainAssignment(ctx, q, action, pr.TeamID, pr.AuthorID, sel, policy, reviewers, picked)
+	if err != nil {
+		return models.AssignmentExplanation{}, err
+	}
+	explanation.ReplacedUserID = oldReviewerID
+	return explanation, nil
+}
@@ -0,0 +1,203 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/narroworb/pr-review-service/internal/codeowners"
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+// codeownerMatch is the CODEOWNERS rule and owner that put a reviewer on a PR.
+type codeownerMatch struct {
+	line    int
+	pattern string
+	owner   string
+}
+
+// columns returns the values stored in pull_requests_reviewers, NULLs for a
+// reviewer not required by CODEOWNERS.
+func (m *codeownerMatch) columns() (sql.NullInt64, sql.NullString, sql.NullString) {
+	if m == nil {
+		return sql.NullInt64{}, sql.NullString{}, sql.NullString{}
+	}
+	return sql.NullInt64{Int64: int64(m.line), Valid: true}, sql.NullString{String: m.pattern, Valid: true}, sql.NullString{String: m.owner, Valid: true}
+}
+
+func (p *PostgresDB) SaveCodeowners(ctx context.Context, repository, content string) error {
+	_, err := p.db.ExecContext(ctx, `INSERT INTO codeowners_files (repository, content) VALUES ($1, $2)
+		ON CONFLICT (repository) DO UPDATE SET content=EXCLUDED.content, uploaded_at=NOW()`, repository, content)
+	return err
+}
+
+func (p *PostgresDB) GetCodeowners(ctx context.Context, repository string) (models.CodeownersFile, error) {
+	r := p.db.QueryRowContext(ctx, "SELECT repository, content, uploaded_at FROM codeowners_files WHERE repository=$1", repository)
+
+	var f models.CodeownersFile
+	if err := r.Scan(&f.Repository, &f.Content, &f.UploadedAt); err != nil {
+		return models.CodeownersFile{}, err
+	}
+	return f, nil
+}
+
+// codeownerTeam resolves a @org/team owner to a non-archived team named either
+// "org/team" or "team", zero if there is none.
+func codeownerTeam(ctx context.Context, q querier, owner string) (int64, error) {
+	if !codeowners.IsTeam(owner) {
+		return 0, nil
+	}
+	fullName := strings.TrimPrefix(owner, "@")
+	shortName := fullName[strings.Index(fullName, "/")+1:]
+
+	var teamID int64
+	err := q.QueryRowContext(ctx, `SELECT team_id FROM teams WHERE name IN ($1, $2) AND archived_at IS NULL
+		ORDER BY name=$1 DESC LIMIT 1`, fullName, shortName).Scan(&teamID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return teamID, err
+}
+
+// ownerCovers reports whether one of the chosen reviewers is the owner or a
+// member of the owner team.
+func ownerCovers(ctx context.Context, q querier, owner string, chosen []string) (bool, error) {
+	if !codeowners.IsTeam(owner) {
+		return strings.HasPrefix(owner, "@") && slices.Contains(chosen, strings.TrimPrefix(owner, "@")), nil
+	}
+
+	teamID, err := codeownerTeam(ctx, q, owner)
+	if err != nil || teamID == 0 {
+		return false, err
+	}
+
+	var covered bool
+	err = q.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM team_members WHERE team_id=$1 AND user_id = ANY($2))", teamID, pq.Array(chosen)).Scan(&covered)
+	return covered, err
+}
+
+// ownerCandidate picks a reviewer of one of levels, any if it is nil, for the
+// owner: the user itself if active, or the least loaded active member of the
+// owner team. Owners that are emails or unknown to the service yield nobody.
+func ownerCandidate(ctx context.Context, q querier, owner string, exclude, levels []string) (string, bool, error) {
+	if !codeowners.IsTeam(owner) {
+		userID, ok := strings.CutPrefix(owner, "@")
+		if !ok || slices.Contains(exclude, userID) {
+			return "", false, nil
+		}
+
+		var active bool
+		err := q.QueryRowContext(ctx, "SELECT "+userAvailable+" AND ($2::text[] IS NULL OR u.seniority = ANY($2)) FROM users u WHERE u.user_id=$1",
+			userID, pq.Array(levels)).Scan(&active)
+		if err == sql.ErrNoRows || err == nil && !active {
+			return "", false, nil
+		}
+		if err != nil {
+			return "", false, err
+		}
+		claimed, err := claimReviewer(ctx, q, userID)
+		return userID, claimed, err
+	}
+
+	teamID, err := codeownerTeam(ctx, q, owner)
+	if err != nil || teamID == 0 {
+		return "", false, err
+	}
+
+	candidates, err := poolCandidates(ctx, q, []int64{teamID}, selection{levels: levels}, exclude, 1)
+	if err != nil || len(candidates) == 0 {
+		return "", false, err
+	}
+	return candidates[0].userID, true, nil
+}
+
+// pickCodeownerReviewers picks one owner per CODEOWNERS rule matching the
+// changed files of a PR in the repository. A rule is skipped when one of the
+// chosen reviewers already owns it; otherwise its owners are tried in the order
+// they are listed, skipping the ones in exclude and, unless levels is nil, the
+// ones of other seniorities. Files without owners and repositories without a
+// CODEOWNERS file add nobody.
+func pickCodeownerReviewers(ctx context.Context, q querier, repository string, files, chosen, exclude, levels []string) ([]pickedReviewer, error) {
+	if repository == "" || len(files) == 0 {
+		return nil, nil
+	}
+
+	var content string
+	err := q.QueryRowContext(ctx, "SELECT content FROM codeowners_files WHERE repository=$1", repository).Scan(&content)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := codeowners.Parse(content)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]codeowners.Rule, 0, len(files))
+	for _, path := range files {
+		rule, ok := f.Match(path)
+		if !ok || len(rule.Owners) == 0 || slices.ContainsFunc(rules, func(r codeowners.Rule) bool { return r.Line == rule.Line }) {
+			continue
+		}
+		rules = append(rules, rule)
+	}
+
+	chosen = append([]string{}, chosen...)
+	picked := make([]pickedReviewer, 0, len(rules))
+	for _, rule := range rules {
+		covered := false
+		for _, owner := range rule.Owners {
+			if covered, err = ownerCovers(ctx, q, owner, chosen); err != nil {
+				return nil, err
+			}
+			if covered {
+				break
+			}
+		}
+		if covered {
+			continue
+		}
+
+		for _, owner := range rule.Owners {
+			userID, ok, err := ownerCandidate(ctx, q, owner, append(append([]string{}, exclude...), chosen...), levels)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+
+			match := &codeownerMatch{line: rule.Line, pattern: rule.Pattern, owner: owner}
+			picked = append(picked, pickedReviewer{userID: userID, reviewerSlot: reviewerSlot{codeowner: match}})
+			chosen = append(chosen, userID)
+			break
+		}
+	}
+
+	return picked, nil
+}
+
+func codeownerReviewersByPRID(ctx context.Context, q querier, pRID string) ([]models.CodeownerReviewer, error) {
+	r, err := q.QueryContext(ctx, `SELECT reviewer_id, codeowners_owner, codeowners_pattern, codeowners_line FROM pull_requests_reviewers
+		WHERE pr_id=$1 AND codeowners_owner IS NOT NULL ORDER BY codeowners_line, reviewer_id`, pRID)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	reviewers := make([]models.CodeownerReviewer, 0)
+	for r.Next() {
+		var cr models.CodeownerReviewer
+		if err := r.Scan(&cr.UserID, &cr.Owner, &cr.Pattern, &cr.Line); err != nil {
+			return nil, err
+		}
+		reviewers = append(reviewers, cr)
+	}
+
+	return reviewers, r.Err()
+}
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+// ReserveIdempotencyKey claims the key for a new request. If the key is already
+// taken by a live record, that record is returned with reserved=false. Expired
+// records, and records still in progress after staleAfter because the process
+// handling them died, are taken over as if the key was never used.
+func (p *PostgresDB) ReserveIdempotencyKey(ctx context.Context, key, requestHash string, ttl, staleAfter time.Duration) (models.IdempotencyRecord, bool, error) {
+	r := p.db.QueryRowContext(ctx, `INSERT INTO idempotency_keys (idempotency_key, request_hash, expires_at)
+		VALUES ($1, $2, NOW() + make_interval(secs => $3))
+		ON CONFLICT (idempotency_key) DO UPDATE
+		SET request_hash=EXCLUDED.request_hash, status_code=NULL, response_body=NULL, created_at=NOW(), expires_at=EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at < NOW()
+		OR idempotency_keys.status_code IS NULL AND idempotency_keys.created_at < NOW() - make_interval(secs => $4)
+		RETURNING idempotency_key`, key, requestHash, ttl.Seconds(), staleAfter.Seconds())
+
+	var reservedKey string
+	err := r.Scan(&reservedKey)
+	if err == nil {
+		return models.IdempotencyRecord{Key: key, RequestHash: requestHash}, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return models.IdempotencyRecord{}, false, err
+	}
+
+	r = p.db.QueryRowContext(ctx, "SELECT idempotency_key, request_hash, COALESCE(status_code, 0), response_body FROM idempotency_keys WHERE idempotency_key=$1", key)
+
+	var rec models.IdempotencyRecord
+	if err := r.Scan(&rec.Key, &rec.RequestHash, &rec.StatusCode, &rec.Body); err != nil {
+		return models.IdempotencyRecord{}, false, err
+	}
+	return rec, false, nil
+}
+
+func (p *PostgresDB) SaveIdempotencyResponse(ctx context.Context, key string, statusCode int, body []byte) error {
+	_, err := p.db.ExecContext(ctx, "UPDATE idempotency_keys SET status_code=$1, response_body=$2 WHERE idempotency_key=$3", statusCode, body, key)
+	return err
+}
+
+func (p *PostgresDB) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	_, err := p.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE idempotency_key=$1", key)
+	return err
+}
+
+func (p *PostgresDB) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	res, err := p.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at < NOW()")
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
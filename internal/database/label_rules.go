@@ -0,0 +1,164 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+func (p *PostgresDB) SetLabelRule(ctx context.Context, label string, teamID int64, reviewersCount int) error {
+	_, err := p.db.ExecContext(ctx, `INSERT INTO label_rules (label, team_id, reviewers_count) VALUES ($1, $2, $3)
+		ON CONFLICT (label, team_id) DO UPDATE SET reviewers_count=EXCLUDED.reviewers_count`, label, teamID, reviewersCount)
+	return err
+}
+
+// DeleteLabelRule removes the rule, or returns sql.ErrNoRows if there is none.
+func (p *PostgresDB) DeleteLabelRule(ctx context.Context, label string, teamID int64) error {
+	res, err := p.db.ExecContext(ctx, "DELETE FROM label_rules WHERE label=$1 AND team_id=$2", label, teamID)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (p *PostgresDB) GetLabelRules(ctx context.Context) ([]models.LabelRule, error) {
+	r, err := p.db.QueryContext(ctx, `SELECT lr.label, lr.team_id, t.name, lr.reviewers_count FROM label_rules lr
+		INNER JOIN teams t ON t.team_id=lr.team_id
+		ORDER BY lr.label, t.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	rules := make([]models.LabelRule, 0)
+	for r.Next() {
+		var rule models.LabelRule
+		if err := r.Scan(&rule.Label, &rule.TeamID, &rule.TeamName, &rule.ReviewersCount); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, r.Err()
+}
+
+// requiredTeam is a team that must provide count reviewers for a PR.
+type requiredTeam struct {
+	teamID int64
+	count  int
+}
+
+// requiredTeamsForLabels returns the teams the labels require reviewers from.
+// When several labels point to the same team the largest count wins. Rules of
+// archived teams are ignored.
+func requiredTeamsForLabels(ctx context.Context, q querier, labels []string) ([]requiredTeam, error) {
+	if len(labels) == 0 {
+		return nil, nil
+	}
+
+	r, err := q.QueryContext(ctx, `SELECT lr.team_id, MAX(lr.reviewers_count) FROM label_rules lr
+		INNER JOIN teams t ON t.team_id=lr.team_id
+		WHERE lr.label = ANY($1) AND t.archived_at IS NULL
+		GROUP BY lr.team_id ORDER BY lr.team_id`, pq.Array(labels))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	teams := make([]requiredTeam, 0, 1)
+	for r.Next() {
+		var rt requiredTeam
+		if err := r.Scan(&rt.teamID, &rt.count); err != nil {
+			return nil, err
+		}
+		teams = append(teams, rt)
+	}
+
+	return teams, r.Err()
+}
+
+// pickRuleReviewers fills the label rule slots of a new PR. Each required team
+// provides its active members who are not in exclude, in the order of
+// poolCandidates for the PR's selection; a slot stays empty if the team has nobody
+// left.
+func pickRuleReviewers(ctx context.Context, q querier, labels []string, sel selection, exclude []string) ([]pickedReviewer, error) {
+	teams, err := requiredTeamsForLabels(ctx, q, labels)
+	if err != nil {
+		return nil, err
+	}
+
+	exclude = append([]string{}, exclude...)
+	picked := make([]pickedReviewer, 0, len(teams))
+	for _, rt := range teams {
+		candidates, err := poolCandidates(ctx, q, []int64{rt.teamID}, sel, exclude, rt.count)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range candidates {
+			c.poolTeamID, c.ruleTeamID = 0, rt.teamID
+			picked = append(picked, c)
+			exclude = append(exclude, c.userID)
+		}
+	}
+
+	return picked, nil
+}
+
+func insertPRLabels(ctx context.Context, q querier, pRID string, labels []string) error {
+	for _, label := range labels {
+		if _, err := q.ExecContext(ctx, "INSERT INTO pull_request_labels (pr_id, label) VALUES ($1, $2) ON CONFLICT DO NOTHING", pRID, label); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func prLabels(ctx context.Context, q querier, pRID string) ([]string, error) {
+	r, err := q.QueryContext(ctx, "SELECT label FROM pull_request_labels WHERE pr_id=$1 ORDER BY label", pRID)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	labels := make([]string, 0)
+	for r.Next() {
+		var label string
+		if err := r.Scan(&label); err != nil {
+			return nil, err
+		}
+		labels = append(labels, label)
+	}
+
+	return labels, r.Err()
+}
+
+func requiredReviewersByPRID(ctx context.Context, q querier, pRID string) ([]models.RequiredReviewer, error) {
+	r, err := q.QueryContext(ctx, `SELECT prr.reviewer_id, t.name FROM pull_requests_reviewers prr
+		INNER JOIN teams t ON t.team_id=prr.rule_team_id
+		WHERE prr.pr_id=$1 ORDER BY t.name, prr.reviewer_id`, pRID)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	reviewers := make([]models.RequiredReviewer, 0)
+	for r.Next() {
+		var rr models.RequiredReviewer
+		if err := r.Scan(&rr.UserID, &rr.TeamName); err != nil {
+			return nil, err
+		}
+		reviewers = append(reviewers, rr)
+	}
+
+	return reviewers, r.Err()
+}
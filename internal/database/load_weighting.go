@@ -0,0 +1,103 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+// reviewWeight is how much the review of the open PR pr adds to its reviewer's
+// load under the stored models.LoadWeighting: one without a weighting,
+// otherwise the weight of the PR's lines added and removed.
+const reviewWeight = `COALESCE((SELECT CASE lw.mode
+		WHEN 'log' THEN 1 + LOG(2, 1 + (pr.lines_added + pr.lines_removed)::numeric / lw.log_base_lines)
+		ELSE COALESCE(
+			(SELECT b.weight FROM load_weight_buckets b WHERE b.max_lines >= pr.lines_added + pr.lines_removed ORDER BY b.max_lines LIMIT 1),
+			(SELECT b.weight FROM load_weight_buckets b ORDER BY b.max_lines DESC LIMIT 1))
+		END FROM load_weighting lw), 1)`
+
+// reviewLoad is the review_load CTE with every reviewer's load, the weighted
+// number of their reviews of open PRs. Finished reviews take no more work, so
+// they do not count. Reviewers are picked least loaded first.
+const reviewLoad = `review_load AS (SELECT prr.reviewer_id, SUM(` + reviewWeight + `) AS weighted
+	FROM pull_requests_reviewers prr
+	INNER JOIN pull_requests pr ON pr.pr_id=prr.pr_id
+	WHERE pr.pr_status='OPEN'
+	GROUP BY prr.reviewer_id)`
+
+// SetLoadWeighting replaces the weighting of open reviews in the reviewer load.
+// Buckets are only kept for models.LoadWeightingBuckets.
+func (p *PostgresDB) SetLoadWeighting(ctx context.Context, w models.LoadWeighting) error {
+	t, err := p.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+
+	if _, err := t.ExecContext(ctx, "DELETE FROM load_weight_buckets"); err != nil {
+		_ = t.Rollback()
+		return err
+	}
+
+	if w.Mode == models.LoadWeightingCount {
+		if _, err := t.ExecContext(ctx, "DELETE FROM load_weighting"); err != nil {
+			_ = t.Rollback()
+			return err
+		}
+		return t.Commit()
+	}
+
+	_, err = t.ExecContext(ctx, `INSERT INTO load_weighting (mode, log_base_lines) VALUES ($1, COALESCE(NULLIF($2, 0), 100))
+		ON CONFLICT (singleton) DO UPDATE SET mode=EXCLUDED.mode, log_base_lines=EXCLUDED.log_base_lines`, w.Mode, w.LogBaseLines)
+	if err != nil {
+		_ = t.Rollback()
+		return err
+	}
+
+	if w.Mode == models.LoadWeightingBuckets {
+		for _, b := range w.Buckets {
+			if _, err := t.ExecContext(ctx, "INSERT INTO load_weight_buckets (max_lines, weight) VALUES ($1, $2)", b.MaxLines, b.Weight); err != nil {
+				_ = t.Rollback()
+				return err
+			}
+		}
+	}
+
+	return t.Commit()
+}
+
+// GetLoadWeighting returns the weighting of open reviews in the reviewer load,
+// models.LoadWeightingCount if none is set. Buckets are ordered by MaxLines.
+func (p *PostgresDB) GetLoadWeighting(ctx context.Context) (models.LoadWeighting, error) {
+	var w models.LoadWeighting
+	err := p.db.QueryRowContext(ctx, "SELECT mode, log_base_lines FROM load_weighting").Scan(&w.Mode, &w.LogBaseLines)
+	if err == sql.ErrNoRows {
+		return models.LoadWeighting{Mode: models.LoadWeightingCount}, nil
+	}
+	if err != nil {
+		return models.LoadWeighting{}, err
+	}
+	if w.Mode != models.LoadWeightingLog {
+		w.LogBaseLines = 0
+	}
+	if w.Mode != models.LoadWeightingBuckets {
+		return w, nil
+	}
+
+	r, err := p.db.QueryContext(ctx, "SELECT max_lines, weight FROM load_weight_buckets ORDER BY max_lines")
+	if err != nil {
+		return models.LoadWeighting{}, err
+	}
+	defer r.Close()
+
+	w.Buckets = make([]models.LoadWeightBucket, 0)
+	for r.Next() {
+		var b models.LoadWeightBucket
+		if err := r.Scan(&b.MaxLines, &b.Weight); err != nil {
+			return models.LoadWeighting{}, err
+		}
+		w.Buckets = append(w.Buckets, b)
+	}
+
+	return w, r.Err()
+}
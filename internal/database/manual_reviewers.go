@@ -0,0 +1,228 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+// lockOpenPR locks the PR row and returns the PR with its reviewers, or
+// models.ErrPRMerged or models.ErrPRClosed if its reviewers can no longer
+// change.
+func lockOpenPR(ctx context.Context, t *sql.Tx, pRID string) (models.PullRequest, []string, error) {
+	pr, err := lockPRByID(ctx, t, pRID)
+	if err != nil {
+		return models.PullRequest{}, nil, err
+	}
+	if pr.Status == models.PRStatusMerged {
+		return models.PullRequest{}, nil, models.ErrPRMerged
+	}
+	if pr.Status == models.PRStatusClosed {
+		return models.PullRequest{}, nil, models.ErrPRClosed
+	}
+
+	reviewers, err := reviewersByPRID(ctx, t, pRID)
+	if err != nil {
+		return models.PullRequest{}, nil, err
+	}
+	return pr, reviewers, nil
+}
+
+// withReviewerDetails fills in the pending, fallback, required and CODEOWNERS
+// reviewers of the PR.
+func withReviewerDetails(ctx context.Context, q querier, pr models.PullRequest) (models.PullRequest, error) {
+	var err error
+	if pr.PendingReviewers, err = pendingReviewers(ctx, q, pr.ID); err != nil {
+		return models.PullRequest{}, err
+	}
+	if pr.FallbackReviewers, err = fallbackReviewersByPRID(ctx, q, pr.ID); err != nil {
+		return models.PullRequest{}, err
+	}
+	if pr.RequiredReviewers, err = requiredReviewersByPRID(ctx, q, pr.ID); err != nil {
+		return models.PullRequest{}, err
+	}
+	if pr.CodeownerReviewers, err = codeownerReviewersByPRID(ctx, q, pr.ID); err != nil {
+		return models.PullRequest{}, err
+	}
+	return pr, nil
+}
+
+// chooseReviewer validates a user chosen by hand for the slot on the PR beside
+// its reviewers. The user must not be the author or a reviewer already, must be
+// available and under their open review cap, must not be excluded by a pair
+// rule or the review policy nor have declined the PR, and must belong to the
+// pool pickReplacementReviewer would use for the slot: the rule or owner team
+// for those slots, the PR team's pools otherwise.
+func chooseReviewer(ctx context.Context, q querier, pr models.PullRequest, sel selection, policy models.ReviewPolicy, slot reviewerSlot, reviewers []string, userID string) (pickedReviewer, error) {
+	if userID == pr.AuthorID {
+		return pickedReviewer{}, models.ErrReviewerIsAuthor
+	}
+	if slices.Contains(reviewers, userID) {
+		return pickedReviewer{}, models.ErrAlreadyAssigned
+	}
+
+	var (
+		available, underCap bool
+		seniority           models.Seniority
+	)
+	err := q.QueryRowContext(ctx, "SELECT "+userAvailable+", "+underCapacity+", u.seniority FROM users u WHERE u.user_id=$1", userID).
+		Scan(&available, &underCap, &seniority)
+	if err == sql.ErrNoRows || err == nil && !available {
+		return pickedReviewer{}, models.ErrReviewerUnavailable
+	}
+	if err != nil {
+		return pickedReviewer{}, err
+	}
+	if slices.Contains(sel.blocked, userID) || policy.ExcludeBots && seniority == models.SeniorityBot {
+		return pickedReviewer{}, models.ErrReviewerExcluded
+	}
+	if slices.Contains(sel.declined, userID) {
+		return pickedReviewer{}, models.ErrReviewerDeclined
+	}
+	if !underCap {
+		return pickedReviewer{}, models.ErrReviewerAtCapacity
+	}
+
+	picked, err := pickReplacementReviewer(ctx, q, pr.TeamID, sel, slot, append([]string{pr.AuthorID}, reviewers...), userID)
+	if err == models.ErrNoCandidate {
+		return pickedReviewer{}, models.ErrNotInReviewerPool
+	}
+	if err != nil {
+		return pickedReviewer{}, err
+	}
+	picked.manual = true
+	return picked, nil
+}
+
+// AddReviewerInTransaction puts the user on the PR as one more ordinary
+// reviewer, validated by chooseReviewer and the team's review policy. A
+// queued slot of the PR is taken by the new reviewer.
+func (p *PostgresDB) AddReviewerInTransaction(ctx context.Context, pRID, userID string) (models.PullRequest, []string, error) {
+	t, err := p.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return models.PullRequest{}, nil, err
+	}
+
+	if err := lockReviewer(ctx, t, userID); err != nil {
+		_ = t.Rollback()
+		return models.PullRequest{}, nil, err
+	}
+
+	pr, reviewers, err := lockOpenPR(ctx, t, pRID)
+	if err != nil {
+		_ = t.Rollback()
+		return models.PullRequest{}, nil, err
+	}
+
+	sel, err := prSelection(ctx, t, pRID, pr.AuthorID)
+	if err != nil {
+		_ = t.Rollback()
+		return models.PullRequest{}, nil, err
+	}
+
+	policy, err := teamReviewPolicy(ctx, t, pr.TeamID)
+	if err != nil {
+		_ = t.Rollback()
+		return models.PullRequest{}, nil, err
+	}
+
+	reviewer, err := chooseReviewer(ctx, t, pr, sel, policy, reviewerSlot{}, reviewers, userID)
+	if err == nil {
+		err = checkReviewPolicy(ctx, t, policy, append(slices.Clone(reviewers), userID))
+	}
+	if err != nil {
+		_ = t.Rollback()
+		return models.PullRequest{}, nil, err
+	}
+
+	explanation, err := explainAssignment(ctx, t, models.AssignmentAdd, pr.TeamID, pr.AuthorID, sel, policy, reviewers, []pickedReviewer{reviewer})
+	if err != nil {
+		_ = t.Rollback()
+		return models.PullRequest{}, nil, err
+	}
+	if err := recordAssignment(ctx, t, pRID, explanation); err != nil {
+		_ = t.Rollback()
+		return models.PullRequest{}, nil, err
+	}
+
+	if err := insertReviewer(ctx, t, pRID, reviewer); err != nil {
+		_ = t.Rollback()
+		return models.PullRequest{}, nil, err
+	}
+	if err := takePendingSlot(ctx, t, pRID); err != nil {
+		_ = t.Rollback()
+		return models.PullRequest{}, nil, err
+	}
+	if err := insertEvent(ctx, t, models.EventReviewerAdded, pRID, map[string]string{"reviewer_id": userID}); err != nil {
+		_ = t.Rollback()
+		return models.PullRequest{}, nil, err
+	}
+
+	pr, err = withReviewerDetails(ctx, t, pr)
+	if err != nil {
+		_ = t.Rollback()
+		return models.PullRequest{}, nil, err
+	}
+
+	return pr, append(reviewers, userID), t.Commit()
+}
+
+// RemoveReviewerInTransaction takes the reviewer off the PR without a
+// replacement, whatever slot they hold, unless the remaining reviewers would
+// break the team's review policy.
+func (p *PostgresDB) RemoveReviewerInTransaction(ctx context.Context, pRID, userID string) (models.PullRequest, []string, error) {
+	t, err := p.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return models.PullRequest{}, nil, err
+	}
+
+	pr, reviewers, err := lockOpenPR(ctx, t, pRID)
+	if err != nil {
+		_ = t.Rollback()
+		return models.PullRequest{}, nil, err
+	}
+	idx := slices.Index(reviewers, userID)
+	if idx == -1 {
+		_ = t.Rollback()
+		return models.PullRequest{}, nil, models.ErrNotAssigned
+	}
+	reviewers = slices.Delete(reviewers, idx, idx+1)
+
+	policy, err := teamReviewPolicy(ctx, t, pr.TeamID)
+	if err != nil {
+		_ = t.Rollback()
+		return models.PullRequest{}, nil, err
+	}
+	if err := checkReviewPolicy(ctx, t, policy, reviewers); err != nil {
+		_ = t.Rollback()
+		return models.PullRequest{}, nil, err
+	}
+
+	if _, err := t.ExecContext(ctx, "DELETE FROM pull_requests_reviewers WHERE pr_id=$1 AND reviewer_id=$2", pRID, userID); err != nil {
+		_ = t.Rollback()
+		return models.PullRequest{}, nil, err
+	}
+	if err := insertEvent(ctx, t, models.EventReviewerRemoved, pRID, map[string]string{"reviewer_id": userID}); err != nil {
+		_ = t.Rollback()
+		return models.PullRequest{}, nil, err
+	}
+
+	pr, err = withReviewerDetails(ctx, t, pr)
+	if err != nil {
+		_ = t.Rollback()
+		return models.PullRequest{}, nil, err
+	}
+
+	return pr, reviewers, t.Commit()
+}
+
+// takePendingSlot removes one queued slot of the PR, if it has any.
+func takePendingSlot(ctx context.Context, q querier, pRID string) error {
+	if _, err := q.ExecContext(ctx, "DELETE FROM review_queue WHERE pr_id=$1 AND missing_reviewers=1", pRID); err != nil {
+		return err
+	}
+	_, err := q.ExecContext(ctx, "UPDATE review_queue SET missing_reviewers=missing_reviewers-1 WHERE pr_id=$1", pRID)
+	return err
+}
@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+// SetPairRule creates the rule or changes its kind, or returns sql.ErrNoRows if
+// one of the users does not exist.
+func (p *PostgresDB) SetPairRule(ctx context.Context, reviewerID, authorID string, kind models.PairRuleKind) (models.PairRule, error) {
+	r := p.db.QueryRowContext(ctx, `INSERT INTO pair_rules (reviewer_id, author_id, kind)
+		SELECT rv.user_id, au.user_id, $3 FROM users rv, users au WHERE rv.user_id=$1 AND au.user_id=$2
+		ON CONFLICT (reviewer_id, author_id) DO UPDATE SET kind=EXCLUDED.kind
+		RETURNING reviewer_id, author_id, kind, created_at`, reviewerID, authorID, kind)
+
+	var rule models.PairRule
+	if err := r.Scan(&rule.ReviewerID, &rule.AuthorID, &rule.Kind, &rule.CreatedAt); err != nil {
+		return models.PairRule{}, err
+	}
+	return rule, nil
+}
+
+// DeletePairRule removes the rule, or returns sql.ErrNoRows if there is none.
+func (p *PostgresDB) DeletePairRule(ctx context.Context, reviewerID, authorID string) error {
+	res, err := p.db.ExecContext(ctx, "DELETE FROM pair_rules WHERE reviewer_id=$1 AND author_id=$2", reviewerID, authorID)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetPairRules returns the rules the user is part of as reviewer or author, or
+// all rules for an empty userID.
+func (p *PostgresDB) GetPairRules(ctx context.Context, userID string) ([]models.PairRule, error) {
+	r, err := p.db.QueryContext(ctx, `SELECT reviewer_id, author_id, kind, created_at FROM pair_rules
+		WHERE $1 = '' OR reviewer_id=$1 OR author_id=$1
+		ORDER BY author_id, reviewer_id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	rules := make([]models.PairRule, 0)
+	for r.Next() {
+		var rule models.PairRule
+		if err := r.Scan(&rule.ReviewerID, &rule.AuthorID, &rule.Kind, &rule.CreatedAt); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, r.Err()
+}
+
+// selection is what a PR asks of the reviewers picked from the pools: skills
+// covering its tags, the author's mentors as a tie-breaker, and never anyone
+// the author has a never rule with or who declined the PR. levels restricts
+// the reviewers to those seniorities, anyone may review if it is nil.
+type selection struct {
+	tags     []string
+	mentors  []string
+	blocked  []string
+	declined []string
+	levels   []string
+}
+
+// authorSelection completes a selection for the tags with the author's pair
+// rules.
+func authorSelection(ctx context.Context, q querier, authorID string, tags []string) (selection, error) {
+	sel := selection{tags: tags}
+
+	r, err := q.QueryContext(ctx, "SELECT reviewer_id, kind FROM pair_rules WHERE author_id=$1 ORDER BY reviewer_id", authorID)
+	if err != nil {
+		return selection{}, err
+	}
+	defer r.Close()
+
+	for r.Next() {
+		var (
+			reviewerID string
+			kind       models.PairRuleKind
+		)
+		if err := r.Scan(&reviewerID, &kind); err != nil {
+			return selection{}, err
+		}
+		if kind == models.PairRuleNever {
+			sel.blocked = append(sel.blocked, reviewerID)
+		} else {
+			sel.mentors = append(sel.mentors, reviewerID)
+		}
+	}
+
+	return sel, r.Err()
+}
+
+// prSelection returns the selection of a stored PR.
+func prSelection(ctx context.Context, q querier, pRID, authorID string) (selection, error) {
+	tags, err := prTags(ctx, q, pRID)
+	if err != nil {
+		return selection{}, err
+	}
+	sel, err := authorSelection(ctx, q, authorID, tags)
+	if err != nil {
+		return selection{}, err
+	}
+	sel.declined, err = declinedReviewers(ctx, q, pRID)
+	if err != nil {
+		return selection{}, err
+	}
+	return sel, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/golang-migrate/migrate/v4"
@@ -17,6 +18,14 @@ type PostgresDB struct {
 	db *sql.DB
 }
 
+// querier is implemented by both *sql.DB and *sql.Tx, so query helpers can be
+// shared between plain reads and transactional flows.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
 func NewPostgresDB(dsn string) (*PostgresDB, error) {
 	conn, err := sql.Open("postgres", dsn)
 	if err != nil {
@@ -53,11 +62,13 @@ func (p *PostgresDB) RunMigrations() error {
 }
 
 func (p *PostgresDB) GetTeamByName(ctx context.Context, teamName string) (models.Team, error) {
-	r := p.db.QueryRowContext(ctx, "SELECT team_id, name FROM teams WHERE name=$1", teamName)
+	r := p.db.QueryRowContext(ctx, `SELECT t.team_id, t.name, t.archived_at, COALESCE(t.parent_team_id, 0), COALESCE(pt.name, ''), t.fallback_to_siblings FROM teams t
+		LEFT JOIN teams pt ON pt.team_id=t.parent_team_id
+		WHERE t.name=$1`, teamName)
 
 	var team models.Team
 
-	if err := r.Scan(&team.ID, &team.Name); err != nil {
+	if err := r.Scan(&team.ID, &team.Name, &team.ArchivedAt, &team.ParentID, &team.ParentName, &team.FallbackToSiblings); err != nil {
 		return models.Team{}, err
 	}
 	return team, nil
@@ -75,7 +86,9 @@ func (p *PostgresDB) CreateTeam(ctx context.Context, teamName string) (int64, er
 }
 
 func (p *PostgresDB) GetUserByID(ctx context.Context, userID string) (models.User, error) {
-	r := p.db.QueryRowContext(ctx, "SELECT user_id, name, is_active, team_id FROM users WHERE user_id=$1", userID)
+	r := p.db.QueryRowContext(ctx, `SELECT u.user_id, u.name, u.is_active, COALESCE(tm.team_id, 0) FROM users u
+		LEFT JOIN team_members tm ON tm.user_id=u.user_id AND tm.is_primary
+		WHERE u.user_id=$1`, userID)
 
 	var user models.User
 
@@ -86,29 +99,47 @@ func (p *PostgresDB) GetUserByID(ctx context.Context, userID string) (models.Use
 }
 
 func (p *PostgresDB) CreateUser(ctx context.Context, user models.User) error {
-	_, err := p.db.ExecContext(ctx, "INSERT INTO users (user_id, name, is_active, team_id) VALUES ($1, $2, $3, $4)", user.ID, user.Name, user.IsActive, user.GroupID)
-	return err
+	t, err := p.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+
+	if _, err := t.ExecContext(ctx, "INSERT INTO users (user_id, name, is_active) VALUES ($1, $2, $3)", user.ID, user.Name, user.IsActive); err != nil {
+		_ = t.Rollback()
+		return err
+	}
+	if user.GroupID != 0 {
+		if err := joinTeam(ctx, t, user.ID, user.GroupID, models.MemberRoleMember, true); err != nil {
+			_ = t.Rollback()
+			return err
+		}
+	}
+
+	return t.Commit()
 }
 
-func (p *PostgresDB) GetUsersInTeam(ctx context.Context, teamID int64) ([]models.User, error) {
-	r, err := p.db.QueryContext(ctx, "SELECT user_id, name, is_active, team_id FROM users WHERE team_id=$1", teamID)
+func (p *PostgresDB) GetUsersInTeam(ctx context.Context, teamID int64) ([]models.Member, error) {
+	r, err := p.db.QueryContext(ctx, `SELECT u.user_id, u.name, u.is_active, tm.role, tm.is_primary FROM users u
+		INNER JOIN team_members tm ON tm.user_id=u.user_id
+		WHERE tm.team_id=$1 ORDER BY tm.joined_at, u.user_id`, teamID)
 	if err != nil {
 		return nil, err
 	}
+	defer r.Close()
 
-	users := make([]models.User, 0, 2)
+	users := make([]models.Member, 0, 2)
 
 	for r.Next() {
-		var user models.User
+		var user models.Member
 
-		if err := r.Scan(&user.ID, &user.Name, &user.IsActive, &user.GroupID); err != nil {
+		if err := r.Scan(&user.ID, &user.Name, &user.IsActive, &user.Role, &user.IsPrimary); err != nil {
 			return nil, err
 		}
 
 		users = append(users, user)
 	}
 
-	return users, nil
+	return users, r.Err()
 }
 
 func (p *PostgresDB) InsertTeamInTransaction(ctx context.Context, teamName string, users []models.User) error {
@@ -121,30 +152,40 @@ func (p *PostgresDB) InsertTeamInTransaction(ctx context.Context, teamName strin
 	var teamID int64
 	if err := r.Scan(&teamID); err != nil {
 		_ = t.Rollback()
+		if isTeamNameTaken(err) {
+			return models.ErrTeamExists
+		}
 		return err
 	}
 
 	for _, user := range users {
-		_, err := t.ExecContext(ctx, "INSERT INTO users (user_id, name, is_active, team_id) VALUES ($1, $2, $3, $4)", user.ID, user.Name, user.IsActive, teamID)
+		_, err := t.ExecContext(ctx, "INSERT INTO users (user_id, name, is_active) VALUES ($1, $2, $3)", user.ID, user.Name, user.IsActive)
 		if err != nil {
 			_ = t.Rollback()
 			return err
 		}
+		if err := joinTeam(ctx, t, user.ID, teamID, models.MemberRoleMember, true); err != nil {
+			_ = t.Rollback()
+			return err
+		}
 	}
 
 	return t.Commit()
 }
 
-func (p *PostgresDB) GetUserWithTeamByID(ctx context.Context, userID string) (models.User, string, error) {
-	r := p.db.QueryRowContext(ctx, "SELECT user_id, users.name, is_active, users.team_id, teams.name FROM users INNER JOIN teams ON users.team_id=teams.team_id WHERE user_id=$1", userID)
-
-	var user models.User
-	var teamName string
+// GetUserWithTeamByID returns the user together with all their team
+// memberships, the primary one first.
+func (p *PostgresDB) GetUserWithTeamByID(ctx context.Context, userID string) (models.User, []models.Membership, error) {
+	user, err := p.GetUserByID(ctx, userID)
+	if err != nil {
+		return models.User{}, nil, err
+	}
 
-	if err := r.Scan(&user.ID, &user.Name, &user.IsActive, &user.GroupID, &teamName); err != nil {
-		return models.User{}, "", err
+	memberships, err := userMemberships(ctx, p.db, userID)
+	if err != nil {
+		return models.User{}, nil, err
 	}
-	return user, teamName, nil
+	return user, memberships, nil
 }
 
 func (p *PostgresDB) UpdateUserActivity(ctx context.Context, userID string, isActive bool) error {
@@ -152,224 +193,285 @@ func (p *PostgresDB) UpdateUserActivity(ctx context.Context, userID string, isAc
 	return err
 }
 
-func (p *PostgresDB) GetPRByID(ctx context.Context, pRID string) (models.PullRequest, error) {
-	r := p.db.QueryRowContext(ctx, "SELECT pr_id, name, author_id, pr_status, merged_at FROM pull_requests WHERE pr_id=$1", pRID)
+// prColumns are the columns of pull_requests, aliased pr, read by scanPR.
+const prColumns = `pr.pr_id, pr.name, pr.author_id, pr.pr_status, COALESCE(pr.team_id, 0), pr.merged_at, pr.closed_at,
+	COALESCE(pr.repository, ''), COALESCE(pr.source_branch, ''), COALESCE(pr.target_branch, ''), COALESCE(pr.url, ''),
+	COALESCE(pr.description, ''), pr.lines_added, pr.lines_removed, pr.files_count, pr.created_at, pr.priority`
 
+// scanPR scans a row starting with prColumns; the columns after them go to
+// extra.
+func scanPR(s interface{ Scan(...any) error }, extra ...any) (models.PullRequest, error) {
 	var pr models.PullRequest
-
-	if err := r.Scan(&pr.ID, &pr.Name, &pr.AuthorID, &pr.Status, &pr.MergedAt); err != nil {
-		return models.PullRequest{}, err
-	}
-	return pr, nil
+	err := s.Scan(append([]any{&pr.ID, &pr.Name, &pr.AuthorID, &pr.Status, &pr.TeamID, &pr.MergedAt, &pr.ClosedAt,
+		&pr.Repository, &pr.SourceBranch, &pr.TargetBranch, &pr.URL,
+		&pr.Description, &pr.LinesAdded, &pr.LinesRemoved, &pr.FilesCount, &pr.CreatedAt, &pr.Priority}, extra...)...)
+	return pr, err
 }
 
-func (p *PostgresDB) GetActiveUsersInTeamExcAuthor(ctx context.Context, teamID int64, userID string) ([]models.User, error) {
-	r, err := p.db.QueryContext(ctx, `WITH pr_count AS (SELECT reviewer_id, COUNT(*) AS cnt FROM pull_requests_reviewers GROUP BY reviewer_id)
- 		SELECT user_id, name, is_active, team_id FROM users LEFT JOIN pr_count ON users.user_id=pr_count.reviewer_id
- 		WHERE user_id!=$1 AND is_active AND team_id=$2 ORDER BY COALESCE(cnt, 0) LIMIT 2;`,
-		userID, teamID)
+func (p *PostgresDB) GetPRByID(ctx context.Context, pRID string) (models.PullRequest, error) {
+	return scanPR(p.db.QueryRowContext(ctx, "SELECT "+prColumns+" FROM pull_requests pr WHERE pr.pr_id=$1", pRID))
+}
 
+// InsertPRInTransaction creates the pull request and picks its reviewers in one
+// transaction. The PR belongs to pr.TeamID, or to the author's primary team when
+// it is zero. Label rules are filled first, each from its required team, then the
+// CODEOWNERS rules matching the changed files, and the remaining of the
+// reviewersPerPR slots come from the PR's team, pr.PreferredReviewers first.
+// Within a team, members whose skills cover more of pr.Tags go first. Team
+// slots that stay empty only because candidates are at their open review cap
+// are queued for AssignPendingReviewers.
+// Reviewers the team cannot provide are borrowed from its fallback pools. The
+// author's membership is re-read under a share lock, so the reviewers always
+// come from a team the author belongs to at commit time.
+func (p *PostgresDB) InsertPRInTransaction(ctx context.Context, pr models.PullRequest) (models.PullRequest, error) {
+	t, err := p.db.BeginTx(ctx, &sql.TxOptions{})
 	if err != nil {
-		return nil, err
+		return models.PullRequest{}, err
 	}
 
-	reviewers := make([]models.User, 0, 2)
-
-	for r.Next() {
-		var user models.User
-		if err := r.Scan(&user.ID, &user.Name, &user.IsActive, &user.GroupID); err != nil {
-			return nil, err
-		}
-
-		reviewers = append(reviewers, user)
+	pr, err = insertPR(ctx, t, pr, false)
+	if err != nil {
+		_ = t.Rollback()
+		return models.PullRequest{}, err
 	}
 
-	return reviewers, nil
+	return pr, t.Commit()
 }
 
-func (p *PostgresDB) InsertPRInTransaction(ctx context.Context, pr models.PullRequest) error {
+// PreviewPR picks the reviewers of the PR the way InsertPRInTransaction does
+// without keeping anything, and returns the PR with the explanation that
+// would be recorded. It takes no capacity locks, so it cannot change the
+// reviewers concurrent assignments pick.
+func (p *PostgresDB) PreviewPR(ctx context.Context, pr models.PullRequest) (models.PullRequest, models.AssignmentExplanation, error) {
 	t, err := p.db.BeginTx(ctx, &sql.TxOptions{})
 	if err != nil {
-		return err
+		return models.PullRequest{}, models.AssignmentExplanation{}, err
 	}
+	defer func() { _ = t.Rollback() }()
 
-	_, err = t.ExecContext(ctx, "INSERT INTO pull_requests (pr_id, name, author_id, pr_status) VALUES ($1, $2, $3, $4)", pr.ID, pr.Name, pr.AuthorID, pr.Status)
+	pr, err = insertPR(ctx, t, pr, true)
 	if err != nil {
-		_ = t.Rollback()
-		return err
+		return models.PullRequest{}, models.AssignmentExplanation{}, err
 	}
 
-	for _, reviewer := range pr.Reviewers {
-		_, err := t.ExecContext(ctx, "INSERT INTO pull_requests_reviewers (pr_id, reviewer_id) VALUES ($1, $2)", pr.ID, reviewer.ID)
-		if err != nil {
-			_ = t.Rollback()
-			return err
-		}
+	explanations, err := assignmentExplanations(ctx, t, pr.ID)
+	if err != nil {
+		return models.PullRequest{}, models.AssignmentExplanation{}, err
 	}
+	explanation := explanations[0]
+	explanation.RecordedAt = nil
 
-	return t.Commit()
+	return pr, explanation, nil
 }
 
-func (p *PostgresDB) GetReviewersByPRID(ctx context.Context, pRID string) ([]string, error) {
-	r, err := p.db.QueryContext(ctx, "SELECT reviewer_id FROM pull_requests_reviewers WHERE pr_id=$1", pRID)
-	if err != nil {
-		return nil, err
+// insertPR does the work of InsertPRInTransaction in t, picking reviewers
+// through a previewQuerier with preview.
+func insertPR(ctx context.Context, t *sql.Tx, pr models.PullRequest, preview bool) (models.PullRequest, error) {
+	var picker querier = t
+	if preview {
+		picker = previewQuerier{t}
 	}
-	reviewersID := make([]string, 0, 2)
 
-	for r.Next() {
-		var userID string
-		if err := r.Scan(&userID); err != nil {
-			return nil, err
+	var authorID string
+	if err := t.QueryRowContext(ctx, "SELECT user_id FROM users WHERE user_id=$1 FOR SHARE", pr.AuthorID).Scan(&authorID); err != nil {
+		return models.PullRequest{}, err
+	}
+
+	teamID, err := resolvePRTeam(ctx, t, pr.AuthorID, pr.TeamID)
+	if err != nil {
+		return models.PullRequest{}, err
+	}
+	pr.TeamID = teamID
+
+	createdAt := sql.NullTime{Time: pr.CreatedAt, Valid: !pr.CreatedAt.IsZero()}
+	r := t.QueryRowContext(ctx, `INSERT INTO pull_requests (pr_id, name, author_id, pr_status, team_id, repository,
+			source_branch, target_branch, url, description, lines_added, lines_removed, files_count, created_at, priority)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''), NULLIF($9, ''), NULLIF($10, ''), $11, $12, $13, COALESCE($14, NOW()), $15)
+		ON CONFLICT (pr_id) DO NOTHING RETURNING created_at`, pr.ID, pr.Name, pr.AuthorID, pr.Status, nullTeamID(teamID), pr.Repository,
+		pr.SourceBranch, pr.TargetBranch, pr.URL, pr.Description, pr.LinesAdded, pr.LinesRemoved, pr.FilesCount, createdAt, pr.Priority)
+	if err := r.Scan(&pr.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return models.PullRequest{}, models.ErrPRExists
 		}
-		reviewersID = append(reviewersID, userID)
+		return models.PullRequest{}, err
 	}
 
-	return reviewersID, nil
-}
+	if err := insertPRLabels(ctx, t, pr.ID, pr.Labels); err != nil {
+		return models.PullRequest{}, err
+	}
+	if err := insertPRTags(ctx, t, pr.ID, pr.Tags); err != nil {
+		return models.PullRequest{}, err
+	}
 
-func (p *PostgresDB) SetMergedStatusPR(ctx context.Context, pRID string) (time.Time, error) {
-	r := p.db.QueryRowContext(ctx, `UPDATE pull_requests SET pr_status=$1, merged_at=NOW() WHERE pr_id=$2 RETURNING merged_at`, models.PRStatusMerged, pRID)
+	policy, err := teamReviewPolicy(ctx, t, teamID)
+	if err != nil {
+		return models.PullRequest{}, err
+	}
+	sel, err := authorSelection(ctx, t, pr.AuthorID, pr.Tags)
+	if err != nil {
+		return models.PullRequest{}, err
+	}
+	sel.levels = policyLevels(policy)
+	exclude := append([]string{pr.AuthorID}, sel.blocked...)
 
-	var mergedAt time.Time
-	if err := r.Scan(&mergedAt); err != nil {
-		return time.Time{}, err
+	picked, err := pickRuleReviewers(ctx, picker, pr.Labels, sel, exclude)
+	if err != nil {
+		return models.PullRequest{}, err
 	}
 
-	return mergedAt, nil
-}
+	chosen := make([]string, 0, len(picked))
+	for _, reviewer := range picked {
+		chosen = append(chosen, reviewer.userID)
+	}
+	ownerPicked, err := pickCodeownerReviewers(ctx, picker, pr.Repository, pr.ChangedFiles, chosen, exclude, sel.levels)
+	if err != nil {
+		return models.PullRequest{}, err
+	}
+	for _, reviewer := range ownerPicked {
+		chosen = append(chosen, reviewer.userID)
+	}
+	picked = append(picked, ownerPicked...)
 
-func (p *PostgresDB) FoundAvailableReviewerPR(ctx context.Context, pRID string, reviewersID []string, authorID string) (string, error) {
-	r := p.db.QueryRowContext(ctx, `WITH team AS (SELECT team_id FROM users u INNER JOIN pull_requests pr ON pr.author_id=u.user_id WHERE pr.pr_id=$1),
-	pr_count AS (SELECT reviewer_id, COUNT(*) AS cnt FROM pull_requests_reviewers GROUP BY reviewer_id)
-	SELECT user_id FROM users u INNER JOIN team t ON u.team_id=t.team_id 
-	LEFT JOIN pr_count prc ON prc.reviewer_id=u.user_id 
-	WHERE is_active AND user_id != ALL($2) AND user_id != $3 ORDER BY cnt LIMIT 1`, pRID, pq.Array(reviewersID), authorID)
+	teamSlots := max(reviewersPerPR-len(ownerPicked), 0)
+	preferredPicked, err := pickPreferredReviewers(ctx, picker, teamID, pr.PreferredReviewers, append(append([]string{}, exclude...), chosen...), sel.levels, teamSlots)
+	if err != nil {
+		return models.PullRequest{}, err
+	}
+	preferredPicked, err = fitPreferred(ctx, t, policy, chosen, preferredPicked, teamSlots)
+	if err != nil {
+		return models.PullRequest{}, err
+	}
+	for _, reviewer := range preferredPicked {
+		chosen = append(chosen, reviewer.userID)
+	}
+	picked = append(picked, preferredPicked...)
 
-	var reviewerID string
-	if err := r.Scan(&reviewerID); err != nil {
-		return "", err
+	teamSlots -= len(preferredPicked)
+	teamPicked, err := pickPolicyReviewers(ctx, picker, teamID, policy, sel, append(append([]string{}, exclude...), chosen...), chosen, teamSlots)
+	if err == nil {
+		err = checkReviewPolicy(ctx, t, policy, append(slices.Clone(chosen), pickedIDs(teamPicked)...))
 	}
-	return reviewerID, nil
-}
+	if isPolicyError(err) {
+		// A rule only reviewers at their cap could meet queues the team
+		// slots instead of failing the PR.
+		pending, qErr := queuePolicySlots(ctx, t, policy, err, pr, chosen, exclude, teamSlots)
+		if qErr != nil {
+			return models.PullRequest{}, qErr
+		}
+		if pending == 0 {
+			return models.PullRequest{}, err
+		}
+		pr.PendingReviewers, teamPicked, err = pending, nil, nil
+	}
+	if err != nil {
+		return models.PullRequest{}, err
+	}
+	for _, reviewer := range teamPicked {
+		chosen = append(chosen, reviewer.userID)
+	}
+	picked = append(picked, teamPicked...)
 
-func (p *PostgresDB) SwapReviewerInPR(ctx context.Context, pRID, oldReviewerID, newReviewerID string) error {
-	_, err := p.db.ExecContext(ctx, `UPDATE pull_requests_reviewers SET reviewer_id=$1 WHERE pr_id=$2 AND reviewer_id=$3`, newReviewerID, pRID, oldReviewerID)
-	return err
-}
+	if missing := teamSlots - len(teamPicked); missing > 0 && pr.PendingReviewers == 0 {
+		capped, err := cappedCandidates(ctx, t, teamID, append(append([]string{}, exclude...), chosen...), sel.levels)
+		if err != nil {
+			return models.PullRequest{}, err
+		}
+		if capped > 0 {
+			pr.PendingReviewers, err = enqueuePendingReviewers(ctx, t, pr.ID, min(missing, capped), pr.Priority)
+			if err != nil {
+				return models.PullRequest{}, err
+			}
+		}
+	}
 
-func (p *PostgresDB) GetPRByReviewerID(ctx context.Context, reviewerID string) ([]models.PullRequest, error) {
-	r, err := p.db.QueryContext(ctx, `SELECT pr.pr_id, name, author_id, pr_status FROM pull_requests_reviewers prr 
-	INNER JOIN pull_requests pr 
-	ON prr.pr_id=pr.pr_id
-	WHERE reviewer_id=$1`, reviewerID)
+	explanation, err := explainAssignment(ctx, t, models.AssignmentCreate, teamID, pr.AuthorID, sel, policy, nil, picked)
 	if err != nil {
-		return nil, err
+		return models.PullRequest{}, err
+	}
+	if err := recordAssignment(ctx, t, pr.ID, explanation); err != nil {
+		return models.PullRequest{}, err
 	}
-	pullrequests := make([]models.PullRequest, 0, 1)
 
-	for r.Next() {
-		var pr models.PullRequest
-		if err := r.Scan(&pr.ID, &pr.Name, &pr.AuthorID, &pr.Status); err != nil {
-			return nil, err
+	pr.Reviewers = make([]models.User, 0, len(picked))
+	reviewerIDs := make([]string, 0, len(picked))
+	for _, reviewer := range picked {
+		if err := insertReviewer(ctx, t, pr.ID, reviewer); err != nil {
+			return models.PullRequest{}, err
 		}
-		pullrequests = append(pullrequests, pr)
+		pr.Reviewers = append(pr.Reviewers, models.User{ID: reviewer.userID})
+		reviewerIDs = append(reviewerIDs, reviewer.userID)
 	}
 
-	return pullrequests, nil
-}
-
-func (p *PostgresDB) GetCountPRStatsByUser(ctx context.Context) ([]models.UserStats, error) {
-	r, err := p.db.QueryContext(ctx,
-		`SELECT u.user_id, COALESCE(a.cnt_author, 0) AS cnt_author,
-			COALESCE(r.cnt_reviewer, 0) AS cnt_reviewer
-		FROM users u
-		LEFT JOIN (
-			SELECT author_id, COUNT(*) AS cnt_author
-			FROM pull_requests
-			GROUP BY author_id
-		) a ON u.user_id = a.author_id
-		LEFT JOIN (
-			SELECT reviewer_id, COUNT(*) AS cnt_reviewer
-			FROM pull_requests_reviewers
-			GROUP BY reviewer_id
-		) r ON u.user_id = r.reviewer_id
-		ORDER BY cnt_author DESC;
-	`)
+	pr.SkillMatches, err = skillMatches(ctx, t, pr.Tags, reviewerIDs)
 	if err != nil {
-		return nil, err
+		return models.PullRequest{}, err
 	}
-	stats := make([]models.UserStats, 0, 10)
 
-	for r.Next() {
-		var s models.UserStats
-		if err := r.Scan(&s.UserID, &s.PRAuthorCount, &s.PRReviewerCount); err != nil {
-			return nil, err
-		}
-		stats = append(stats, s)
+	pr.FallbackReviewers, err = fallbackReviewersByPRID(ctx, t, pr.ID)
+	if err != nil {
+		return models.PullRequest{}, err
 	}
 
-	return stats, nil
-}
+	pr.RequiredReviewers, err = requiredReviewersByPRID(ctx, t, pr.ID)
+	if err != nil {
+		return models.PullRequest{}, err
+	}
 
-func (p *PostgresDB) GetCountPRStatsByTeam(ctx context.Context) ([]models.TeamStats, error) {
-	r, err := p.db.QueryContext(ctx,
-		`SELECT
-			t.name,
-			COUNT(DISTINCT u.user_id) AS users_count,
-			COUNT(pr.pr_id) AS total_pr,
-			COUNT(pr.pr_id) FILTER (WHERE pr.pr_status = 'OPEN')  AS open_pr,
-			COUNT(pr.pr_id) FILTER (WHERE pr.pr_status = 'MERGED') AS merged_pr
-		FROM teams t
-		LEFT JOIN users u ON u.team_id = t.team_id
-		LEFT JOIN pull_requests pr ON pr.author_id = u.user_id
-		GROUP BY t.name
-		ORDER BY t.name;
-		`)
+	pr.CodeownerReviewers, err = codeownerReviewersByPRID(ctx, t, pr.ID)
 	if err != nil {
-		return nil, err
+		return models.PullRequest{}, err
 	}
-	stats := make([]models.TeamStats, 0, 10)
 
-	for r.Next() {
-		var s models.TeamStats
-		if err := r.Scan(&s.TeamName, &s.UsersCount, &s.AllPRCount, &s.OpenPRCount, &s.MergedPRCount); err != nil {
-			return nil, err
+	return pr, nil
+}
+
+// resolvePRTeam returns the team a new PR of the author belongs to and share
+// locks the membership row. A requested team must be one of the author's teams;
+// without one the primary team is used, and zero means the author has none.
+func resolvePRTeam(ctx context.Context, t *sql.Tx, authorID string, requestedTeamID int64) (int64, error) {
+	var teamID int64
+	if requestedTeamID != 0 {
+		err := t.QueryRowContext(ctx, "SELECT team_id FROM team_members WHERE user_id=$1 AND team_id=$2 FOR SHARE", authorID, requestedTeamID).Scan(&teamID)
+		if err == sql.ErrNoRows {
+			return 0, models.ErrNotTeamMember
 		}
-		stats = append(stats, s)
+		return teamID, err
 	}
 
-	return stats, nil
+	err := t.QueryRowContext(ctx, "SELECT team_id FROM team_members WHERE user_id=$1 AND is_primary FOR SHARE", authorID).Scan(&teamID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return teamID, err
 }
 
-func (p *PostgresDB) GetCountReviewerStatsByPR(ctx context.Context) (map[string]int64, error) {
-	r, err := p.db.QueryContext(ctx,
-		`SELECT
-			pr_id,
-			COUNT(*) AS reviewers_count
-		FROM pull_requests_reviewers
-		GROUP BY pr_id
-		ORDER BY pr_id;
-		`)
+func (p *PostgresDB) GetPRByReviewerID(ctx context.Context, reviewerID string) ([]models.PullRequest, error) {
+	r, err := p.db.QueryContext(ctx, `SELECT `+prColumns+` FROM pull_requests_reviewers prr
+	INNER JOIN pull_requests pr 
+	ON prr.pr_id=pr.pr_id
+	WHERE reviewer_id=$1`, reviewerID)
 	if err != nil {
 		return nil, err
 	}
-	stats := make(map[string]int64)
+	defer r.Close()
+
+	pullrequests := make([]models.PullRequest, 0, 1)
 
 	for r.Next() {
-		var prID string
-		var count int64
-		if err := r.Scan(&prID, &count); err != nil {
+		pr, err := scanPR(r)
+		if err != nil {
 			return nil, err
 		}
-		stats[prID] = count
+		pullrequests = append(pullrequests, pr)
 	}
 
-	return stats, nil
+	return pullrequests, r.Err()
 }
 
 func (p *PostgresDB) UpdateUsersActivityInTeam(ctx context.Context, teamID int64) ([]models.User, error) {
-	rows, err := p.db.QueryContext(ctx, "UPDATE users SET is_active=FALSE WHERE team_id=$1 RETURNING user_id, name, is_active", teamID)
+	rows, err := p.db.QueryContext(ctx, `UPDATE users SET is_active=FALSE
+		WHERE user_id IN (SELECT user_id FROM team_members WHERE team_id=$1)
+		RETURNING user_id, name, is_active`, teamID)
 	if err != nil {
 		return nil, err
 	}
@@ -409,29 +511,190 @@ func (p *PostgresDB) UpdateUsersActivityByID(ctx context.Context, usersSet map[s
 	return users, usersSet, nil
 }
 
-func (p *PostgresDB) FoundAvailableReviewerPRAndSwapReviewerInPR(ctx context.Context, pRID string, reviewersID []string, authorID string, oldReviewerID string) (string, error) {
+// lockPRByID reads the pull request and holds a row lock on it until the
+// transaction ends, serializing merge and reassign calls on the same PR.
+func lockPRByID(ctx context.Context, t *sql.Tx, pRID string) (models.PullRequest, error) {
+	return scanPR(t.QueryRowContext(ctx, "SELECT "+prColumns+" FROM pull_requests pr WHERE pr.pr_id=$1 FOR UPDATE", pRID))
+}
+
+func reviewersByPRID(ctx context.Context, q querier, pRID string) ([]string, error) {
+	r, err := q.QueryContext(ctx, "SELECT reviewer_id FROM pull_requests_reviewers WHERE pr_id=$1", pRID)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	reviewersID := make([]string, 0, 2)
+	for r.Next() {
+		var userID string
+		if err := r.Scan(&userID); err != nil {
+			return nil, err
+		}
+		reviewersID = append(reviewersID, userID)
+	}
+
+	return reviewersID, r.Err()
+}
+
+// MergePRInTransaction marks the pull request as merged. Merging an already
+// merged PR is a no-op that returns the stored merge time.
+func (p *PostgresDB) MergePRInTransaction(ctx context.Context, pRID string) (models.PullRequest, []string, error) {
 	t, err := p.db.BeginTx(ctx, &sql.TxOptions{})
 	if err != nil {
-		return "", err
+		return models.PullRequest{}, nil, err
+	}
+
+	pr, err := lockPRByID(ctx, t, pRID)
+	if err != nil {
+		_ = t.Rollback()
+		return models.PullRequest{}, nil, err
+	}
+
+	if pr.Status == models.PRStatusClosed {
+		_ = t.Rollback()
+		return models.PullRequest{}, nil, models.ErrPRClosed
 	}
 
-	r := t.QueryRowContext(ctx, `WITH team AS (SELECT team_id FROM users u INNER JOIN pull_requests pr ON pr.author_id=u.user_id WHERE pr.pr_id=$1),
-	pr_count AS (SELECT reviewer_id, COUNT(*) AS cnt FROM pull_requests_reviewers GROUP BY reviewer_id)
-	SELECT user_id FROM users u INNER JOIN team t ON u.team_id=t.team_id 
-	LEFT JOIN pr_count prc ON prc.reviewer_id=u.user_id 
-	WHERE is_active AND user_id != ALL($2) AND user_id != $3 ORDER BY cnt LIMIT 1`, pRID, pq.Array(reviewersID), authorID)
+	if pr.Status != models.PRStatusMerged {
+		if _, err := t.ExecContext(ctx, "DELETE FROM review_queue WHERE pr_id=$1", pRID); err != nil {
+			_ = t.Rollback()
+			return models.PullRequest{}, nil, err
+		}
+
+		var mergedAt time.Time
+		r := t.QueryRowContext(ctx, "UPDATE pull_requests SET pr_status=$1, merged_at=NOW() WHERE pr_id=$2 RETURNING merged_at", models.PRStatusMerged, pRID)
+		if err := r.Scan(&mergedAt); err != nil {
+			_ = t.Rollback()
+			return models.PullRequest{}, nil, err
+		}
+		pr.Status, pr.MergedAt = models.PRStatusMerged, &mergedAt
+	}
 
-	var newReviewerID string
-	if err := r.Scan(&newReviewerID); err != nil {
+	reviewers, err := reviewersByPRID(ctx, t, pRID)
+	if err != nil {
 		_ = t.Rollback()
-		return "", err
+		return models.PullRequest{}, nil, err
 	}
 
-	_, err = p.db.ExecContext(ctx, `UPDATE pull_requests_reviewers SET reviewer_id=$1 WHERE pr_id=$2 AND reviewer_id=$3`, newReviewerID, pRID, oldReviewerID)
+	return pr, reviewers, t.Commit()
+}
+
+// ReassignReviewerInTransaction replaces oldReviewerID on the pull request with
+// newReviewerID, validated by chooseReviewer, or else with the least loaded
+// active member of the PR's team or its fallback pools. A label rule slot is
+// refilled only from the rule's team. The PR row is locked first and its status
+// and reviewers are re-validated under the lock, so concurrent reassigns cannot
+// assign the same user twice and a merged PR is never changed. If an ordinary
+// slot has no replacement only because the candidates are at their cap, the
+// old reviewer is removed, the slot is queued and the returned ID is empty.
+func (p *PostgresDB) ReassignReviewerInTransaction(ctx context.Context, pRID, oldReviewerID, newReviewerID string) (models.PullRequest, []string, string, error) {
+	t, err := p.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return models.PullRequest{}, nil, "", err
+	}
+
+	if newReviewerID != "" {
+		if err := lockReviewer(ctx, t, newReviewerID); err != nil {
+			_ = t.Rollback()
+			return models.PullRequest{}, nil, "", err
+		}
+	}
+
+	pr, reviewers, newID, err := reassignReviewer(ctx, t, models.AssignmentReassign, pRID, oldReviewerID, newReviewerID)
 	if err != nil {
 		_ = t.Rollback()
-		return "", err
+		return models.PullRequest{}, nil, "", err
+	}
+
+	return pr, reviewers, newID, t.Commit()
+}
+
+// reassignReviewer takes the old reviewer's slot on the open PR and gives it to
+// the new reviewer, or to a replacement picked from the slot's pool when
+// newReviewerID is empty. An ordinary slot nobody can take now is queued. It
+// returns the PR, its reviewers and the replacement, empty for a queued slot.
+// With newReviewerID given, the caller must hold their lockReviewer lock.
+func reassignReviewer(ctx context.Context, t *sql.Tx, action, pRID, oldReviewerID, newReviewerID string) (models.PullRequest, []string, string, error) {
+	pr, reviewers, err := lockOpenPR(ctx, t, pRID)
+	if err != nil {
+		return models.PullRequest{}, nil, "", err
+	}
+	idx := slices.Index(reviewers, oldReviewerID)
+	if idx == -1 {
+		return models.PullRequest{}, nil, "", models.ErrNotAssigned
+	}
+
+	slot, err := reviewerSlotOf(ctx, t, pRID, oldReviewerID)
+	if err != nil {
+		return models.PullRequest{}, nil, "", err
+	}
+
+	sel, err := prSelection(ctx, t, pRID, pr.AuthorID)
+	if err != nil {
+		return models.PullRequest{}, nil, "", err
 	}
-	err = t.Commit()
-	return newReviewerID, err
+
+	policy, err := teamReviewPolicy(ctx, t, pr.TeamID)
+	if err != nil {
+		return models.PullRequest{}, nil, "", err
+	}
+
+	remaining := slices.Delete(slices.Clone(reviewers), idx, idx+1)
+	var newReviewer pickedReviewer
+	if newReviewerID != "" {
+		newReviewer, err = chooseReviewer(ctx, t, pr, sel, policy, slot, reviewers, newReviewerID)
+		if err == nil {
+			err = checkReviewPolicy(ctx, t, policy, append(remaining, newReviewerID))
+		}
+		if err != nil {
+			return models.PullRequest{}, nil, "", err
+		}
+	} else {
+		newReviewer, err = pickPolicyReplacement(ctx, t, pr.TeamID, policy, sel, slot, append([]string{pr.AuthorID}, reviewers...), remaining)
+	}
+	if err == nil || err == models.ErrNoCandidate {
+		if err := recordReplacement(ctx, t, action, pr, sel, policy, reviewers, oldReviewerID, newReviewer); err != nil {
+			return models.PullRequest{}, nil, "", err
+		}
+	}
+	if err == models.ErrNoCandidate && slot == (reviewerSlot{}) {
+		newReviewer, err = pickedReviewer{}, queueReassignment(ctx, t, pr, oldReviewerID, reviewers)
+		reviewers = slices.Delete(reviewers, idx, idx+1)
+	} else if err == nil {
+		err = replaceReviewer(ctx, t, pRID, oldReviewerID, newReviewer)
+		reviewers[idx] = newReviewer.userID
+	}
+	if err != nil {
+		return models.PullRequest{}, nil, "", err
+	}
+
+	handover := models.ReviewHandover{PRID: pRID, OldReviewerID: oldReviewerID, NewReviewerID: newReviewer.userID}
+	if err := insertEvent(ctx, t, models.EventReviewerReplaced, pRID, handover); err != nil {
+		return models.PullRequest{}, nil, "", err
+	}
+
+	pr, err = withReviewerDetails(ctx, t, pr)
+	if err != nil {
+		return models.PullRequest{}, nil, "", err
+	}
+
+	return pr, reviewers, newReviewer.userID, nil
+}
+
+// queueReassignment removes the reviewer from the PR and queues their slot if
+// someone at their cap could take it over, or returns models.ErrNoCandidate.
+func queueReassignment(ctx context.Context, t *sql.Tx, pr models.PullRequest, oldReviewerID string, reviewers []string) error {
+	capped, err := cappedCandidates(ctx, t, pr.TeamID, append([]string{pr.AuthorID}, reviewers...), nil)
+	if err != nil {
+		return err
+	}
+	if capped == 0 {
+		return models.ErrNoCandidate
+	}
+
+	if _, err := t.ExecContext(ctx, "DELETE FROM pull_requests_reviewers WHERE pr_id=$1 AND reviewer_id=$2", pr.ID, oldReviewerID); err != nil {
+		return err
+	}
+	_, err = enqueuePendingReviewers(ctx, t, pr.ID, 1, pr.Priority)
+	return err
 }
@@ -0,0 +1,130 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+// prSortKeys are the expressions PRs are listed by and the SQL types their
+// cursor values are cast to.
+var prSortKeys = map[models.PRSort]struct{ expr, typ string }{
+	models.PRSortCreatedAt: {"pr.created_at", "timestamptz"},
+	models.PRSortName:      {"pr.name", "text"},
+	models.PRSortSize:      {"pr.lines_added + pr.lines_removed", "int"},
+}
+
+// likeEscaper escapes the wildcards of ILIKE.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ListPRs returns up to f.Limit PRs matching the filter in its order, with
+// their reviewers and labels.
+func (p *PostgresDB) ListPRs(ctx context.Context, f models.PRListFilter) ([]models.PRSummary, error) {
+	key := prSortKeys[f.Sort]
+	cmp, dir := ">", "ASC"
+	if f.Desc {
+		cmp, dir = "<", "DESC"
+	}
+
+	args := []any{nullString(string(f.Status)), nullString(f.AuthorID), nullString(f.ReviewerID), nullTeamID(f.TeamID),
+		nullString(f.Label), nullString(likeEscaper.Replace(f.Name)), f.CreatedFrom, f.CreatedTo, f.MergedFrom, f.MergedTo, nil, nil, f.Limit}
+	if f.After != nil {
+		args[10], args[11] = f.After.Value, f.After.ID
+	}
+
+	r, err := p.db.QueryContext(ctx, `SELECT `+prColumns+`, COALESCE(t.name, ''),
+		ARRAY(SELECT prr.reviewer_id FROM pull_requests_reviewers prr WHERE prr.pr_id=pr.pr_id ORDER BY prr.reviewer_id),
+		ARRAY(SELECT pl.label FROM pull_request_labels pl WHERE pl.pr_id=pr.pr_id ORDER BY pl.label)
+		FROM pull_requests pr
+		LEFT JOIN teams t ON t.team_id=pr.team_id
+		WHERE ($1::text IS NULL OR pr.pr_status=$1)
+		AND ($2::text IS NULL OR pr.author_id=$2)
+		AND ($3::text IS NULL OR EXISTS (SELECT 1 FROM pull_requests_reviewers prr WHERE prr.pr_id=pr.pr_id AND prr.reviewer_id=$3))
+		AND ($4::int IS NULL OR pr.team_id=$4)
+		AND ($5::text IS NULL OR EXISTS (SELECT 1 FROM pull_request_labels pl WHERE pl.pr_id=pr.pr_id AND pl.label=$5))
+		AND ($6::text IS NULL OR pr.name ILIKE '%' || $6 || '%')
+		AND ($7::timestamptz IS NULL OR pr.created_at >= $7)
+		AND ($8::timestamptz IS NULL OR pr.created_at < $8)
+		AND ($9::timestamptz IS NULL OR pr.merged_at >= $9)
+		AND ($10::timestamptz IS NULL OR pr.merged_at < $10)
+		AND ($11::text IS NULL OR (`+key.expr+`, pr.pr_id) `+cmp+` ($11::`+key.typ+`, $12::text))
+		ORDER BY `+key.expr+` `+dir+`, pr.pr_id `+dir+`
+		LIMIT $13`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	prs := make([]models.PRSummary, 0, f.Limit)
+	for r.Next() {
+		var (
+			teamName          string
+			reviewers, labels []string
+		)
+		pr, err := scanPR(r, &teamName, pq.Array(&reviewers), pq.Array(&labels))
+		if err != nil {
+			return nil, err
+		}
+		prs = append(prs, prSummary(pr, teamName, append([]string{}, reviewers...), append([]string{}, labels...)))
+	}
+
+	return prs, r.Err()
+}
+
+// GetPRDetails returns the PR with its reviewers, labels, tags and reviewer
+// slots, or sql.ErrNoRows.
+func (p *PostgresDB) GetPRDetails(ctx context.Context, pRID string) (models.PRDetails, error) {
+	var teamName string
+	pr, err := scanPR(p.db.QueryRowContext(ctx, `SELECT `+prColumns+`, COALESCE(t.name, '') FROM pull_requests pr
+		LEFT JOIN teams t ON t.team_id=pr.team_id WHERE pr.pr_id=$1`, pRID), &teamName)
+	if err != nil {
+		return models.PRDetails{}, err
+	}
+	if pr, err = withReviewerDetails(ctx, p.db, pr); err != nil {
+		return models.PRDetails{}, err
+	}
+	reviewers, err := reviewersByPRID(ctx, p.db, pRID)
+	if err != nil {
+		return models.PRDetails{}, err
+	}
+	labels, err := prLabels(ctx, p.db, pRID)
+	if err != nil {
+		return models.PRDetails{}, err
+	}
+	tags, err := prTags(ctx, p.db, pRID)
+	if err != nil {
+		return models.PRDetails{}, err
+	}
+
+	return models.PRDetails{
+		PRSummary:          prSummary(pr, teamName, reviewers, labels),
+		Tags:               tags,
+		FallbackReviewers:  pr.FallbackReviewers,
+		RequiredReviewers:  pr.RequiredReviewers,
+		CodeownerReviewers: pr.CodeownerReviewers,
+		PendingReviewers:   pr.PendingReviewers,
+	}, nil
+}
+
+func prSummary(pr models.PullRequest, teamName string, reviewers, labels []string) models.PRSummary {
+	return models.PRSummary{
+		PRID:       pr.ID,
+		PRName:     pr.Name,
+		AuthorID:   pr.AuthorID,
+		Status:     pr.Status,
+		TeamName:   teamName,
+		Priority:   pr.Priority,
+		PRMetadata: pr.PRMetadata,
+		Labels:     labels,
+		Reviewers:  reviewers,
+		MergedAt:   pr.MergedAt,
+		ClosedAt:   pr.ClosedAt,
+	}
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -0,0 +1,321 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+// AcceptReviewInTransaction marks the reviewer's assignment on the open PR as
+// accepted and returns the PR, its reviewers and when the review was accepted.
+// Accepting again keeps the first time. An acceptance is the reviewer's first
+// response unless they responded before.
+func (p *PostgresDB) AcceptReviewInTransaction(ctx context.Context, pRID, reviewerID string) (models.PullRequest, []string, time.Time, error) {
+	t, err := p.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return models.PullRequest{}, nil, time.Time{}, err
+	}
+
+	pr, reviewers, err := lockOpenPR(ctx, t, pRID)
+	if err != nil {
+		_ = t.Rollback()
+		return models.PullRequest{}, nil, time.Time{}, err
+	}
+	if !slices.Contains(reviewers, reviewerID) {
+		_ = t.Rollback()
+		return models.PullRequest{}, nil, time.Time{}, models.ErrNotAssigned
+	}
+
+	var acceptedAt time.Time
+	err = t.QueryRowContext(ctx, `UPDATE pull_requests_reviewers SET accepted_at=COALESCE(accepted_at, NOW()),
+		first_response_at=COALESCE(first_response_at, NOW())
+		WHERE pr_id=$1 AND reviewer_id=$2 RETURNING accepted_at`, pRID, reviewerID).Scan(&acceptedAt)
+	if err != nil {
+		_ = t.Rollback()
+		return models.PullRequest{}, nil, time.Time{}, err
+	}
+
+	payload := map[string]any{"reviewer_id": reviewerID, "accepted_at": acceptedAt}
+	if err := insertEvent(ctx, t, models.EventReviewAccepted, pRID, payload); err != nil {
+		_ = t.Rollback()
+		return models.PullRequest{}, nil, time.Time{}, err
+	}
+
+	pr, err = withReviewerDetails(ctx, t, pr)
+	if err != nil {
+		_ = t.Rollback()
+		return models.PullRequest{}, nil, time.Time{}, err
+	}
+
+	return pr, reviewers, acceptedAt, t.Commit()
+}
+
+// DeclineReviewInTransaction records the reviewer's decline and gives their
+// slot to a replacement the way ReassignReviewerInTransaction does without a
+// chosen reviewer. The reviewer is not picked for the PR again. The returned
+// reviewer ID is empty when the slot was queued.
+func (p *PostgresDB) DeclineReviewInTransaction(ctx context.Context, pRID, reviewerID, reason string) (models.PullRequest, []string, string, error) {
+	t, err := p.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return models.PullRequest{}, nil, "", err
+	}
+
+	_, reviewers, err := lockOpenPR(ctx, t, pRID)
+	if err != nil {
+		_ = t.Rollback()
+		return models.PullRequest{}, nil, "", err
+	}
+	if !slices.Contains(reviewers, reviewerID) {
+		_ = t.Rollback()
+		return models.PullRequest{}, nil, "", models.ErrNotAssigned
+	}
+
+	if _, err := insertDecline(ctx, t, pRID, reviewerID, reason, false); err != nil {
+		_ = t.Rollback()
+		return models.PullRequest{}, nil, "", err
+	}
+
+	pr, reviewers, newReviewerID, err := reassignReviewer(ctx, t, models.AssignmentDecline, pRID, reviewerID, "")
+	if err != nil {
+		_ = t.Rollback()
+		return models.PullRequest{}, nil, "", err
+	}
+
+	return pr, reviewers, newReviewerID, t.Commit()
+}
+
+// ackStartedAt is when the time to accept a review started: its assignment, or
+// the last time nobody could take it over after it expired. assigned_at itself
+// is never moved, as SLAs, the inbox and the stats count from it.
+const ackStartedAt = `COALESCE(prr.ack_restarted_at, prr.assigned_at)`
+
+// ReassignUnacceptedReviews takes the reviews not accepted within deadline of
+// ackStartedAt on open PRs from their reviewers, as if they declined them.
+// A review nobody can take over is kept and its deadline restarted. Every
+// review is handed over in its own transaction; the returned events are the
+// recorded declines.
+func (p *PostgresDB) ReassignUnacceptedReviews(ctx context.Context, deadline time.Duration) ([]models.Event, error) {
+	r, err := p.db.QueryContext(ctx, `SELECT prr.pr_id, prr.reviewer_id FROM pull_requests_reviewers prr
+		INNER JOIN pull_requests pr ON pr.pr_id=prr.pr_id
+		WHERE prr.accepted_at IS NULL AND `+ackStartedAt+` <= NOW() - make_interval(secs => $1) AND pr.pr_status='OPEN'
+		ORDER BY `+ackStartedAt+` LIMIT $2`, deadline.Seconds(), maxQueueBatch)
+	if err != nil {
+		return nil, err
+	}
+
+	type dueReview struct {
+		prID       string
+		reviewerID string
+	}
+	due := make([]dueReview, 0, 2)
+	for r.Next() {
+		var d dueReview
+		if err := r.Scan(&d.prID, &d.reviewerID); err != nil {
+			_ = r.Close()
+			return nil, err
+		}
+		due = append(due, d)
+	}
+	_ = r.Close()
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+
+	events := make([]models.Event, 0)
+	for _, d := range due {
+		e, err := p.expireReview(ctx, d.prID, d.reviewerID, deadline)
+		if err == models.ErrNoCandidate {
+			_, err = p.db.ExecContext(ctx, `UPDATE pull_requests_reviewers SET ack_restarted_at=NOW()
+				WHERE pr_id=$1 AND reviewer_id=$2 AND accepted_at IS NULL`, d.prID, d.reviewerID)
+			if err != nil {
+				return events, err
+			}
+			continue
+		}
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return events, err
+		}
+		events = append(events, e)
+	}
+
+	return events, nil
+}
+
+// expireReview hands over one overdue review, or returns sql.ErrNoRows if it
+// was accepted, reassigned or its PR finished in the meantime.
+func (p *PostgresDB) expireReview(ctx context.Context, pRID, reviewerID string, deadline time.Duration) (models.Event, error) {
+	t, err := p.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return models.Event{}, err
+	}
+
+	var overdue bool
+	err = t.QueryRowContext(ctx, `SELECT prr.accepted_at IS NULL AND `+ackStartedAt+` <= NOW() - make_interval(secs => $3) AND pr.pr_status='OPEN'
+		FROM pull_requests_reviewers prr
+		INNER JOIN pull_requests pr ON pr.pr_id=prr.pr_id
+		WHERE prr.pr_id=$1 AND prr.reviewer_id=$2
+		FOR UPDATE OF prr`, pRID, reviewerID, deadline.Seconds()).Scan(&overdue)
+	if err == nil && !overdue {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		_ = t.Rollback()
+		return models.Event{}, err
+	}
+
+	decline, err := insertDecline(ctx, t, pRID, reviewerID, "", true)
+	if err != nil {
+		_ = t.Rollback()
+		return models.Event{}, err
+	}
+
+	if _, _, _, err := reassignReviewer(ctx, t, models.AssignmentExpire, pRID, reviewerID, ""); err != nil {
+		_ = t.Rollback()
+		return models.Event{}, err
+	}
+
+	e, err := recordEvent(ctx, t, models.EventReviewDeclined, pRID, decline)
+	if err != nil {
+		_ = t.Rollback()
+		return models.Event{}, err
+	}
+
+	return e, t.Commit()
+}
+
+// insertDecline records that the reviewer gave up the review of the PR, with
+// their assignment and first response kept from the reviewer row it replaces.
+// A decline is itself a first response; an expired review has none of its own.
+func insertDecline(ctx context.Context, q querier, pRID, reviewerID, reason string, expired bool) (models.ReviewDecline, error) {
+	d := models.ReviewDecline{PRID: pRID, ReviewerID: reviewerID, Reason: reason, Expired: expired}
+	err := q.QueryRowContext(ctx, `INSERT INTO review_declines (pr_id, reviewer_id, reason, expired, assigned_at, first_response_at)
+		VALUES ($1, $2, $3, $4,
+			(SELECT assigned_at FROM pull_requests_reviewers WHERE pr_id=$1 AND reviewer_id=$2),
+			(SELECT CASE WHEN $4 THEN first_response_at ELSE COALESCE(first_response_at, NOW()) END
+				FROM pull_requests_reviewers WHERE pr_id=$1 AND reviewer_id=$2))
+		RETURNING declined_at, assigned_at, first_response_at`, pRID, reviewerID, reason, expired).
+		Scan(&d.DeclinedAt, &d.AssignedAt, &d.FirstResponseAt)
+	if err != nil {
+		return models.ReviewDecline{}, err
+	}
+	if !expired {
+		err = insertEvent(ctx, q, models.EventReviewDeclined, pRID, d)
+	}
+	return d, err
+}
+
+// declinedReviewers returns the users who declined the PR or let its review
+// expire.
+func declinedReviewers(ctx context.Context, q querier, pRID string) ([]string, error) {
+	r, err := q.QueryContext(ctx, "SELECT DISTINCT reviewer_id FROM review_declines WHERE pr_id=$1 ORDER BY reviewer_id", pRID)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var declined []string
+	for r.Next() {
+		var userID string
+		if err := r.Scan(&userID); err != nil {
+			return nil, err
+		}
+		declined = append(declined, userID)
+	}
+
+	return declined, r.Err()
+}
+
+// GetDeclineStats counts the declined and expired reviews matching the filter,
+// declined by the user with it, and groups the declines by reason, the most
+// common first. Without grouping it returns a single DeclineStats. Both queries
+// read one snapshot, so the reasons always add up to the counts.
+func (p *PostgresDB) GetDeclineStats(ctx context.Context, f models.StatsFilter) ([]models.DeclineStats, error) {
+	t, err := p.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = t.Rollback() }()
+
+	where := statsWindow("rd.declined_at") + ` AND ` + statsTeamPRs + ` AND ($4::text IS NULL OR rd.reviewer_id=$4)`
+
+	r, err := t.QueryContext(ctx, `SELECT NULLIF(`+statsBucket("rd.declined_at")+`, '-infinity') AS bucket,
+		COUNT(*) FILTER (WHERE NOT rd.expired), COUNT(*) FILTER (WHERE rd.expired)
+		FROM review_declines rd
+		INNER JOIN pull_requests pr ON pr.pr_id=rd.pr_id
+		WHERE `+where+`
+		GROUP BY 1
+		ORDER BY 1`, statsArgs(f)...)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]models.DeclineStats, 0, 1)
+	for r.Next() {
+		s := models.DeclineStats{Reasons: make([]models.DeclineReasonStats, 0)}
+		if err := r.Scan(&s.Bucket, &s.DeclinedCount, &s.ExpiredCount); err != nil {
+			_ = r.Close()
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	_ = r.Close()
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+	if f.GroupBy == "" && len(stats) == 0 {
+		stats = append(stats, models.DeclineStats{Reasons: make([]models.DeclineReasonStats, 0)})
+	}
+
+	// The scanned times may differ in location, so buckets are keyed by their
+	// instant; ungrouped stats have no bucket and are keyed by zero.
+	byBucket := make(map[int64]int, len(stats))
+	for i, s := range stats {
+		byBucket[bucketKey(s.Bucket)] = i
+	}
+
+	r, err = t.QueryContext(ctx, `SELECT NULLIF(`+statsBucket("rd.declined_at")+`, '-infinity') AS bucket,
+		MIN(rd.reason), COUNT(*) AS cnt
+		FROM review_declines rd
+		INNER JOIN pull_requests pr ON pr.pr_id=rd.pr_id
+		WHERE NOT rd.expired AND `+where+`
+		GROUP BY 1, LOWER(rd.reason)
+		ORDER BY 1, cnt DESC, LOWER(rd.reason)`, statsArgs(f)...)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	for r.Next() {
+		var (
+			bucket *time.Time
+			s      models.DeclineReasonStats
+		)
+		if err := r.Scan(&bucket, &s.Reason, &s.Count); err != nil {
+			return nil, err
+		}
+		i, ok := byBucket[bucketKey(bucket)]
+		if !ok {
+			continue
+		}
+		stats[i].Reasons = append(stats[i].Reasons, s)
+	}
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+
+	return stats, t.Commit()
+}
+
+// bucketKey identifies a stats bucket by its instant, or zero without one.
+func bucketKey(bucket *time.Time) int64 {
+	if bucket == nil {
+		return 0
+	}
+	return bucket.UnixNano()
+}
@@ -0,0 +1,339 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+// SetSeniority returns sql.ErrNoRows if there is no such user.
+func (p *PostgresDB) SetSeniority(ctx context.Context, userID string, seniority models.Seniority) error {
+	res, err := p.db.ExecContext(ctx, "UPDATE users SET seniority=$1 WHERE user_id=$2", seniority, userID)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SetReviewPolicy sets the policy for the team's PRs; the zero policy removes
+// it.
+func (p *PostgresDB) SetReviewPolicy(ctx context.Context, teamID int64, policy models.ReviewPolicy) error {
+	if policy == (models.ReviewPolicy{}) {
+		_, err := p.db.ExecContext(ctx, "DELETE FROM team_review_policies WHERE team_id=$1", teamID)
+		return err
+	}
+
+	_, err := p.db.ExecContext(ctx, `INSERT INTO team_review_policies (team_id, min_seniors, no_sole_junior, exclude_bots) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (team_id) DO UPDATE SET min_seniors=EXCLUDED.min_seniors, no_sole_junior=EXCLUDED.no_sole_junior, exclude_bots=EXCLUDED.exclude_bots`,
+		teamID, policy.MinSeniors, policy.NoSoleJunior, policy.ExcludeBots)
+	return err
+}
+
+func (p *PostgresDB) GetReviewPolicy(ctx context.Context, teamID int64) (models.ReviewPolicy, error) {
+	return teamReviewPolicy(ctx, p.db, teamID)
+}
+
+// teamReviewPolicy returns the policy for PRs of the team, the zero policy if
+// it has none or teamID is zero.
+func teamReviewPolicy(ctx context.Context, q querier, teamID int64) (models.ReviewPolicy, error) {
+	var policy models.ReviewPolicy
+	err := q.QueryRowContext(ctx, "SELECT min_seniors, no_sole_junior, exclude_bots FROM team_review_policies WHERE team_id=$1", teamID).
+		Scan(&policy.MinSeniors, &policy.NoSoleJunior, &policy.ExcludeBots)
+	if err == sql.ErrNoRows {
+		return models.ReviewPolicy{}, nil
+	}
+	return policy, err
+}
+
+func isPolicyError(err error) bool {
+	return err == models.ErrPolicyMinSeniors || err == models.ErrPolicySoleJunior
+}
+
+// policyLevels returns the seniorities the policy lets review, out of levels if
+// any are given, for selection.levels. It is nil when anyone may review.
+func policyLevels(policy models.ReviewPolicy, levels ...models.Seniority) []string {
+	if len(levels) == 0 {
+		if !policy.ExcludeBots {
+			return nil
+		}
+		levels = []models.Seniority{models.SeniorityJunior, models.SeniorityMid, models.SenioritySenior, models.SeniorityLead}
+	}
+
+	values := make([]string, 0, len(levels))
+	for _, l := range levels {
+		if policy.ExcludeBots && l == models.SeniorityBot {
+			continue
+		}
+		values = append(values, string(l))
+	}
+	return values
+}
+
+// ruleLevels returns the seniorities one of which a reviewer must have to mend
+// the rule of the policy error.
+func ruleLevels(err error) []models.Seniority {
+	switch err {
+	case models.ErrPolicyMinSeniors:
+		return []models.Seniority{models.SenioritySenior, models.SeniorityLead}
+	case models.ErrPolicySoleJunior:
+		return []models.Seniority{models.SeniorityMid, models.SenioritySenior, models.SeniorityLead}
+	}
+	return nil
+}
+
+// reviewerMix describes a reviewer set: how many seniors it has, whether it has
+// juniors and whether it has mid or senior reviewers a junior may review beside.
+type reviewerMix struct {
+	seniors                 int
+	hasJunior, hasNonJunior bool
+}
+
+func seniorities(ctx context.Context, q querier, userIDs []string) (map[string]models.Seniority, error) {
+	levels := make(map[string]models.Seniority, len(userIDs))
+	if len(userIDs) == 0 {
+		return levels, nil
+	}
+
+	r, err := q.QueryContext(ctx, "SELECT user_id, seniority FROM users WHERE user_id = ANY($1)", pq.Array(userIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	for r.Next() {
+		var (
+			id string
+			s  models.Seniority
+		)
+		if err := r.Scan(&id, &s); err != nil {
+			return nil, err
+		}
+		levels[id] = s
+	}
+
+	return levels, r.Err()
+}
+
+func mixIn(levels map[string]models.Seniority, userIDs []string) reviewerMix {
+	var mix reviewerMix
+	for _, id := range userIDs {
+		switch s := levels[id]; {
+		case s == models.SeniorityJunior:
+			mix.hasJunior = true
+		case s.Senior():
+			mix.seniors++
+			mix.hasNonJunior = true
+		case s == models.SeniorityMid:
+			mix.hasNonJunior = true
+		}
+	}
+	return mix
+}
+
+func mixOf(ctx context.Context, q querier, userIDs []string) (reviewerMix, error) {
+	levels, err := seniorities(ctx, q, userIDs)
+	if err != nil {
+		return reviewerMix{}, err
+	}
+	return mixIn(levels, userIDs), nil
+}
+
+// checkReviewPolicy returns the error of the first rule of the policy the
+// reviewer set breaks, or nil.
+func checkReviewPolicy(ctx context.Context, q querier, policy models.ReviewPolicy, reviewers []string) error {
+	if policy.MinSeniors == 0 && !policy.NoSoleJunior {
+		return nil
+	}
+
+	mix, err := mixOf(ctx, q, reviewers)
+	if err != nil {
+		return err
+	}
+	if mix.seniors < policy.MinSeniors {
+		return models.ErrPolicyMinSeniors
+	}
+	if policy.NoSoleJunior && mix.hasJunior && !mix.hasNonJunior {
+		return models.ErrPolicySoleJunior
+	}
+	return nil
+}
+
+func pickedIDs(picked []pickedReviewer) []string {
+	ids := make([]string, 0, len(picked))
+	for _, p := range picked {
+		ids = append(ids, p.userID)
+	}
+	return ids
+}
+
+// fitPreferred drops the last non-senior preferred reviewers while the slots
+// left after them are too few for the seniors the policy still needs beside
+// the chosen reviewers.
+func fitPreferred(ctx context.Context, q querier, policy models.ReviewPolicy, chosen []string, preferred []pickedReviewer, slots int) ([]pickedReviewer, error) {
+	if policy.MinSeniors == 0 || len(preferred) == 0 {
+		return preferred, nil
+	}
+
+	ids := append(append([]string{}, chosen...), pickedIDs(preferred)...)
+	levels, err := seniorities(ctx, q, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	needed := policy.MinSeniors - mixIn(levels, chosen).seniors
+	fitted := append([]pickedReviewer{}, preferred...)
+	for i := len(fitted) - 1; i >= 0; i-- {
+		if slots-len(fitted) >= needed-mixIn(levels, pickedIDs(fitted)).seniors {
+			break
+		}
+		if !levels[fitted[i].userID].Senior() {
+			fitted = append(fitted[:i], fitted[i+1:]...)
+		}
+	}
+
+	return fitted, nil
+}
+
+// pickPolicyReviewers picks up to count reviewers for a PR of the team the way
+// pickReviewers does, beside the current reviewers, so that the set meets the
+// policy: the missing seniors are picked first among seniors and, if the set
+// would have juniors only, the last picked junior is swapped for a mid or
+// senior reviewer. It returns the error of the rule it cannot satisfy.
+func pickPolicyReviewers(ctx context.Context, q querier, teamID int64, policy models.ReviewPolicy, sel selection, exclude, current []string, count int) ([]pickedReviewer, error) {
+	exclude = append([]string{}, exclude...)
+	sel.levels = policyLevels(policy)
+	if policy.MinSeniors == 0 && !policy.NoSoleJunior {
+		return pickReviewers(ctx, q, teamID, sel, exclude, count)
+	}
+
+	mix, err := mixOf(ctx, q, current)
+	if err != nil {
+		return nil, err
+	}
+
+	picked := make([]pickedReviewer, 0, count)
+	if needed := policy.MinSeniors - mix.seniors; needed > 0 {
+		if needed > count {
+			return nil, models.ErrPolicyMinSeniors
+		}
+		seniors := sel
+		seniors.levels = policyLevels(policy, ruleLevels(models.ErrPolicyMinSeniors)...)
+		picked, err = pickReviewers(ctx, q, teamID, seniors, exclude, needed)
+		if err != nil {
+			return nil, err
+		}
+		if len(picked) < needed {
+			return nil, models.ErrPolicyMinSeniors
+		}
+		exclude = append(exclude, pickedIDs(picked)...)
+	}
+
+	rest, err := pickReviewers(ctx, q, teamID, sel, exclude, count-len(picked))
+	if err != nil {
+		return nil, err
+	}
+	picked = append(picked, rest...)
+	exclude = append(exclude, pickedIDs(rest)...)
+
+	if !policy.NoSoleJunior || len(picked) == 0 {
+		return picked, nil
+	}
+	levels, err := seniorities(ctx, q, append(append([]string{}, current...), pickedIDs(picked)...))
+	if err != nil {
+		return nil, err
+	}
+	if mix = mixIn(levels, append(append([]string{}, current...), pickedIDs(picked)...)); !mix.hasJunior || mix.hasNonJunior {
+		return picked, nil
+	}
+
+	others := sel
+	others.levels = policyLevels(policy, ruleLevels(models.ErrPolicySoleJunior)...)
+	swap, err := pickReviewers(ctx, q, teamID, others, exclude, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(swap) == 0 {
+		return nil, models.ErrPolicySoleJunior
+	}
+	for i := len(picked) - 1; i >= 0; i-- {
+		if levels[picked[i].userID] == models.SeniorityJunior {
+			picked[i] = swap[0]
+			break
+		}
+	}
+
+	return picked, nil
+}
+
+// pickPolicyReplacement picks a replacement for the slot the way
+// pickReplacementReviewer does, beside the remaining reviewers. If the set
+// lacks seniors, or has no mid or senior reviewer while the policy forbids a
+// sole junior, the replacement is taken among those. When only a candidate who
+// breaks the policy is left, the error of the rule is returned instead of
+// models.ErrNoCandidate.
+func pickPolicyReplacement(ctx context.Context, q querier, teamID int64, policy models.ReviewPolicy, sel selection, slot reviewerSlot, exclude, remaining []string) (pickedReviewer, error) {
+	sel.levels = policyLevels(policy)
+
+	mix, err := mixOf(ctx, q, remaining)
+	if err != nil {
+		return pickedReviewer{}, err
+	}
+
+	var broken error
+	switch {
+	case mix.seniors < policy.MinSeniors:
+		broken = models.ErrPolicyMinSeniors
+	case policy.NoSoleJunior && !mix.hasNonJunior:
+		broken = models.ErrPolicySoleJunior
+	}
+	if broken == nil {
+		return pickReplacementReviewer(ctx, q, teamID, sel, slot, exclude)
+	}
+
+	mending := sel
+	mending.levels = policyLevels(policy, ruleLevels(broken)...)
+	picked, err := pickReplacementReviewer(ctx, q, teamID, mending, slot, exclude)
+	if err != models.ErrNoCandidate {
+		return picked, err
+	}
+	if _, err := pickReplacementReviewer(ctx, q, teamID, sel, slot, exclude); err != nil {
+		return pickedReviewer{}, err
+	}
+	return pickedReviewer{}, broken
+}
+
+// queuePolicySlots queues the slots of a new PR whose team reviewers would
+// break the rule of the policy error when only reviewers at their cap could
+// mend it: some of the seniorities the rule asks for are capped and the seniors
+// still needed fit into the slots. It returns the pending slots, zero if the
+// PR cannot wait for them.
+func queuePolicySlots(ctx context.Context, q querier, policy models.ReviewPolicy, broken error, pr models.PullRequest, chosen, exclude []string, slots int) (int, error) {
+	if slots == 0 {
+		return 0, nil
+	}
+	if broken == models.ErrPolicyMinSeniors {
+		mix, err := mixOf(ctx, q, chosen)
+		if err != nil {
+			return 0, err
+		}
+		if policy.MinSeniors-mix.seniors > slots {
+			return 0, nil
+		}
+	}
+
+	capped, err := cappedCandidates(ctx, q, pr.TeamID, append(append([]string{}, exclude...), chosen...), policyLevels(policy, ruleLevels(broken)...))
+	if err != nil || capped == 0 {
+		return 0, err
+	}
+	return enqueuePendingReviewers(ctx, q, pr.ID, slots, pr.Priority)
+}
@@ -0,0 +1,345 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+// underCapacity holds for the user u while they review fewer open PRs than
+// their cap: their own max_open_reviews, or else the lowest cap among their
+// non-archived teams. Users without any cap are always under it.
+const underCapacity = `(SELECT COUNT(*) FROM pull_requests_reviewers oprr
+		INNER JOIN pull_requests opr ON opr.pr_id=oprr.pr_id
+		WHERE oprr.reviewer_id=u.user_id AND opr.pr_status='OPEN') < COALESCE(u.max_open_reviews,
+		(SELECT MIN(ct.max_open_reviews) FROM team_members ctm INNER JOIN teams ct ON ct.team_id=ctm.team_id
+		WHERE ctm.user_id=u.user_id AND ct.archived_at IS NULL), 2147483647)`
+
+// maxQueueBatch bounds how many queued PRs one AssignPendingReviewers call
+// looks at.
+const maxQueueBatch = 100
+
+// Transactions that assign reviewers under caps hold a per-user capacity lock
+// on every user they assign, so that two of them cannot both give the last
+// free place of a user away, while assignments of different users still run
+// in parallel.
+
+// lockReviewer waits for the capacity lock of a user chosen by hand. It must
+// be the first lock the transaction takes, since the holder may be waiting
+// for a PR row this transaction would otherwise have locked.
+func lockReviewer(ctx context.Context, t *sql.Tx, userID string) error {
+	_, err := t.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext('review_capacity'), hashtext($1))", userID)
+	return err
+}
+
+// previewQuerier picks reviewers for a preview. claimReviewer only checks the
+// caps of its candidates and takes no capacity locks, so an open preview never
+// keeps a real assignment from picking them.
+type previewQuerier struct{ querier }
+
+// claimReviewer takes the capacity lock of a picked candidate and reports
+// whether they are still under their cap. The lock is only tried: a candidate
+// another transaction is assigning right now is skipped rather than waited
+// for, so transactions that already hold PR rows cannot deadlock. The cap is
+// checked in a statement of its own after the lock is taken, so that it sees
+// the reviews of the previous holder.
+func claimReviewer(ctx context.Context, q querier, userID string) (bool, error) {
+	if _, preview := q.(previewQuerier); !preview {
+		var locked bool
+		err := q.QueryRowContext(ctx, "SELECT pg_try_advisory_xact_lock(hashtext('review_capacity'), hashtext($1))", userID).Scan(&locked)
+		if err != nil || !locked {
+			return false, err
+		}
+	}
+
+	var underCap bool
+	err := q.QueryRowContext(ctx, "SELECT "+underCapacity+" FROM users u WHERE u.user_id=$1", userID).Scan(&underCap)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return underCap, err
+}
+
+// cappedCandidates counts the active members of the team's pools who are not in
+// exclude, are of one of levels unless it is nil, and could review if they were
+// not at their cap.
+func cappedCandidates(ctx context.Context, q querier, teamID int64, exclude, levels []string) (int, error) {
+	pools, err := reviewerPools(ctx, q, teamID)
+	if err != nil {
+		return 0, err
+	}
+	teamIDs := make([]int64, 0, len(pools))
+	for _, pool := range pools {
+		teamIDs = append(teamIDs, pool.teamIDs...)
+	}
+	if len(teamIDs) == 0 {
+		return 0, nil
+	}
+
+	var n int
+	err = q.QueryRowContext(ctx, `SELECT COUNT(DISTINCT u.user_id) FROM users u
+		INNER JOIN team_members tm ON tm.user_id=u.user_id
+		WHERE `+userAvailable+` AND tm.team_id = ANY($1) AND u.user_id != ALL($2)
+		AND ($3::text[] IS NULL OR u.seniority = ANY($3)) AND NOT `+underCapacity,
+		pq.Array(teamIDs), pq.Array(exclude), pq.Array(levels)).Scan(&n)
+	return n, err
+}
+
+// enqueuePendingReviewers puts the PR into the review queue for count more
+// reviewers, adding to the slots it already waits for.
+func enqueuePendingReviewers(ctx context.Context, q querier, pRID string, count, priority int) (int, error) {
+	var pending int
+	err := q.QueryRowContext(ctx, `INSERT INTO review_queue (pr_id, missing_reviewers, priority) VALUES ($1, $2, $3)
+		ON CONFLICT (pr_id) DO UPDATE SET missing_reviewers=review_queue.missing_reviewers+EXCLUDED.missing_reviewers
+		RETURNING missing_reviewers`, pRID, count, priority).Scan(&pending)
+	if err != nil {
+		return 0, err
+	}
+
+	return pending, insertEvent(ctx, q, models.EventReviewersPending, pRID, map[string]int{"missing_reviewers": pending})
+}
+
+func pendingReviewers(ctx context.Context, q querier, pRID string) (int, error) {
+	var pending int
+	err := q.QueryRowContext(ctx, "SELECT missing_reviewers FROM review_queue WHERE pr_id=$1", pRID).Scan(&pending)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return pending, err
+}
+
+func insertEvent(ctx context.Context, q querier, eventType, pRID string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = q.ExecContext(ctx, "INSERT INTO events (event_type, pr_id, payload) VALUES ($1, NULLIF($2, ''), $3)", eventType, pRID, body)
+	return err
+}
+
+// AssignPendingReviewers fills the slots of queued PRs with reviewers who are
+// under their cap, in FIFO order or, with byPriority, highest priority first.
+// A PR that cannot be served, also because its team's review policy cannot be
+// met yet, does not hold back the ones behind it. Every
+// assignment emits a models.EventReviewersAssigned event; the new events are
+// returned.
+func (p *PostgresDB) AssignPendingReviewers(ctx context.Context, byPriority bool) ([]models.Event, error) {
+	t, err := p.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	order := "rq.enqueued_at, rq.pr_id"
+	if byPriority {
+		order = "rq.priority DESC, " + order
+	}
+	r, err := t.QueryContext(ctx, `SELECT rq.pr_id, rq.missing_reviewers, pr.author_id, pr.pr_status, COALESCE(pr.team_id, 0) FROM review_queue rq
+		INNER JOIN pull_requests pr ON pr.pr_id=rq.pr_id
+		ORDER BY `+order+` LIMIT $1
+		FOR UPDATE OF rq, pr`, maxQueueBatch)
+	if err != nil {
+		_ = t.Rollback()
+		return nil, err
+	}
+
+	type queuedPR struct {
+		prID, authorID string
+		missing        int
+		status         models.PRStatus
+		teamID         int64
+	}
+	queue := make([]queuedPR, 0, 8)
+	for r.Next() {
+		var q queuedPR
+		if err := r.Scan(&q.prID, &q.missing, &q.authorID, &q.status, &q.teamID); err != nil {
+			_ = r.Close()
+			_ = t.Rollback()
+			return nil, err
+		}
+		queue = append(queue, q)
+	}
+	_ = r.Close()
+	if err := r.Err(); err != nil {
+		_ = t.Rollback()
+		return nil, err
+	}
+
+	events := make([]models.Event, 0)
+	for _, q := range queue {
+		if q.status != models.PRStatusOpen {
+			if _, err := t.ExecContext(ctx, "DELETE FROM review_queue WHERE pr_id=$1", q.prID); err != nil {
+				_ = t.Rollback()
+				return nil, err
+			}
+			continue
+		}
+
+		reviewers, err := reviewersByPRID(ctx, t, q.prID)
+		if err != nil {
+			_ = t.Rollback()
+			return nil, err
+		}
+		sel, err := prSelection(ctx, t, q.prID, q.authorID)
+		if err != nil {
+			_ = t.Rollback()
+			return nil, err
+		}
+
+		policy, err := teamReviewPolicy(ctx, t, q.teamID)
+		if err != nil {
+			_ = t.Rollback()
+			return nil, err
+		}
+
+		picked, err := pickPolicyReviewers(ctx, t, q.teamID, policy, sel, append([]string{q.authorID}, reviewers...), reviewers, q.missing)
+		if isPolicyError(err) {
+			continue
+		}
+		if err != nil {
+			_ = t.Rollback()
+			return nil, err
+		}
+		if len(picked) == 0 {
+			continue
+		}
+
+		explanation, err := explainAssignment(ctx, t, models.AssignmentQueue, q.teamID, q.authorID, sel, policy, reviewers, picked)
+		if err != nil {
+			_ = t.Rollback()
+			return nil, err
+		}
+		if err := recordAssignment(ctx, t, q.prID, explanation); err != nil {
+			_ = t.Rollback()
+			return nil, err
+		}
+
+		assigned := make([]string, 0, len(picked))
+		for _, reviewer := range picked {
+			if err := insertReviewer(ctx, t, q.prID, reviewer); err != nil {
+				_ = t.Rollback()
+				return nil, err
+			}
+			assigned = append(assigned, reviewer.userID)
+		}
+
+		if len(picked) == q.missing {
+			_, err = t.ExecContext(ctx, "DELETE FROM review_queue WHERE pr_id=$1", q.prID)
+		} else {
+			_, err = t.ExecContext(ctx, "UPDATE review_queue SET missing_reviewers=$1 WHERE pr_id=$2", q.missing-len(picked), q.prID)
+		}
+		if err != nil {
+			_ = t.Rollback()
+			return nil, err
+		}
+
+		payload := struct {
+			Reviewers        []string `json:"assigned_reviewers"`
+			PendingReviewers int      `json:"missing_reviewers"`
+		}{assigned, q.missing - len(picked)}
+		e, err := recordEvent(ctx, t, models.EventReviewersAssigned, q.prID, payload)
+		if err != nil {
+			_ = t.Rollback()
+			return nil, err
+		}
+		events = append(events, e)
+	}
+
+	return events, t.Commit()
+}
+
+// GetEvents returns up to limit events with IDs above afterID, oldest first.
+func (p *PostgresDB) GetEvents(ctx context.Context, afterID int64, limit int) ([]models.Event, error) {
+	r, err := p.db.QueryContext(ctx, `SELECT event_id, event_type, COALESCE(pr_id, ''), payload, created_at FROM events
+		WHERE event_id > $1 ORDER BY event_id LIMIT $2`, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	events := make([]models.Event, 0)
+	for r.Next() {
+		var (
+			e       models.Event
+			payload []byte
+		)
+		if err := r.Scan(&e.ID, &e.Type, &e.PRID, &payload, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.Payload = payload
+		events = append(events, e)
+	}
+
+	return events, r.Err()
+}
+
+// SetUserCapacity sets the user's cap on open reviews, nil removes it. It
+// returns sql.ErrNoRows if there is no such user.
+func (p *PostgresDB) SetUserCapacity(ctx context.Context, userID string, maxOpenReviews *int) error {
+	res, err := p.db.ExecContext(ctx, "UPDATE users SET max_open_reviews=$1 WHERE user_id=$2", maxOpenReviews, userID)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SetTeamCapacity sets the cap on open reviews for members of the team who have
+// none of their own, nil removes it.
+func (p *PostgresDB) SetTeamCapacity(ctx context.Context, teamID int64, maxOpenReviews *int) error {
+	_, err := p.db.ExecContext(ctx, "UPDATE teams SET max_open_reviews=$1 WHERE team_id=$2", maxOpenReviews, teamID)
+	return err
+}
+
+// ClosePRInTransaction closes an open pull request without merging it and drops
+// it from the review queue. Closing a closed PR changes nothing; a merged PR is
+// rejected with models.ErrPRMerged.
+func (p *PostgresDB) ClosePRInTransaction(ctx context.Context, pRID string) (models.PullRequest, []string, error) {
+	t, err := p.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return models.PullRequest{}, nil, err
+	}
+
+	pr, err := lockPRByID(ctx, t, pRID)
+	if err != nil {
+		_ = t.Rollback()
+		return models.PullRequest{}, nil, err
+	}
+
+	switch pr.Status {
+	case models.PRStatusMerged:
+		_ = t.Rollback()
+		return models.PullRequest{}, nil, models.ErrPRMerged
+	case models.PRStatusOpen:
+		var closedAt time.Time
+		r := t.QueryRowContext(ctx, "UPDATE pull_requests SET pr_status=$1, closed_at=NOW() WHERE pr_id=$2 RETURNING closed_at", models.PRStatusClosed, pRID)
+		if err := r.Scan(&closedAt); err != nil {
+			_ = t.Rollback()
+			return models.PullRequest{}, nil, err
+		}
+		pr.Status, pr.ClosedAt = models.PRStatusClosed, &closedAt
+
+		if _, err := t.ExecContext(ctx, "DELETE FROM review_queue WHERE pr_id=$1", pRID); err != nil {
+			_ = t.Rollback()
+			return models.PullRequest{}, nil, err
+		}
+	}
+
+	reviewers, err := reviewersByPRID(ctx, t, pRID)
+	if err != nil {
+		_ = t.Rollback()
+		return models.PullRequest{}, nil, err
+	}
+
+	return pr, reviewers, t.Commit()
+}
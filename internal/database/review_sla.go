@@ -0,0 +1,309 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+// SetSLAPolicy sets the SLA for reviews of the team's PRs; a zero
+// ResponseMinutes removes it.
+func (p *PostgresDB) SetSLAPolicy(ctx context.Context, teamID int64, policy models.SLAPolicy) error {
+	if policy.ResponseMinutes == 0 {
+		_, err := p.db.ExecContext(ctx, "DELETE FROM team_sla_policies WHERE team_id=$1", teamID)
+		return err
+	}
+
+	_, err := p.db.ExecContext(ctx, `INSERT INTO team_sla_policies (team_id, response_minutes, remind_after_minutes, reassign_after_minutes, notify_lead_after_minutes)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (team_id) DO UPDATE SET response_minutes=EXCLUDED.response_minutes, remind_after_minutes=EXCLUDED.remind_after_minutes,
+			reassign_after_minutes=EXCLUDED.reassign_after_minutes, notify_lead_after_minutes=EXCLUDED.notify_lead_after_minutes`,
+		teamID, policy.ResponseMinutes, policy.RemindAfterMinutes, policy.ReassignAfterMinutes, policy.NotifyLeadAfterMinutes)
+	return err
+}
+
+// GetSLAPolicy returns the team's SLA, the zero policy if it has none.
+func (p *PostgresDB) GetSLAPolicy(ctx context.Context, teamID int64) (models.SLAPolicy, error) {
+	var policy models.SLAPolicy
+	err := p.db.QueryRowContext(ctx, `SELECT response_minutes, remind_after_minutes, reassign_after_minutes, notify_lead_after_minutes
+		FROM team_sla_policies WHERE team_id=$1`, teamID).
+		Scan(&policy.ResponseMinutes, &policy.RemindAfterMinutes, &policy.ReassignAfterMinutes, &policy.NotifyLeadAfterMinutes)
+	if err == sql.ErrNoRows {
+		return models.SLAPolicy{}, nil
+	}
+	return policy, err
+}
+
+type slaStep struct {
+	name    string
+	minutes int
+}
+
+// slaLadder returns the escalation steps of the policy in order, with zero
+// minutes for the skipped ones.
+func slaLadder(policy models.SLAPolicy) []slaStep {
+	return []slaStep{
+		{models.SLAStepRemind, policy.RemindAfterMinutes},
+		{models.SLAStepReassign, policy.ReassignAfterMinutes},
+		{models.SLAStepNotifyLead, policy.NotifyLeadAfterMinutes},
+	}
+}
+
+// slaReview is an unaccepted review on an open PR of a team with an SLA.
+type slaReview struct {
+	prID       string
+	teamID     int64
+	reviewerID string
+	assignedAt time.Time
+	breached   bool
+	escalation string
+	policy     models.SLAPolicy
+}
+
+// dueSteps returns the escalation steps after the last one taken whose
+// thresholds the review's age has passed.
+func (rv slaReview) dueSteps(age time.Duration) []slaStep {
+	ladder := slaLadder(rv.policy)
+	next := slices.IndexFunc(ladder, func(s slaStep) bool { return s.name == rv.escalation }) + 1
+
+	due := make([]slaStep, 0, len(ladder))
+	for _, s := range ladder[next:] {
+		if s.minutes == 0 {
+			continue
+		}
+		if age < time.Duration(s.minutes)*time.Minute {
+			break
+		}
+		due = append(due, s)
+	}
+	return due
+}
+
+// EscalateReviewSLAs flags the unaccepted reviews of open PRs that are past
+// their team's response time and takes the due steps of the escalation ladder.
+// Ages are the reviewer's working time since the assignment. Every review is
+// escalated in its own transaction; the breaches and steps are returned as
+// events.
+func (p *PostgresDB) EscalateReviewSLAs(ctx context.Context) ([]models.Event, error) {
+	r, err := p.db.QueryContext(ctx, `SELECT prr.pr_id, pr.team_id, prr.reviewer_id, prr.assigned_at, prr.sla_breached_at IS NOT NULL, COALESCE(prr.sla_escalation, ''),
+			sp.response_minutes, sp.remind_after_minutes, sp.reassign_after_minutes, sp.notify_lead_after_minutes
+		FROM pull_requests_reviewers prr
+		INNER JOIN pull_requests pr ON pr.pr_id=prr.pr_id
+		INNER JOIN team_sla_policies sp ON sp.team_id=pr.team_id
+		WHERE prr.first_response_at IS NULL AND pr.pr_status='OPEN'
+		AND (prr.sla_breached_at IS NULL OR COALESCE(prr.sla_escalation, '') != $1)
+		AND prr.assigned_at <= NOW() - make_interval(mins => LEAST(sp.response_minutes, NULLIF(sp.remind_after_minutes, 0),
+			NULLIF(sp.reassign_after_minutes, 0), NULLIF(sp.notify_lead_after_minutes, 0)))
+		ORDER BY prr.assigned_at`, models.SLAStepNotifyLead)
+	if err != nil {
+		return nil, err
+	}
+
+	reviews := make([]slaReview, 0)
+	reviewerIDs := make([]string, 0)
+	for r.Next() {
+		var rv slaReview
+		if err := r.Scan(&rv.prID, &rv.teamID, &rv.reviewerID, &rv.assignedAt, &rv.breached, &rv.escalation,
+			&rv.policy.ResponseMinutes, &rv.policy.RemindAfterMinutes, &rv.policy.ReassignAfterMinutes, &rv.policy.NotifyLeadAfterMinutes); err != nil {
+			_ = r.Close()
+			return nil, err
+		}
+		reviews = append(reviews, rv)
+		reviewerIDs = append(reviewerIDs, rv.reviewerID)
+	}
+	_ = r.Close()
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+
+	// The reviews are ordered by assignment, so the first one is the oldest.
+	now := time.Now()
+	since := now
+	if len(reviews) > 0 {
+		since = reviews[0].assignedAt
+	}
+	schedules, err := reviewerSchedules(ctx, p.db, reviewerIDs, since)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]models.Event, 0)
+	for _, rv := range reviews {
+		age := schedules[rv.reviewerID].Between(rv.assignedAt, now)
+		breach := !rv.breached && age >= time.Duration(rv.policy.ResponseMinutes)*time.Minute
+		steps := rv.dueSteps(age)
+		if !breach && len(steps) == 0 {
+			continue
+		}
+
+		escalated, err := p.escalateReview(ctx, rv, age, breach, steps)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return events, err
+		}
+		events = append(events, escalated...)
+	}
+
+	return events, nil
+}
+
+// escalateReview flags the breach of the review and takes the steps, or returns
+// sql.ErrNoRows if the review was accepted, reassigned, escalated or its PR
+// finished in the meantime. The ladder stops at a reassignment that found a
+// replacement or queued the slot.
+func (p *PostgresDB) escalateReview(ctx context.Context, rv slaReview, age time.Duration, breach bool, steps []slaStep) ([]models.Event, error) {
+	t, err := p.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		assignedAt time.Time
+		breached   bool
+		escalation string
+	)
+	err = t.QueryRowContext(ctx, `SELECT prr.assigned_at, prr.sla_breached_at IS NOT NULL, COALESCE(prr.sla_escalation, '') FROM pull_requests_reviewers prr
+		INNER JOIN pull_requests pr ON pr.pr_id=prr.pr_id
+		WHERE prr.pr_id=$1 AND prr.reviewer_id=$2 AND prr.first_response_at IS NULL AND pr.pr_status='OPEN'
+		FOR UPDATE OF prr`, rv.prID, rv.reviewerID).Scan(&assignedAt, &breached, &escalation)
+	if err == nil && (!assignedAt.Equal(rv.assignedAt) || breached != rv.breached || escalation != rv.escalation) {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		_ = t.Rollback()
+		return nil, err
+	}
+
+	ageMinutes := int(age / time.Minute)
+	events := make([]models.Event, 0, len(steps)+1)
+	if breach {
+		if _, err := t.ExecContext(ctx, "UPDATE pull_requests_reviewers SET sla_breached_at=NOW() WHERE pr_id=$1 AND reviewer_id=$2", rv.prID, rv.reviewerID); err != nil {
+			_ = t.Rollback()
+			return nil, err
+		}
+		payload := map[string]any{"reviewer_id": rv.reviewerID, "assigned_at": rv.assignedAt, "age_minutes": ageMinutes}
+		e, err := recordEvent(ctx, t, models.EventSLABreached, rv.prID, payload)
+		if err != nil {
+			_ = t.Rollback()
+			return nil, err
+		}
+		events = append(events, e)
+	}
+
+	for _, s := range steps {
+		escalated := models.SLAEscalation{ReviewerID: rv.reviewerID, Step: s.name, AgeMinutes: ageMinutes}
+		reassigned := false
+		switch s.name {
+		case models.SLAStepReassign:
+			// A failed attempt is undone so that it leaves no explanation
+			// behind, and the ladder goes on.
+			if _, err := t.ExecContext(ctx, "SAVEPOINT sla_reassign"); err != nil {
+				_ = t.Rollback()
+				return nil, err
+			}
+			_, _, newReviewerID, err := reassignReviewer(ctx, t, models.AssignmentEscalate, rv.prID, rv.reviewerID, "")
+			if err == models.ErrNoCandidate {
+				_, err = t.ExecContext(ctx, "ROLLBACK TO SAVEPOINT sla_reassign")
+			} else {
+				escalated.NewReviewerID, reassigned = newReviewerID, err == nil
+			}
+			if err != nil {
+				_ = t.Rollback()
+				return nil, err
+			}
+		case models.SLAStepNotifyLead:
+			if escalated.LeadIDs, err = teamLeads(ctx, t, rv.teamID); err != nil {
+				_ = t.Rollback()
+				return nil, err
+			}
+		}
+
+		e, err := recordEvent(ctx, t, models.EventSLAEscalated, rv.prID, escalated)
+		if err != nil {
+			_ = t.Rollback()
+			return nil, err
+		}
+		events = append(events, e)
+		if reassigned {
+			break
+		}
+
+		if _, err := t.ExecContext(ctx, "UPDATE pull_requests_reviewers SET sla_escalation=$1 WHERE pr_id=$2 AND reviewer_id=$3", s.name, rv.prID, rv.reviewerID); err != nil {
+			_ = t.Rollback()
+			return nil, err
+		}
+	}
+
+	return events, t.Commit()
+}
+
+func teamLeads(ctx context.Context, q querier, teamID int64) ([]string, error) {
+	r, err := q.QueryContext(ctx, "SELECT user_id FROM team_members WHERE team_id=$1 AND role=$2 ORDER BY user_id", teamID, models.MemberRoleLead)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	leads := make([]string, 0, 1)
+	for r.Next() {
+		var userID string
+		if err := r.Scan(&userID); err != nil {
+			return nil, err
+		}
+		leads = append(leads, userID)
+	}
+
+	return leads, r.Err()
+}
+
+// GetSLABreaches returns the breached reviews that are still unaccepted on open
+// PRs of the team, or of all teams if teamID is zero, the oldest first.
+func (p *PostgresDB) GetSLABreaches(ctx context.Context, teamID int64) ([]models.SLABreach, error) {
+	r, err := p.db.QueryContext(ctx, `SELECT pr.pr_id, pr.name, t.name, prr.reviewer_id, prr.assigned_at, prr.sla_breached_at, COALESCE(prr.sla_escalation, '')
+		FROM pull_requests_reviewers prr
+		INNER JOIN pull_requests pr ON pr.pr_id=prr.pr_id
+		INNER JOIN teams t ON t.team_id=pr.team_id
+		WHERE prr.sla_breached_at IS NOT NULL AND prr.first_response_at IS NULL AND pr.pr_status='OPEN' AND ($1 = 0 OR pr.team_id=$1)
+		ORDER BY prr.assigned_at, pr.pr_id, prr.reviewer_id`, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	breaches := make([]models.SLABreach, 0)
+	reviewerIDs := make([]string, 0)
+	for r.Next() {
+		var b models.SLABreach
+		if err := r.Scan(&b.PRID, &b.PRName, &b.TeamName, &b.ReviewerID, &b.AssignedAt, &b.BreachedAt, &b.Escalation); err != nil {
+			_ = r.Close()
+			return nil, err
+		}
+		breaches = append(breaches, b)
+		reviewerIDs = append(reviewerIDs, b.ReviewerID)
+	}
+	_ = r.Close()
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	since := now
+	if len(breaches) > 0 {
+		since = breaches[0].AssignedAt
+	}
+	schedules, err := reviewerSchedules(ctx, p.db, reviewerIDs, since)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, b := range breaches {
+		breaches[i].AgeMinutes = int(schedules[b.ReviewerID].Between(b.AssignedAt, now) / time.Minute)
+	}
+	slices.SortStableFunc(breaches, func(a, b models.SLABreach) int { return b.AgeMinutes - a.AgeMinutes })
+
+	return breaches, nil
+}
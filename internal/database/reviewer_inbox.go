@@ -0,0 +1,123 @@
+package database
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+// inboxRankAt is the RankAt of the review prr of the PR pr: its assignment moved
+// back by the priority and breach minutes of its score. Open reviews are listed
+// by it, the earliest first, so aging alone does not reorder them, but a review
+// marked as breaching its SLA moves up: the cursor is not stable across breach
+// flags, and such a review may be skipped or repeated between pages. Merged PRs
+// have no score and follow by their assignment.
+var inboxRankAt = `CASE WHEN pr.pr_status='MERGED' THEN prr.assigned_at
+	ELSE prr.assigned_at - make_interval(mins => pr.priority * ` + strconv.Itoa(models.InboxPriorityMinutes) + ` +
+		CASE WHEN prr.first_response_at IS NULL AND prr.sla_breached_at IS NOT NULL AND sp.response_minutes IS NOT NULL THEN ` + strconv.Itoa(models.InboxBreachMinutes) + ` ELSE 0 END) END`
+
+// GetInbox returns up to f.Limit of the user's reviews of open PRs, and of
+// merged ones with f.IncludeMerged, highest score first; ties go to the PR ID.
+// A cursor value is the PR status and RankAt of the last review of the previous
+// page.
+func (p *PostgresDB) GetInbox(ctx context.Context, f models.InboxFilter) ([]models.InboxItem, error) {
+	args := []any{f.UserID, f.IncludeMerged, nil, nil, f.Limit}
+	if f.After != nil {
+		args[2], args[3] = f.After.Value, f.After.ID
+	}
+
+	r, err := p.db.QueryContext(ctx, `SELECT * FROM (SELECT pr.pr_id, pr.name, pr.author_id, pr.pr_status, COALESCE(t.name, '') AS team_name, COALESCE(pr.url, '') AS url,
+		pr.priority, prr.assigned_at, prr.accepted_at, prr.first_response_at, prr.sla_breached_at IS NOT NULL AS breached, pr.merged_at,
+		COALESCE(sp.response_minutes, 0) AS response_minutes,
+		`+inboxRankAt+` AS rank_at
+		FROM pull_requests_reviewers prr
+		INNER JOIN pull_requests pr ON pr.pr_id=prr.pr_id
+		LEFT JOIN teams t ON t.team_id=pr.team_id
+		LEFT JOIN team_sla_policies sp ON sp.team_id=pr.team_id
+		WHERE prr.reviewer_id=$1 AND (pr.pr_status='OPEN' OR $2 AND pr.pr_status='MERGED')) i
+		WHERE $3::text IS NULL OR (i.pr_status='MERGED', i.rank_at, i.pr_id) >
+			(split_part($3, ' ', 1)='MERGED', split_part($3, ' ', 2)::timestamptz, $4::text)
+		ORDER BY i.pr_status='MERGED', i.rank_at, i.pr_id
+		LIMIT $5`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	type inboxRow struct {
+		item            models.InboxItem
+		breached        bool
+		mergedAt        *time.Time
+		responseMinutes int
+	}
+	rows := make([]inboxRow, 0, f.Limit)
+	for r.Next() {
+		var row inboxRow
+		err := r.Scan(&row.item.PRID, &row.item.PRName, &row.item.AuthorID, &row.item.Status, &row.item.TeamName, &row.item.URL,
+			&row.item.Priority, &row.item.AssignedAt, &row.item.AcceptedAt, &row.item.FirstResponseAt, &row.breached, &row.mergedAt, &row.responseMinutes,
+			&row.item.RankAt)
+		if err != nil {
+			_ = r.Close()
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	_ = r.Close()
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	since := now
+	for _, row := range rows {
+		if row.item.AssignedAt.Before(since) {
+			since = row.item.AssignedAt
+		}
+	}
+	schedules, err := reviewerSchedules(ctx, p.db, []string{f.UserID}, since)
+	if err != nil {
+		return nil, err
+	}
+	schedule := schedules[f.UserID]
+
+	items := make([]models.InboxItem, 0, len(rows))
+	for _, row := range rows {
+		item := row.item
+		item.AckState = models.AckStatePending
+		if item.AcceptedAt != nil {
+			item.AckState = models.AckStateAccepted
+		}
+
+		end := now
+		if row.mergedAt != nil {
+			end = *row.mergedAt
+		}
+		item.AgeMinutes = int(schedule.Between(item.AssignedAt, end) / time.Minute)
+
+		if item.Status == models.PRStatusOpen {
+			if item.FirstResponseAt == nil && row.responseMinutes > 0 {
+				if deadline, ok := schedule.Add(item.AssignedAt, time.Duration(row.responseMinutes)*time.Minute); ok {
+					item.SLADeadline = &deadline
+				}
+				item.SLABreached = row.breached
+			}
+			item.Score = int(now.Sub(item.RankAt) / time.Minute)
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// GetInboxCount counts the user's reviews of open PRs, the unaccepted ones and
+// the ones that breached their SLA.
+func (p *PostgresDB) GetInboxCount(ctx context.Context, userID string) (models.InboxCount, error) {
+	var c models.InboxCount
+	err := p.db.QueryRowContext(ctx, `SELECT COUNT(*), COUNT(*) FILTER (WHERE prr.accepted_at IS NULL),
+		COUNT(*) FILTER (WHERE prr.first_response_at IS NULL AND prr.sla_breached_at IS NOT NULL)
+		FROM pull_requests_reviewers prr
+		INNER JOIN pull_requests pr ON pr.pr_id=prr.pr_id
+		WHERE prr.reviewer_id=$1 AND pr.pr_status='OPEN'`, userID).Scan(&c.Open, &c.Unaccepted, &c.SLABreached)
+	return c, err
+}
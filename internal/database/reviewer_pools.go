@@ -0,0 +1,472 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+// reviewersPerPR is how many reviewers a new pull request gets when there are
+// enough candidates.
+const reviewersPerPR = 2
+
+// reviewerPool is a group of teams candidates are drawn from together. The
+// first pool is always the PR's own team; the others are fallbacks.
+type reviewerPool struct {
+	teamIDs  []int64
+	fallback bool
+}
+
+// reviewerSlot describes why a reviewer holds their place on a PR, so that a
+// replacement can be picked the same way. ruleTeamID is set for a label rule
+// slot of that team, codeowner for a slot required by CODEOWNERS; both are
+// empty for an ordinary slot.
+type reviewerSlot struct {
+	ruleTeamID int64
+	codeowner  *codeownerMatch
+}
+
+// pickedReviewer is a candidate chosen for a PR. poolTeamID is the team the
+// reviewer was borrowed from, zero for the PR's own team. manual marks a
+// reviewer chosen by a person rather than picked.
+type pickedReviewer struct {
+	userID     string
+	poolTeamID int64
+	preferred  bool
+	manual     bool
+	reviewerSlot
+}
+
+// source names how the reviewer got their place on the PR.
+func (r pickedReviewer) source() string {
+	switch {
+	case r.manual:
+		return models.SourceManual
+	case r.ruleTeamID != 0:
+		return models.SourceLabelRule
+	case r.codeowner != nil:
+		return models.SourceCodeowners
+	case r.preferred:
+		return models.SourcePreferred
+	case r.poolTeamID != 0:
+		return models.SourceFallbackPool
+	}
+	return models.SourceTeam
+}
+
+// reviewerPools returns the pools for a PR of the team in the order they are
+// tried: the team itself, its parent, the parent's other children if the team
+// falls back to siblings, and then the remaining ancestors up to the root.
+// Archived teams are skipped but still walked through.
+func reviewerPools(ctx context.Context, q querier, teamID int64) ([]reviewerPool, error) {
+	if teamID == 0 {
+		return nil, nil
+	}
+
+	pools := []reviewerPool{{teamIDs: []int64{teamID}}}
+
+	var (
+		parentID sql.NullInt64
+		siblings bool
+	)
+	err := q.QueryRowContext(ctx, "SELECT parent_team_id, fallback_to_siblings FROM teams WHERE team_id=$1", teamID).Scan(&parentID, &siblings)
+	if err == sql.ErrNoRows {
+		return pools, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	visited := map[int64]struct{}{teamID: {}}
+	for first := true; parentID.Valid; first = false {
+		if _, ok := visited[parentID.Int64]; ok {
+			break
+		}
+		visited[parentID.Int64] = struct{}{}
+
+		var (
+			archived    bool
+			grandparent sql.NullInt64
+		)
+		err := q.QueryRowContext(ctx, "SELECT archived_at IS NOT NULL, parent_team_id FROM teams WHERE team_id=$1", parentID.Int64).Scan(&archived, &grandparent)
+		if err == sql.ErrNoRows {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !archived {
+			pools = append(pools, reviewerPool{teamIDs: []int64{parentID.Int64}, fallback: true})
+		}
+
+		if first && siblings {
+			siblingIDs, err := siblingTeams(ctx, q, parentID.Int64, teamID)
+			if err != nil {
+				return nil, err
+			}
+			if len(siblingIDs) > 0 {
+				pools = append(pools, reviewerPool{teamIDs: siblingIDs, fallback: true})
+			}
+		}
+
+		parentID = grandparent
+	}
+
+	return pools, nil
+}
+
+func siblingTeams(ctx context.Context, q querier, parentID, teamID int64) ([]int64, error) {
+	r, err := q.QueryContext(ctx, "SELECT team_id FROM teams WHERE parent_team_id=$1 AND team_id!=$2 AND archived_at IS NULL ORDER BY team_id", parentID, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	teamIDs := make([]int64, 0, 2)
+	for r.Next() {
+		var id int64
+		if err := r.Scan(&id); err != nil {
+			return nil, err
+		}
+		teamIDs = append(teamIDs, id)
+	}
+
+	return teamIDs, r.Err()
+}
+
+// pickReviewers picks up to count active reviewers for a PR of the team with the
+// selection who are not in exclude, restricted to only if it is given. Each pool is
+// drained in the order of poolCandidates before the next one is tried.
+func pickReviewers(ctx context.Context, q querier, teamID int64, sel selection, exclude []string, count int, only ...string) ([]pickedReviewer, error) {
+	pools, err := reviewerPools(ctx, q, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	exclude = append([]string{}, exclude...)
+	picked := make([]pickedReviewer, 0, count)
+	for _, pool := range pools {
+		if len(picked) == count {
+			break
+		}
+
+		candidates, err := poolCandidates(ctx, q, pool.teamIDs, sel, exclude, count-len(picked), only...)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range candidates {
+			if !pool.fallback {
+				c.poolTeamID = 0
+			}
+			picked = append(picked, c)
+			exclude = append(exclude, c.userID)
+		}
+	}
+
+	return picked, nil
+}
+
+// pickPreferredReviewers picks up to count of the preferred users, in the given
+// order, for a PR of the team. A preferred user is only taken if they are
+// active, not in exclude, of one of levels unless it is nil and a member of one
+// of the team's pools; the ones who are not are skipped and the slots are left
+// to pickReviewers.
+func pickPreferredReviewers(ctx context.Context, q querier, teamID int64, preferred, exclude, levels []string, count int) ([]pickedReviewer, error) {
+	if len(preferred) == 0 || count == 0 {
+		return nil, nil
+	}
+
+	pools, err := reviewerPools(ctx, q, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	available := make(map[string]pickedReviewer, len(preferred))
+	for _, pool := range pools {
+		candidates, err := poolCandidates(ctx, q, pool.teamIDs, selection{levels: levels}, exclude, len(preferred), preferred...)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range candidates {
+			if _, ok := available[c.userID]; ok {
+				continue
+			}
+			if !pool.fallback {
+				c.poolTeamID = 0
+			}
+			c.preferred = true
+			available[c.userID] = c
+		}
+	}
+
+	picked := make([]pickedReviewer, 0, count)
+	for _, id := range preferred {
+		if len(picked) == count {
+			break
+		}
+		if c, ok := available[id]; ok {
+			picked = append(picked, c)
+			delete(available, id)
+		}
+	}
+
+	return picked, nil
+}
+
+// candidateWindow bounds how many candidates of a pool are ranked by working
+// hours, so that a large pool does not load every member's schedule while the
+// PR rows are locked.
+const candidateWindow = 50
+
+// poolCandidates returns up to limit active members of the teams who are not in
+// exclude, blocked by the selection or declined the PR, are of the selection's
+// seniorities and are under their open review cap.
+// Members whose skills cover more of the selection's tags come first, then the
+// ones who are working now or start working soonest, then the least loaded
+// ones; mentors of the author win the remaining ties. With only given, the
+// candidates are restricted to those users. Only the candidateWindow best
+// members by coverage and load are ranked by working hours, and a candidate is
+// only returned once claimReviewer took them.
+func poolCandidates(ctx context.Context, q querier, teamIDs []int64, sel selection, exclude []string, limit int, only ...string) ([]pickedReviewer, error) {
+	exclude = append(append(append([]string{}, exclude...), sel.blocked...), sel.declined...)
+	r, err := q.QueryContext(ctx, `WITH `+reviewLoad+`,
+	coverage AS (SELECT user_id, COUNT(*) AS cnt FROM user_skills WHERE skill = ANY($4) GROUP BY user_id)
+	SELECT u.user_id, MIN(tm.team_id), COALESCE(cv.cnt, 0), COALESCE(rl.weighted, 0) FROM users u
+	INNER JOIN team_members tm ON tm.user_id=u.user_id
+	LEFT JOIN review_load rl ON rl.reviewer_id=u.user_id
+	LEFT JOIN coverage cv ON cv.user_id=u.user_id
+	WHERE `+userAvailable+` AND tm.team_id = ANY($1) AND u.user_id != ALL($2) AND ($3::text[] IS NULL OR u.user_id = ANY($3))
+	AND ($6::text[] IS NULL OR u.seniority = ANY($6)) AND `+underCapacity+`
+	GROUP BY u.user_id, rl.weighted, cv.cnt
+	ORDER BY COALESCE(cv.cnt, 0) DESC, COALESCE(rl.weighted, 0), u.user_id
+	LIMIT $5`, pq.Array(teamIDs), pq.Array(exclude), pq.Array(only), pq.Array(sel.tags), max(limit, candidateWindow), pq.Array(sel.levels))
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]rankedCandidate, 0, limit)
+	for r.Next() {
+		var c rankedCandidate
+		if err := r.Scan(&c.userID, &c.poolTeamID, &c.coverage, &c.load); err != nil {
+			_ = r.Close()
+			return nil, err
+		}
+		candidates = append(candidates, c)
+	}
+	_ = r.Close()
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	if err := rankCandidates(ctx, q, candidates, sel); err != nil {
+		return nil, err
+	}
+
+	picked := make([]pickedReviewer, 0, limit)
+	for _, c := range candidates {
+		if len(picked) == limit {
+			break
+		}
+		claimed, err := claimReviewer(ctx, q, c.userID)
+		if err != nil {
+			return nil, err
+		}
+		if claimed {
+			picked = append(picked, c.pickedReviewer)
+		}
+	}
+
+	return picked, nil
+}
+
+// rankedCandidate is a candidate with what candidates are ranked by.
+type rankedCandidate struct {
+	pickedReviewer
+	coverage int
+	wait     time.Duration
+	load     float64
+	mentor   bool
+}
+
+// rankCandidates fills in the wait until working and the mentor flag of the
+// candidates and sorts them: more tag coverage first, then less wait, then less
+// load, then mentors of the author. Ties keep their order.
+func rankCandidates(ctx context.Context, q querier, candidates []rankedCandidate, sel selection) error {
+	userIDs := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		userIDs = append(userIDs, c.userID)
+	}
+	schedules, err := reviewerSchedules(ctx, q, userIDs, time.Now())
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range candidates {
+		candidates[i].wait = untilWorking(schedules[candidates[i].userID], now)
+		candidates[i].mentor = slices.Contains(sel.mentors, candidates[i].userID)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].coverage != candidates[j].coverage {
+			return candidates[i].coverage > candidates[j].coverage
+		}
+		if candidates[i].wait != candidates[j].wait {
+			return candidates[i].wait < candidates[j].wait
+		}
+		if candidates[i].load != candidates[j].load {
+			return candidates[i].load < candidates[j].load
+		}
+		return candidates[i].mentor && !candidates[j].mentor
+	})
+	return nil
+}
+
+// pickReplacementReviewer returns the best active reviewer for the slot on a PR
+// of the team with the selection who is not in exclude, or
+// models.ErrNoCandidate. Label rule slots and slots owned by a CODEOWNERS team
+// are only refilled from that team; other slots, including ones owned by a
+// single user, become ordinary and fall back to the team's parent pools. With
+// only given, the replacement is one of those users.
+func pickReplacementReviewer(ctx context.Context, q querier, teamID int64, sel selection, slot reviewerSlot, exclude []string, only ...string) (pickedReviewer, error) {
+	slotTeamID := slot.ruleTeamID
+	if slotTeamID == 0 && slot.codeowner != nil {
+		ownerTeamID, err := codeownerTeam(ctx, q, slot.codeowner.owner)
+		if err != nil {
+			return pickedReviewer{}, err
+		}
+		slotTeamID = ownerTeamID
+	}
+
+	var (
+		picked []pickedReviewer
+		err    error
+	)
+	if slotTeamID != 0 {
+		picked, err = poolCandidates(ctx, q, []int64{slotTeamID}, sel, exclude, 1, only...)
+		for i := range picked {
+			picked[i].poolTeamID, picked[i].reviewerSlot = 0, slot
+		}
+	} else {
+		picked, err = pickReviewers(ctx, q, teamID, sel, exclude, 1, only...)
+	}
+	if err != nil {
+		return pickedReviewer{}, err
+	}
+	if len(picked) == 0 {
+		return pickedReviewer{}, models.ErrNoCandidate
+	}
+	return picked[0], nil
+}
+
+func insertReviewer(ctx context.Context, q querier, pRID string, reviewer pickedReviewer) error {
+	line, pattern, owner := reviewer.codeowner.columns()
+	_, err := q.ExecContext(ctx, `INSERT INTO pull_requests_reviewers (pr_id, reviewer_id, pool_team_id, rule_team_id, codeowners_line, codeowners_pattern, codeowners_owner)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		pRID, reviewer.userID, nullTeamID(reviewer.poolTeamID), nullTeamID(reviewer.ruleTeamID), line, pattern, owner)
+	return err
+}
+
+// replaceReviewer puts the new reviewer into the old reviewer's slot, waiting
+// for them to accept it.
+func replaceReviewer(ctx context.Context, q querier, pRID, oldReviewerID string, reviewer pickedReviewer) error {
+	line, pattern, owner := reviewer.codeowner.columns()
+	_, err := q.ExecContext(ctx, `UPDATE pull_requests_reviewers SET reviewer_id=$1, pool_team_id=$2, rule_team_id=$3,
+		codeowners_line=$4, codeowners_pattern=$5, codeowners_owner=$6, assigned_at=NOW(), ack_restarted_at=NULL, accepted_at=NULL, first_response_at=NULL,
+		sla_breached_at=NULL, sla_escalation=NULL
+		WHERE pr_id=$7 AND reviewer_id=$8`,
+		reviewer.userID, nullTeamID(reviewer.poolTeamID), nullTeamID(reviewer.ruleTeamID), line, pattern, owner, pRID, oldReviewerID)
+	return err
+}
+
+// reviewerSlotOf returns the slot the reviewer holds on the PR.
+func reviewerSlotOf(ctx context.Context, q querier, pRID, reviewerID string) (reviewerSlot, error) {
+	var (
+		slot    reviewerSlot
+		line    sql.NullInt64
+		pattern sql.NullString
+		owner   sql.NullString
+	)
+	err := q.QueryRowContext(ctx, `SELECT COALESCE(rule_team_id, 0), codeowners_line, codeowners_pattern, codeowners_owner FROM pull_requests_reviewers
+		WHERE pr_id=$1 AND reviewer_id=$2`, pRID, reviewerID).Scan(&slot.ruleTeamID, &line, &pattern, &owner)
+	if err != nil {
+		return reviewerSlot{}, err
+	}
+	if owner.Valid {
+		slot.codeowner = &codeownerMatch{line: int(line.Int64), pattern: pattern.String, owner: owner.String}
+	}
+	return slot, nil
+}
+
+func fallbackReviewersByPRID(ctx context.Context, q querier, pRID string) ([]models.FallbackReviewer, error) {
+	r, err := q.QueryContext(ctx, `SELECT prr.reviewer_id, t.name FROM pull_requests_reviewers prr
+		INNER JOIN teams t ON t.team_id=prr.pool_team_id
+		WHERE prr.pr_id=$1 ORDER BY prr.reviewer_id`, pRID)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	reviewers := make([]models.FallbackReviewer, 0)
+	for r.Next() {
+		var fr models.FallbackReviewer
+		if err := r.Scan(&fr.UserID, &fr.TeamName); err != nil {
+			return nil, err
+		}
+		reviewers = append(reviewers, fr)
+	}
+
+	return reviewers, r.Err()
+}
+
+func nullTeamID(teamID int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: teamID, Valid: teamID != 0}
+}
+
+// SetTeamParent changes the team's place in the hierarchy. parentID zero makes
+// it a root team. A parent that is the team itself or one of its descendants is
+// rejected with models.ErrTeamCycle.
+func (p *PostgresDB) SetTeamParent(ctx context.Context, teamID, parentID int64, fallbackToSiblings bool) error {
+	t, err := p.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+
+	// Concurrent re-parenting could close a cycle that neither transaction
+	// sees on its own, so hierarchy changes are serialized.
+	if _, err := t.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext('teams_hierarchy'))"); err != nil {
+		_ = t.Rollback()
+		return err
+	}
+
+	if parentID != 0 {
+		var cycle bool
+		err := t.QueryRowContext(ctx, `WITH RECURSIVE ancestors AS (
+			SELECT team_id, parent_team_id FROM teams WHERE team_id=$1
+			UNION
+			SELECT t.team_id, t.parent_team_id FROM teams t INNER JOIN ancestors a ON t.team_id=a.parent_team_id
+		) SELECT EXISTS (SELECT 1 FROM ancestors WHERE team_id=$2)`, parentID, teamID).Scan(&cycle)
+		if err != nil {
+			_ = t.Rollback()
+			return err
+		}
+		if cycle {
+			_ = t.Rollback()
+			return models.ErrTeamCycle
+		}
+	}
+
+	_, err = t.ExecContext(ctx, "UPDATE teams SET parent_team_id=$1, fallback_to_siblings=$2 WHERE team_id=$3", nullTeamID(parentID), fallbackToSiblings, teamID)
+	if err != nil {
+		_ = t.Rollback()
+		return err
+	}
+
+	return t.Commit()
+}
@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+// SetUserSkills replaces the user's skills, or returns sql.ErrNoRows if there is
+// no such user.
+func (p *PostgresDB) SetUserSkills(ctx context.Context, userID string, skills []string) error {
+	t, err := p.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+
+	var id string
+	if err := t.QueryRowContext(ctx, "SELECT user_id FROM users WHERE user_id=$1 FOR UPDATE", userID).Scan(&id); err != nil {
+		_ = t.Rollback()
+		return err
+	}
+
+	if _, err := t.ExecContext(ctx, "DELETE FROM user_skills WHERE user_id=$1", userID); err != nil {
+		_ = t.Rollback()
+		return err
+	}
+	if _, err := t.ExecContext(ctx, "INSERT INTO user_skills (user_id, skill) SELECT $1, UNNEST($2::text[]) ON CONFLICT DO NOTHING", userID, pq.Array(skills)); err != nil {
+		_ = t.Rollback()
+		return err
+	}
+
+	return t.Commit()
+}
+
+func (p *PostgresDB) GetUserSkills(ctx context.Context, userID string) ([]string, error) {
+	r, err := p.db.QueryContext(ctx, "SELECT skill FROM user_skills WHERE user_id=$1 ORDER BY skill", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	skills := make([]string, 0)
+	for r.Next() {
+		var skill string
+		if err := r.Scan(&skill); err != nil {
+			return nil, err
+		}
+		skills = append(skills, skill)
+	}
+
+	return skills, r.Err()
+}
+
+func insertPRTags(ctx context.Context, q querier, pRID string, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	_, err := q.ExecContext(ctx, "INSERT INTO pull_request_tags (pr_id, tag) SELECT $1, UNNEST($2::text[]) ON CONFLICT DO NOTHING", pRID, pq.Array(tags))
+	return err
+}
+
+func prTags(ctx context.Context, q querier, pRID string) ([]string, error) {
+	r, err := q.QueryContext(ctx, "SELECT tag FROM pull_request_tags WHERE pr_id=$1 ORDER BY tag", pRID)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	tags := make([]string, 0)
+	for r.Next() {
+		var tag string
+		if err := r.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, r.Err()
+}
+
+// skillMatches returns, for each reviewer, which of the PR's tags their skills
+// cover. It is empty for a PR without tags.
+func skillMatches(ctx context.Context, q querier, tags, reviewers []string) ([]models.SkillMatch, error) {
+	matches := make([]models.SkillMatch, 0, len(reviewers))
+	if len(tags) == 0 {
+		return matches, nil
+	}
+
+	r, err := q.QueryContext(ctx, "SELECT user_id, skill FROM user_skills WHERE user_id = ANY($1) AND skill = ANY($2) ORDER BY skill",
+		pq.Array(reviewers), pq.Array(tags))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	matched := make(map[string][]string, len(reviewers))
+	for r.Next() {
+		var userID, skill string
+		if err := r.Scan(&userID, &skill); err != nil {
+			return nil, err
+		}
+		matched[userID] = append(matched[userID], skill)
+	}
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, userID := range reviewers {
+		m := models.SkillMatch{UserID: userID, MatchedTags: matched[userID]}
+		if m.MatchedTags == nil {
+			m.MatchedTags = make([]string, 0)
+		}
+		m.Score = float64(len(m.MatchedTags)) / float64(len(tags))
+		matches = append(matches, m)
+	}
+
+	return matches, nil
+}
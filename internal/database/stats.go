@@ -0,0 +1,204 @@
+package database
+
+import (
+	"context"
+
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+// statsArgs are the parameters of the stats queries: $1 and $2 the window, $3
+// the team, $4 the user and $5 the grouping.
+func statsArgs(f models.StatsFilter) []any {
+	return []any{f.From, f.To, nullTeamID(f.TeamID), nullString(f.UserID), nullString(string(f.GroupBy))}
+}
+
+// statsWindow keeps the rows whose time expr falls into the window of
+// statsArgs.
+func statsWindow(expr string) string {
+	return `($1::timestamptz IS NULL OR ` + expr + ` >= $1) AND ($2::timestamptz IS NULL OR ` + expr + ` < $2)`
+}
+
+// statsBucket is the start of the bucket of time expr, -infinity without
+// grouping so that buckets can be joined on.
+func statsBucket(expr string) string {
+	return `COALESCE(date_trunc($5::text, ` + expr + `, 'UTC'), '-infinity')`
+}
+
+// statsTeamPRs and statsUserPRs keep the PRs pr of the team of statsArgs and
+// the PRs the user authored or reviews.
+const (
+	statsTeamPRs = `($3::int IS NULL OR pr.team_id=$3)`
+	statsUserPRs = `($4::text IS NULL OR pr.author_id=$4
+		OR EXISTS (SELECT 1 FROM pull_requests_reviewers sprr WHERE sprr.pr_id=pr.pr_id AND sprr.reviewer_id=$4))`
+)
+
+// GetCountPRStatsByUser counts the PRs of every user matching the filter, the
+// user with it, and the members of the team with it. WeightedLoad is the
+// current load whatever the window.
+func (p *PostgresDB) GetCountPRStatsByUser(ctx context.Context, f models.StatsFilter) ([]models.UserStats, error) {
+	r, err := p.db.QueryContext(ctx,
+		`WITH `+reviewLoad+`,
+		a AS (
+			SELECT pr.author_id AS user_id, `+statsBucket("pr.created_at")+` AS bucket, COUNT(*) AS cnt_author
+			FROM pull_requests pr
+			WHERE `+statsWindow("pr.created_at")+` AND `+statsTeamPRs+`
+			GROUP BY 1, 2
+		),
+		r AS (
+			SELECT prr.reviewer_id AS user_id, `+statsBucket("prr.assigned_at")+` AS bucket, COUNT(*) AS cnt_reviewer
+			FROM pull_requests_reviewers prr
+			INNER JOIN pull_requests pr ON pr.pr_id = prr.pr_id
+			WHERE `+statsWindow("prr.assigned_at")+` AND `+statsTeamPRs+`
+			GROUP BY 1, 2
+		),
+		tc AS (
+			SELECT prr.reviewer_id AS user_id, `+statsBucket("prr.assigned_at")+` AS bucket, COUNT(DISTINCT prr.pr_id) AS cnt_tagged,
+				COUNT(*) AS cnt_tags, COUNT(us.skill) AS cnt_covered
+			FROM pull_requests_reviewers prr
+			INNER JOIN pull_requests pr ON pr.pr_id = prr.pr_id
+			INNER JOIN pull_request_tags pt ON pt.pr_id = prr.pr_id
+			LEFT JOIN user_skills us ON us.user_id = prr.reviewer_id AND us.skill = pt.tag
+			WHERE `+statsWindow("prr.assigned_at")+` AND `+statsTeamPRs+`
+			GROUP BY 1, 2
+		),
+		d AS (
+			SELECT rd.reviewer_id AS user_id, `+statsBucket("rd.declined_at")+` AS bucket,
+				COUNT(*) FILTER (WHERE NOT rd.expired) AS cnt_declined, COUNT(*) FILTER (WHERE rd.expired) AS cnt_expired
+			FROM review_declines rd
+			INNER JOIN pull_requests pr ON pr.pr_id = rd.pr_id
+			WHERE `+statsWindow("rd.declined_at")+` AND `+statsTeamPRs+`
+			GROUP BY 1, 2
+		),
+		b AS (
+			SELECT user_id, bucket FROM a
+			UNION SELECT user_id, bucket FROM r
+			UNION SELECT user_id, bucket FROM d
+			UNION SELECT user_id, '-infinity' FROM users WHERE $5::text IS NULL
+		)
+		SELECT u.user_id, NULLIF(b.bucket, '-infinity'), COALESCE(a.cnt_author, 0) AS cnt_author,
+			COALESCE(r.cnt_reviewer, 0), COALESCE(tc.cnt_tagged, 0), COALESCE(tc.cnt_tags, 0), COALESCE(tc.cnt_covered, 0),
+			COALESCE(d.cnt_declined, 0), COALESCE(d.cnt_expired, 0), COALESCE(rl.weighted, 0)
+		FROM b
+		INNER JOIN users u ON u.user_id = b.user_id
+		LEFT JOIN a ON a.user_id = b.user_id AND a.bucket = b.bucket
+		LEFT JOIN r ON r.user_id = b.user_id AND r.bucket = b.bucket
+		LEFT JOIN tc ON tc.user_id = b.user_id AND tc.bucket = b.bucket
+		LEFT JOIN d ON d.user_id = b.user_id AND d.bucket = b.bucket
+		LEFT JOIN review_load rl ON u.user_id = rl.reviewer_id
+		LEFT JOIN team_members tm ON tm.user_id = u.user_id AND tm.is_primary
+		LEFT JOIN teams t ON t.team_id = tm.team_id
+		WHERE ($6 OR t.archived_at IS NULL)
+		AND ($3::int IS NULL OR EXISTS (SELECT 1 FROM team_members stm WHERE stm.user_id = u.user_id AND stm.team_id = $3))
+		AND ($4::text IS NULL OR u.user_id = $4)
+		ORDER BY b.bucket, cnt_author DESC;
+	`, append(statsArgs(f), f.IncludeArchived)...)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	stats := make([]models.UserStats, 0, 10)
+	for r.Next() {
+		var (
+			s                 models.UserStats
+			tags, coveredTags int64
+		)
+		if err := r.Scan(&s.UserID, &s.Bucket, &s.PRAuthorCount, &s.PRReviewerCount, &s.TaggedPRReviewerCount, &tags, &coveredTags,
+			&s.DeclinedCount, &s.ExpiredCount, &s.WeightedLoad); err != nil {
+			return nil, err
+		}
+		if tags > 0 {
+			s.TagCoverage = float64(coveredTags) / float64(tags)
+		}
+		stats = append(stats, s)
+	}
+
+	return stats, r.Err()
+}
+
+// GetCountPRStatsByTeam counts the PRs of every team matching the filter, the
+// team with it. UsersCount is the current number of members.
+func (p *PostgresDB) GetCountPRStatsByTeam(ctx context.Context, f models.StatsFilter) ([]models.TeamStats, error) {
+	r, err := p.db.QueryContext(ctx,
+		`WITH c AS (
+			SELECT pr.team_id, `+statsBucket("pr.created_at")+` AS bucket,
+				COUNT(*) AS total_pr, COUNT(*) FILTER (WHERE pr.pr_status = 'OPEN') AS open_pr
+			FROM pull_requests pr
+			WHERE `+statsWindow("pr.created_at")+` AND `+statsTeamPRs+` AND `+statsUserPRs+`
+			GROUP BY 1, 2
+		),
+		m AS (
+			SELECT pr.team_id, `+statsBucket("pr.merged_at")+` AS bucket, COUNT(*) AS merged_pr,
+				AVG(EXTRACT(EPOCH FROM pr.merged_at - pr.created_at) / 60) FILTER (WHERE pr.merged_at >= pr.created_at) AS avg_merge_minutes
+			FROM pull_requests pr
+			WHERE pr.pr_status = 'MERGED' AND ($5::text IS NULL OR pr.merged_at IS NOT NULL)
+			AND `+statsWindow("pr.merged_at")+` AND `+statsTeamPRs+` AND `+statsUserPRs+`
+			GROUP BY 1, 2
+		),
+		b AS (
+			SELECT team_id, bucket FROM c
+			UNION SELECT team_id, bucket FROM m
+			UNION SELECT team_id, '-infinity' FROM teams WHERE $5::text IS NULL
+		)
+		SELECT
+			t.name,
+			NULLIF(b.bucket, '-infinity'),
+			(SELECT COUNT(*) FROM team_members tm WHERE tm.team_id = t.team_id) AS users_count,
+			COALESCE(c.total_pr, 0),
+			COALESCE(c.open_pr, 0),
+			COALESCE(m.merged_pr, 0),
+			COALESCE(m.avg_merge_minutes, 0)
+		FROM b
+		INNER JOIN teams t ON t.team_id = b.team_id
+		LEFT JOIN c ON c.team_id = b.team_id AND c.bucket = b.bucket
+		LEFT JOIN m ON m.team_id = b.team_id AND m.bucket = b.bucket
+		WHERE ($6 OR t.archived_at IS NULL) AND ($3::int IS NULL OR t.team_id = $3)
+		ORDER BY b.bucket, t.name;
+		`, append(statsArgs(f), f.IncludeArchived)...)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	stats := make([]models.TeamStats, 0, 10)
+	for r.Next() {
+		var s models.TeamStats
+		if err := r.Scan(&s.TeamName, &s.Bucket, &s.UsersCount, &s.AllPRCount, &s.OpenPRCount, &s.MergedPRCount, &s.AvgTimeToMergeMinutes); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+
+	return stats, r.Err()
+}
+
+// GetCountReviewerStatsByPR counts the reviewers of the PRs matching the
+// filter that were assigned in the window.
+func (p *PostgresDB) GetCountReviewerStatsByPR(ctx context.Context, f models.StatsFilter) ([]models.PRReviewerStats, error) {
+	r, err := p.db.QueryContext(ctx,
+		`SELECT
+			prr.pr_id,
+			NULLIF(`+statsBucket("prr.assigned_at")+`, '-infinity') AS bucket,
+			COUNT(*) AS reviewers_count
+		FROM pull_requests_reviewers prr
+		INNER JOIN pull_requests pr ON pr.pr_id = prr.pr_id
+		WHERE `+statsWindow("prr.assigned_at")+` AND `+statsTeamPRs+` AND `+statsUserPRs+`
+		GROUP BY 1, 2
+		ORDER BY 2, 1;
+		`, statsArgs(f)...)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	stats := make([]models.PRReviewerStats, 0, 10)
+	for r.Next() {
+		var s models.PRReviewerStats
+		if err := r.Scan(&s.PRID, &s.Bucket, &s.Count); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+
+	return stats, r.Err()
+}
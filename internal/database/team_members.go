@@ -0,0 +1,376 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+func joinTeamHistory(ctx context.Context, q querier, userID string, teamID int64) error {
+	_, err := q.ExecContext(ctx, "INSERT INTO user_team_history (user_id, team_id, team_name) SELECT $1, team_id, name FROM teams WHERE team_id=$2", userID, teamID)
+	return err
+}
+
+func leaveTeamHistory(ctx context.Context, q querier, userID string, teamID int64) error {
+	_, err := q.ExecContext(ctx, "UPDATE user_team_history SET left_at=NOW() WHERE user_id=$1 AND team_id=$2 AND left_at IS NULL", userID, teamID)
+	return err
+}
+
+// joinTeam adds the membership and records it in the history. The membership
+// becomes primary when asked to, or when the user has no primary team yet.
+func joinTeam(ctx context.Context, q querier, userID string, teamID int64, role models.MemberRole, primary bool) error {
+	if role == "" {
+		role = models.MemberRoleMember
+	}
+	if primary {
+		if _, err := q.ExecContext(ctx, "UPDATE team_members SET is_primary=FALSE WHERE user_id=$1 AND is_primary", userID); err != nil {
+			return err
+		}
+	}
+
+	_, err := q.ExecContext(ctx, `INSERT INTO team_members (user_id, team_id, role, is_primary)
+		VALUES ($1, $2, $3, $4 OR NOT EXISTS (SELECT 1 FROM team_members WHERE user_id=$1 AND is_primary))`, userID, teamID, role, primary)
+	if err != nil {
+		return err
+	}
+	return joinTeamHistory(ctx, q, userID, teamID)
+}
+
+// leaveTeam removes the membership and records it in the history. It returns
+// the removed membership, or sql.ErrNoRows if the user was not in the team.
+func leaveTeam(ctx context.Context, q querier, userID string, teamID int64) (models.Membership, error) {
+	r := q.QueryRowContext(ctx, "DELETE FROM team_members WHERE user_id=$1 AND team_id=$2 RETURNING team_id, role, is_primary", userID, teamID)
+
+	var m models.Membership
+	if err := r.Scan(&m.TeamID, &m.Role, &m.IsPrimary); err != nil {
+		return models.Membership{}, err
+	}
+	return m, leaveTeamHistory(ctx, q, userID, teamID)
+}
+
+// promotePrimaryTeam makes the user's oldest remaining membership primary if
+// the user has none.
+func promotePrimaryTeam(ctx context.Context, q querier, userID string) error {
+	_, err := q.ExecContext(ctx, `UPDATE team_members SET is_primary=TRUE
+		WHERE user_id=$1 AND team_id=(SELECT team_id FROM team_members WHERE user_id=$1 ORDER BY joined_at, team_id LIMIT 1)
+		AND NOT EXISTS (SELECT 1 FROM team_members WHERE user_id=$1 AND is_primary)`, userID)
+	return err
+}
+
+func userMemberships(ctx context.Context, q querier, userID string) ([]models.Membership, error) {
+	r, err := q.QueryContext(ctx, `SELECT tm.team_id, t.name, tm.role, tm.is_primary FROM team_members tm
+		INNER JOIN teams t ON t.team_id=tm.team_id
+		WHERE tm.user_id=$1 ORDER BY tm.is_primary DESC, tm.joined_at, t.name`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	memberships := make([]models.Membership, 0, 1)
+	for r.Next() {
+		var m models.Membership
+		if err := r.Scan(&m.TeamID, &m.TeamName, &m.Role, &m.IsPrimary); err != nil {
+			return nil, err
+		}
+		memberships = append(memberships, m)
+	}
+
+	return memberships, r.Err()
+}
+
+// AddMembersToTeamInTransaction adds the users to the team, creating the ones
+// that do not exist yet. Existing users keep their name and activity and get an
+// additional membership. If any user is already in the team the whole call fails
+// with models.ErrUserExists.
+func (p *PostgresDB) AddMembersToTeamInTransaction(ctx context.Context, teamID int64, members []models.Member) error {
+	t, err := p.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+
+	for _, m := range members {
+		_, err := t.ExecContext(ctx, "INSERT INTO users (user_id, name, is_active) VALUES ($1, $2, $3) ON CONFLICT (user_id) DO NOTHING", m.ID, m.Name, m.IsActive)
+		if err != nil {
+			_ = t.Rollback()
+			return err
+		}
+
+		var alreadyMember bool
+		if err := t.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM team_members WHERE user_id=$1 AND team_id=$2)", m.ID, teamID).Scan(&alreadyMember); err != nil {
+			_ = t.Rollback()
+			return err
+		}
+		if alreadyMember {
+			_ = t.Rollback()
+			return models.ErrUserExists
+		}
+
+		if err := joinTeam(ctx, t, m.ID, teamID, m.Role, m.IsPrimary); err != nil {
+			_ = t.Rollback()
+			return err
+		}
+	}
+
+	return t.Commit()
+}
+
+// UpdateTeamMember changes the member's role in the team and, if makePrimary is
+// set, makes the team the user's primary one.
+func (p *PostgresDB) UpdateTeamMember(ctx context.Context, teamID int64, userID string, role models.MemberRole, makePrimary bool) error {
+	t, err := p.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+
+	if _, err := lockUser(ctx, t, userID); err != nil {
+		_ = t.Rollback()
+		return err
+	}
+
+	res, err := t.ExecContext(ctx, "UPDATE team_members SET role=COALESCE(NULLIF($1, ''), role) WHERE user_id=$2 AND team_id=$3", role, userID, teamID)
+	if err != nil {
+		_ = t.Rollback()
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		_ = t.Rollback()
+		if err != nil {
+			return err
+		}
+		return models.ErrNotTeamMember
+	}
+
+	if makePrimary {
+		if _, err := t.ExecContext(ctx, "UPDATE team_members SET is_primary=FALSE WHERE user_id=$1 AND is_primary AND team_id!=$2", userID, teamID); err != nil {
+			_ = t.Rollback()
+			return err
+		}
+		if _, err := t.ExecContext(ctx, "UPDATE team_members SET is_primary=TRUE WHERE user_id=$1 AND team_id=$2", userID, teamID); err != nil {
+			_ = t.Rollback()
+			return err
+		}
+	}
+
+	return t.Commit()
+}
+
+// RemoveUserFromTeamInTransaction removes the user from the team and hands their
+// open reviews on the team's pull requests over to the remaining members. If it
+// was the user's primary team, the oldest remaining membership becomes primary.
+func (p *PostgresDB) RemoveUserFromTeamInTransaction(ctx context.Context, userID string, teamID int64) ([]models.ReviewHandover, error) {
+	t, err := p.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := lockUser(ctx, t, userID); err != nil {
+		_ = t.Rollback()
+		return nil, err
+	}
+
+	if _, err := leaveTeam(ctx, t, userID, teamID); err != nil {
+		_ = t.Rollback()
+		if err == sql.ErrNoRows {
+			return nil, models.ErrNotTeamMember
+		}
+		return nil, err
+	}
+
+	if err := promotePrimaryTeam(ctx, t, userID); err != nil {
+		_ = t.Rollback()
+		return nil, err
+	}
+
+	handovers, err := handOverOpenReviews(ctx, t, userID, teamID)
+	if err != nil {
+		_ = t.Rollback()
+		return nil, err
+	}
+
+	return handovers, t.Commit()
+}
+
+// MoveUserToTeamInTransaction replaces the user's membership in fromTeamID (the
+// primary team when zero) with a membership in toTeamID that keeps the role and
+// the primary flag. Open reviews on the old team's pull requests are handed over
+// to its remaining members. It returns the team the user was moved from, zero if
+// the user had no team and was just added to the new one.
+func (p *PostgresDB) MoveUserToTeamInTransaction(ctx context.Context, userID string, fromTeamID, toTeamID int64) (int64, []models.ReviewHandover, error) {
+	t, err := p.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	primaryTeamID, err := lockUser(ctx, t, userID)
+	if err != nil {
+		_ = t.Rollback()
+		return 0, nil, err
+	}
+	if fromTeamID == 0 {
+		fromTeamID = primaryTeamID
+	}
+
+	var alreadyMember bool
+	if err := t.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM team_members WHERE user_id=$1 AND team_id=$2)", userID, toTeamID).Scan(&alreadyMember); err != nil {
+		_ = t.Rollback()
+		return 0, nil, err
+	}
+	if alreadyMember {
+		_ = t.Rollback()
+		return 0, nil, models.ErrAlreadyInTeam
+	}
+
+	old := models.Membership{Role: models.MemberRoleMember, IsPrimary: true}
+	handovers := make([]models.ReviewHandover, 0)
+	if fromTeamID != 0 {
+		old, err = leaveTeam(ctx, t, userID, fromTeamID)
+		if err == sql.ErrNoRows {
+			err = models.ErrNotTeamMember
+		}
+		if err != nil {
+			_ = t.Rollback()
+			return 0, nil, err
+		}
+	}
+
+	if err := joinTeam(ctx, t, userID, toTeamID, old.Role, old.IsPrimary); err != nil {
+		_ = t.Rollback()
+		return 0, nil, err
+	}
+
+	if fromTeamID != 0 {
+		handovers, err = handOverOpenReviews(ctx, t, userID, fromTeamID)
+		if err != nil {
+			_ = t.Rollback()
+			return 0, nil, err
+		}
+	}
+
+	return fromTeamID, handovers, t.Commit()
+}
+
+// lockUser takes a row lock on the user, serializing membership changes and PR
+// creation by the user. It returns the user's primary team.
+func lockUser(ctx context.Context, t *sql.Tx, userID string) (int64, error) {
+	var primaryTeamID int64
+	err := t.QueryRowContext(ctx, `SELECT COALESCE((SELECT team_id FROM team_members WHERE user_id=u.user_id AND is_primary), 0)
+		FROM users u WHERE u.user_id=$1 FOR UPDATE`, userID).Scan(&primaryTeamID)
+	return primaryTeamID, err
+}
+
+// handOverOpenReviews replaces the user on every review they hold for the team
+// (on the team's own PRs, as a fallback reviewer borrowed from it, or in a
+// label rule slot of it) with a replacement picked the way pickReplacementReviewer
+// refills the slot. Reviews nobody can take are released. PR rows are locked in
+// id order, the same lock the reassign flow takes.
+func handOverOpenReviews(ctx context.Context, t *sql.Tx, userID string, teamID int64) ([]models.ReviewHandover, error) {
+	reviews, err := lockOpenReviews(ctx, t, userID, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	handovers := make([]models.ReviewHandover, 0, len(reviews))
+	for _, rv := range reviews {
+		reviewers, err := reviewersByPRID(ctx, t, rv.prID)
+		if err != nil {
+			return nil, err
+		}
+
+		slot, err := reviewerSlotOf(ctx, t, rv.prID, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		sel, err := prSelection(ctx, t, rv.prID, rv.authorID)
+		if err != nil {
+			return nil, err
+		}
+
+		handover := models.ReviewHandover{PRID: rv.prID, OldReviewerID: userID}
+		policy, err := teamReviewPolicy(ctx, t, rv.teamID)
+		if err != nil {
+			return nil, err
+		}
+
+		remaining := slices.DeleteFunc(slices.Clone(reviewers), func(id string) bool { return id == userID })
+		newReviewer, err := pickPolicyReplacement(ctx, t, rv.teamID, policy, sel, slot, append([]string{rv.authorID}, reviewers...), remaining)
+		if isPolicyError(err) {
+			err = models.ErrNoCandidate
+		}
+		pr := models.PullRequest{ID: rv.prID, AuthorID: rv.authorID, TeamID: rv.teamID}
+		if err == nil || err == models.ErrNoCandidate {
+			if err := recordReplacement(ctx, t, models.AssignmentHandover, pr, sel, policy, reviewers, userID, newReviewer); err != nil {
+				return nil, err
+			}
+		}
+		switch err {
+		case nil:
+			err = replaceReviewer(ctx, t, rv.prID, userID, newReviewer)
+			handover.NewReviewerID = newReviewer.userID
+		case models.ErrNoCandidate:
+			_, err = t.ExecContext(ctx, "DELETE FROM pull_requests_reviewers WHERE pr_id=$1 AND reviewer_id=$2", rv.prID, userID)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		handovers = append(handovers, handover)
+	}
+
+	return handovers, nil
+}
+
+// openReview is an open PR the user reviews. teamID is the PR's team.
+type openReview struct {
+	prID, authorID string
+	teamID         int64
+}
+
+// lockOpenReviews locks, in id order, the open PRs the user reviews for the
+// team, or for any team if teamID is zero.
+func lockOpenReviews(ctx context.Context, t *sql.Tx, userID string, teamID int64) ([]openReview, error) {
+	r, err := t.QueryContext(ctx, `SELECT pr.pr_id, pr.author_id, COALESCE(pr.team_id, 0) FROM pull_requests pr
+		INNER JOIN pull_requests_reviewers prr ON prr.pr_id=pr.pr_id
+		WHERE prr.reviewer_id=$1 AND pr.pr_status=$2
+		AND ($3::bigint=0 OR COALESCE(prr.rule_team_id, prr.pool_team_id, pr.team_id)=$3)
+		ORDER BY pr.pr_id
+		FOR UPDATE OF pr`, userID, models.PRStatusOpen, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	reviews := make([]openReview, 0, 2)
+	for r.Next() {
+		var rv openReview
+		if err := r.Scan(&rv.prID, &rv.authorID, &rv.teamID); err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, rv)
+	}
+
+	return reviews, r.Err()
+}
+
+func (p *PostgresDB) GetUserTeamHistory(ctx context.Context, userID string) ([]models.TeamMembership, error) {
+	r, err := p.db.QueryContext(ctx, `SELECT COALESCE(t.name, h.team_name), h.joined_at, h.left_at FROM user_team_history h
+		LEFT JOIN teams t ON t.team_id=h.team_id
+		WHERE h.user_id=$1 ORDER BY h.joined_at, h.id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	history := make([]models.TeamMembership, 0, 1)
+	for r.Next() {
+		var m models.TeamMembership
+		if err := r.Scan(&m.TeamName, &m.JoinedAt, &m.LeftAt); err != nil {
+			return nil, err
+		}
+		history = append(history, m)
+	}
+
+	return history, r.Err()
+}
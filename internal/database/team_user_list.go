@@ -0,0 +1,140 @@
+package database
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+// teamSortKeys and userSortKeys are the expressions teams and users are listed
+// by and the SQL types their cursor values are cast to.
+var (
+	teamSortKeys = map[models.TeamSort]struct{ expr, typ string }{
+		models.TeamSortName:    {"s.name", "text"},
+		models.TeamSortMembers: {"s.members_count", "bigint"},
+	}
+	userSortKeys = map[models.UserSort]struct{ expr, typ string }{
+		models.UserSortID:   {"u.user_id", "text"},
+		models.UserSortName: {"u.name", "text"},
+	}
+)
+
+// ListTeams returns up to f.Limit teams matching the filter in its order, with
+// their member counts.
+func (p *PostgresDB) ListTeams(ctx context.Context, f models.TeamListFilter) ([]models.TeamSummary, error) {
+	key := teamSortKeys[f.Sort]
+	cmp, dir := ">", "ASC"
+	if f.Desc {
+		cmp, dir = "<", "DESC"
+	}
+
+	args := []any{nullString(likeEscaper.Replace(f.NamePrefix)), f.IncludeArchived, nil, nil, f.Limit}
+	if f.After != nil {
+		args[2], args[3] = f.After.Value, f.After.ID
+	}
+
+	r, err := p.db.QueryContext(ctx, `SELECT s.team_id, s.name, s.is_archived, s.parent_name, s.members_count, s.active_count FROM (
+			SELECT t.team_id, COALESCE(t.name, '') AS name, t.archived_at IS NOT NULL AS is_archived, COALESCE(pt.name, '') AS parent_name,
+			COUNT(tm.user_id) AS members_count, COUNT(tm.user_id) FILTER (WHERE u.is_active) AS active_count
+			FROM teams t
+			LEFT JOIN teams pt ON pt.team_id=t.parent_team_id
+			LEFT JOIN team_members tm ON tm.team_id=t.team_id
+			LEFT JOIN users u ON u.user_id=tm.user_id
+			WHERE ($1::text IS NULL OR lower(t.name) LIKE lower($1) || '%')
+			AND ($2 OR t.archived_at IS NULL)
+			GROUP BY t.team_id, t.name, t.archived_at, pt.name
+		) s
+		WHERE ($3::text IS NULL OR (`+key.expr+`, s.team_id) `+cmp+` ($3::`+key.typ+`, $4::int))
+		ORDER BY `+key.expr+` `+dir+`, s.team_id `+dir+`
+		LIMIT $5`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	teams := make([]models.TeamSummary, 0, f.Limit)
+	for r.Next() {
+		var t models.TeamSummary
+		if err := r.Scan(&t.TeamID, &t.TeamName, &t.IsArchived, &t.ParentTeamName, &t.MembersCount, &t.ActiveCount); err != nil {
+			return nil, err
+		}
+		teams = append(teams, t)
+	}
+
+	return teams, r.Err()
+}
+
+// ListUsers returns up to f.Limit users matching the filter in its order, with
+// all their teams.
+func (p *PostgresDB) ListUsers(ctx context.Context, f models.UserListFilter) ([]models.UserSummary, error) {
+	key := userSortKeys[f.Sort]
+	cmp, dir := ">", "ASC"
+	if f.Desc {
+		cmp, dir = "<", "DESC"
+	}
+
+	args := []any{nullTeamID(f.TeamID), nullString(string(f.Role)), f.IsActive, nullString(likeEscaper.Replace(f.NamePrefix)), nil, nil, f.Limit,
+		nullString(string(f.Seniority))}
+	if f.After != nil {
+		args[4], args[5] = f.After.Value, f.After.ID
+	}
+
+	r, err := p.db.QueryContext(ctx, `SELECT u.user_id, u.name, u.is_active, u.seniority FROM users u
+		WHERE ($1::int IS NULL AND $2::text IS NULL OR EXISTS (SELECT 1 FROM team_members tm WHERE tm.user_id=u.user_id
+			AND ($1::int IS NULL OR tm.team_id=$1) AND ($2::text IS NULL OR tm.role=$2)))
+		AND ($3::bool IS NULL OR u.is_active=$3)
+		AND ($4::text IS NULL OR lower(u.name) LIKE lower($4) || '%')
+		AND ($8::text IS NULL OR u.seniority=$8)
+		AND ($5::text IS NULL OR (`+key.expr+`, u.user_id) `+cmp+` ($5::`+key.typ+`, $6::text))
+		ORDER BY `+key.expr+` `+dir+`, u.user_id `+dir+`
+		LIMIT $7`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]models.UserSummary, 0, f.Limit)
+	ids := make([]string, 0, f.Limit)
+	for r.Next() {
+		u := models.UserSummary{Teams: []models.Membership{}}
+		if err := r.Scan(&u.UserID, &u.Username, &u.IsActive, &u.Seniority); err != nil {
+			_ = r.Close()
+			return nil, err
+		}
+		users = append(users, u)
+		ids = append(ids, u.UserID)
+	}
+	_ = r.Close()
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return users, nil
+	}
+
+	r, err = p.db.QueryContext(ctx, `SELECT tm.user_id, tm.team_id, t.name, tm.role, tm.is_primary FROM team_members tm
+		INNER JOIN teams t ON t.team_id=tm.team_id
+		WHERE tm.user_id = ANY($1) ORDER BY tm.is_primary DESC, tm.joined_at, t.name`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	byID := make(map[string]int, len(users))
+	for i, u := range users {
+		byID[u.UserID] = i
+	}
+	for r.Next() {
+		var (
+			userID string
+			m      models.Membership
+		)
+		if err := r.Scan(&userID, &m.TeamID, &m.TeamName, &m.Role, &m.IsPrimary); err != nil {
+			return nil, err
+		}
+		i := byID[userID]
+		users[i].Teams = append(users[i].Teams, m)
+	}
+
+	return users, r.Err()
+}
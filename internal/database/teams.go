@@ -0,0 +1,195 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+// isTeamNameTaken reports whether err comes from the unique index on team
+// names, which settles concurrent creates and renames to the same name.
+func isTeamNameTaken(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "teams_name_key"
+}
+
+// RenameTeam gives the team a new name, or returns models.ErrTeamExists if
+// another team has it and sql.ErrNoRows if the team is gone.
+func (p *PostgresDB) RenameTeam(ctx context.Context, teamID int64, newName string) error {
+	res, err := p.db.ExecContext(ctx, "UPDATE teams SET name=$1 WHERE team_id=$2", newName, teamID)
+	if isTeamNameTaken(err) {
+		return models.ErrTeamExists
+	}
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ArchiveTeamInTransaction marks the team as archived and deactivates the members
+// that do not belong to any other active team. Pull requests and review
+// assignments are left untouched. Archiving an archived team keeps the original
+// archive time.
+func (p *PostgresDB) ArchiveTeamInTransaction(ctx context.Context, teamID int64) ([]models.User, error) {
+	t, err := p.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := t.ExecContext(ctx, "UPDATE teams SET archived_at=COALESCE(archived_at, NOW()) WHERE team_id=$1", teamID); err != nil {
+		_ = t.Rollback()
+		return nil, err
+	}
+
+	r, err := t.QueryContext(ctx, `UPDATE users u SET is_active=FALSE
+		WHERE u.user_id IN (SELECT user_id FROM team_members WHERE team_id=$1)
+		AND NOT EXISTS (SELECT 1 FROM team_members tm INNER JOIN teams t ON t.team_id=tm.team_id
+			WHERE tm.user_id=u.user_id AND tm.team_id!=$1 AND t.archived_at IS NULL)
+		RETURNING u.user_id, u.name, u.is_active`, teamID)
+	if err != nil {
+		_ = t.Rollback()
+		return nil, err
+	}
+
+	users := make([]models.User, 0, 2)
+	for r.Next() {
+		var u models.User
+		if err := r.Scan(&u.ID, &u.Name, &u.IsActive); err != nil {
+			_ = r.Close()
+			_ = t.Rollback()
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	_ = r.Close()
+	if err := r.Err(); err != nil {
+		_ = t.Rollback()
+		return nil, err
+	}
+
+	return users, t.Commit()
+}
+
+// DeleteTeamInTransaction deletes the team. A team with members is only deleted
+// when force is set: the members leave it first and the open reviews they hold on
+// the team's pull requests are released. Members whose primary team it was get
+// their oldest remaining membership as primary. Membership history is kept.
+func (p *PostgresDB) DeleteTeamInTransaction(ctx context.Context, teamID int64, force bool) ([]models.ReviewHandover, error) {
+	t, err := p.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var lockedID int64
+	if err := t.QueryRowContext(ctx, "SELECT team_id FROM teams WHERE team_id=$1 FOR UPDATE", teamID).Scan(&lockedID); err != nil {
+		_ = t.Rollback()
+		return nil, err
+	}
+
+	members, err := lockTeamMembers(ctx, t, teamID)
+	if err != nil {
+		_ = t.Rollback()
+		return nil, err
+	}
+	if len(members) > 0 && !force {
+		_ = t.Rollback()
+		return nil, models.ErrTeamNotEmpty
+	}
+
+	handovers := make([]models.ReviewHandover, 0, len(members))
+	for _, userID := range members {
+		released, err := releaseOpenReviews(ctx, t, userID, teamID)
+		if err != nil {
+			_ = t.Rollback()
+			return nil, err
+		}
+		handovers = append(handovers, released...)
+	}
+
+	for _, userID := range members {
+		if _, err := leaveTeam(ctx, t, userID, teamID); err != nil {
+			_ = t.Rollback()
+			return nil, err
+		}
+		if err := promotePrimaryTeam(ctx, t, userID); err != nil {
+			_ = t.Rollback()
+			return nil, err
+		}
+	}
+
+	if _, err := t.ExecContext(ctx, "DELETE FROM teams WHERE team_id=$1", teamID); err != nil {
+		_ = t.Rollback()
+		return nil, err
+	}
+
+	return handovers, t.Commit()
+}
+
+func lockTeamMembers(ctx context.Context, t *sql.Tx, teamID int64) ([]string, error) {
+	r, err := t.QueryContext(ctx, `SELECT u.user_id FROM users u
+		INNER JOIN team_members tm ON tm.user_id=u.user_id
+		WHERE tm.team_id=$1 ORDER BY u.user_id FOR UPDATE OF u`, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	members := make([]string, 0, 2)
+	for r.Next() {
+		var userID string
+		if err := r.Scan(&userID); err != nil {
+			return nil, err
+		}
+		members = append(members, userID)
+	}
+
+	return members, r.Err()
+}
+
+// releaseOpenReviews removes the user from every open review they hold for the
+// team, the same reviews handOverOpenReviews hands over, without picking a
+// replacement.
+func releaseOpenReviews(ctx context.Context, t *sql.Tx, userID string, teamID int64) ([]models.ReviewHandover, error) {
+	r, err := t.QueryContext(ctx, `SELECT pr.pr_id FROM pull_requests pr
+		INNER JOIN pull_requests_reviewers prr ON prr.pr_id=pr.pr_id
+		WHERE prr.reviewer_id=$1 AND pr.pr_status=$2
+		AND COALESCE(prr.rule_team_id, prr.pool_team_id, pr.team_id)=$3
+		ORDER BY pr.pr_id
+		FOR UPDATE OF pr`, userID, models.PRStatusOpen, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	released := make([]models.ReviewHandover, 0, 2)
+	for r.Next() {
+		h := models.ReviewHandover{OldReviewerID: userID}
+		if err := r.Scan(&h.PRID); err != nil {
+			_ = r.Close()
+			return nil, err
+		}
+		released = append(released, h)
+	}
+	_ = r.Close()
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, h := range released {
+		if _, err := t.ExecContext(ctx, "DELETE FROM pull_requests_reviewers WHERE pr_id=$1 AND reviewer_id=$2", h.PRID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	return released, nil
+}
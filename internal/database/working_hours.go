@@ -0,0 +1,214 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/narroworb/pr-review-service/internal/models"
+	"github.com/narroworb/pr-review-service/internal/workhours"
+)
+
+// SetWorkingHours sets the user's working hours, nil removes them. It returns
+// sql.ErrNoRows if there is no such user.
+func (p *PostgresDB) SetWorkingHours(ctx context.Context, userID string, wh *models.WorkingHours) error {
+	var (
+		timeZone   sql.NullString
+		days       []int64
+		start, end sql.NullInt64
+	)
+	if wh != nil {
+		timeZone = sql.NullString{String: wh.TimeZone, Valid: true}
+		for _, d := range wh.WorkDays {
+			days = append(days, int64(d))
+		}
+		start = sql.NullInt64{Int64: int64(wh.StartMinute), Valid: true}
+		end = sql.NullInt64{Int64: int64(wh.EndMinute), Valid: true}
+	}
+
+	res, err := p.db.ExecContext(ctx, `UPDATE users SET time_zone=$1, work_days=$2, work_start_minute=$3, work_end_minute=$4
+		WHERE user_id=$5`, timeZone, pq.Array(days), start, end, userID)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetWorkingHours returns the user's working hours, nil if they have none, or
+// sql.ErrNoRows if there is no such user.
+func (p *PostgresDB) GetWorkingHours(ctx context.Context, userID string) (*models.WorkingHours, error) {
+	hours, err := workingHours(ctx, p.db, []string{userID})
+	if err != nil {
+		return nil, err
+	}
+	wh, ok := hours[userID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return wh, nil
+}
+
+// GetUserSchedule returns the schedule the user is picked as a reviewer by:
+// their working hours, or all day every day, and their teams' holidays. It
+// returns sql.ErrNoRows if there is no such user.
+func (p *PostgresDB) GetUserSchedule(ctx context.Context, userID string) (workhours.Schedule, error) {
+	schedules, err := reviewerSchedules(ctx, p.db, []string{userID}, time.Now())
+	if err != nil {
+		return workhours.Schedule{}, err
+	}
+	s, ok := schedules[userID]
+	if !ok {
+		return workhours.Schedule{}, sql.ErrNoRows
+	}
+	return s, nil
+}
+
+// workingHours returns the working hours of the users that exist, nil for the
+// ones who have none.
+func workingHours(ctx context.Context, q querier, userIDs []string) (map[string]*models.WorkingHours, error) {
+	r, err := q.QueryContext(ctx, `SELECT user_id, time_zone, work_days, work_start_minute, work_end_minute FROM users
+		WHERE user_id = ANY($1)`, pq.Array(userIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	hours := make(map[string]*models.WorkingHours, len(userIDs))
+	for r.Next() {
+		var (
+			userID     string
+			timeZone   sql.NullString
+			days       []int64
+			start, end sql.NullInt64
+		)
+		if err := r.Scan(&userID, &timeZone, pq.Array(&days), &start, &end); err != nil {
+			return nil, err
+		}
+		if !timeZone.Valid {
+			hours[userID] = nil
+			continue
+		}
+
+		wh := &models.WorkingHours{TimeZone: timeZone.String, StartMinute: int(start.Int64), EndMinute: int(end.Int64)}
+		for _, d := range days {
+			wh.WorkDays = append(wh.WorkDays, int(d))
+		}
+		hours[userID] = wh
+	}
+
+	return hours, r.Err()
+}
+
+// reviewerSchedules returns the schedules of the users: their working hours, or
+// all day every day, with the holidays of their non-archived teams from the day
+// before since on. Times measured from an assignment need since to be no later
+// than it.
+func reviewerSchedules(ctx context.Context, q querier, userIDs []string, since time.Time) (map[string]workhours.Schedule, error) {
+	hours, err := workingHours(ctx, q, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	schedules := make(map[string]workhours.Schedule, len(hours))
+	for userID, wh := range hours {
+		s := workhours.Always()
+		if wh != nil {
+			loc, err := time.LoadLocation(wh.TimeZone)
+			if err != nil {
+				return nil, err
+			}
+			s = workhours.Schedule{Location: loc, Start: wh.StartMinute, End: wh.EndMinute}
+			for _, d := range wh.WorkDays {
+				s.Days[d%7] = true
+			}
+		}
+		s.Holidays = make(map[string]bool)
+		schedules[userID] = s
+	}
+
+	r, err := q.QueryContext(ctx, `SELECT DISTINCT tm.user_id, to_char(th.holiday, 'YYYY-MM-DD') FROM team_members tm
+		INNER JOIN teams t ON t.team_id=tm.team_id AND t.archived_at IS NULL
+		INNER JOIN team_holidays th ON th.team_id=tm.team_id
+		WHERE tm.user_id = ANY($1) AND th.holiday >= $2::timestamptz::date - 1`, pq.Array(userIDs), since)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	for r.Next() {
+		var userID, day string
+		if err := r.Scan(&userID, &day); err != nil {
+			return nil, err
+		}
+		if s, ok := schedules[userID]; ok {
+			s.Holidays[day] = true
+		}
+	}
+
+	return schedules, r.Err()
+}
+
+// untilWorking returns how long the schedule leaves until the user works,
+// zero if they are working now.
+func untilWorking(s workhours.Schedule, now time.Time) time.Duration {
+	next, ok := s.NextStart(now)
+	if !ok {
+		return math.MaxInt64
+	}
+	return next.Sub(now)
+}
+
+// SetTeamHolidays replaces the team's holidays.
+func (p *PostgresDB) SetTeamHolidays(ctx context.Context, teamID int64, holidays []models.Holiday) error {
+	t, err := p.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+
+	if _, err := t.ExecContext(ctx, "DELETE FROM team_holidays WHERE team_id=$1", teamID); err != nil {
+		_ = t.Rollback()
+		return err
+	}
+
+	dates, names := make([]string, 0, len(holidays)), make([]string, 0, len(holidays))
+	for _, h := range holidays {
+		dates, names = append(dates, h.Date), append(names, h.Name)
+	}
+	if _, err := t.ExecContext(ctx, `INSERT INTO team_holidays (team_id, holiday, name)
+		SELECT $1, d::date, n FROM UNNEST($2::text[], $3::text[]) AS h(d, n)
+		ON CONFLICT DO NOTHING`, teamID, pq.Array(dates), pq.Array(names)); err != nil {
+		_ = t.Rollback()
+		return err
+	}
+
+	return t.Commit()
+}
+
+func (p *PostgresDB) GetTeamHolidays(ctx context.Context, teamID int64) ([]models.Holiday, error) {
+	r, err := p.db.QueryContext(ctx, "SELECT to_char(holiday, 'YYYY-MM-DD'), name FROM team_holidays WHERE team_id=$1 ORDER BY holiday", teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	holidays := make([]models.Holiday, 0)
+	for r.Next() {
+		var h models.Holiday
+		if err := r.Scan(&h.Date, &h.Name); err != nil {
+			return nil, err
+		}
+		holidays = append(holidays, h)
+	}
+
+	return holidays, r.Err()
+}
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/narroworb/pr-review-service/internal/ical"
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+const (
+	maxAbsenceReasonLen = 200
+	maxCalendarUIDLen   = 255
+)
+
+func (h *HandlersRepo) AddAbsence(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	var req models.AddAbsenceRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "BAD_REQUEST", "invalid json body of request", http.StatusBadRequest)
+		return
+	}
+	if req.UserID == "" {
+		writeError(w, "BAD_REQUEST", "empty user_id", http.StatusBadRequest)
+		return
+	}
+	if req.StartsAt.IsZero() || !req.EndsAt.After(req.StartsAt) {
+		writeError(w, "BAD_REQUEST", "starts_at is required and must be before ends_at", http.StatusBadRequest)
+		return
+	}
+	if utf8.RuneCountInString(req.Reason) > maxAbsenceReasonLen {
+		writeError(w, "BAD_REQUEST", fmt.Sprintf("reason must be at most %d characters", maxAbsenceReasonLen), http.StatusBadRequest)
+		return
+	}
+
+	absence, err := h.db.AddAbsence(ctx, models.Absence{
+		UserID:          req.UserID,
+		StartsAt:        req.StartsAt,
+		EndsAt:          req.EndsAt,
+		Reason:          req.Reason,
+		ReassignReviews: req.ReassignReviews,
+	})
+	if err == sql.ErrNoRows {
+		writeError(w, "USER_NOT_FOUND", fmt.Sprintf("there is no user with id=%s", req.UserID), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in add absence in handler /users/addAbsence: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+	h.notifyCapacityFreed()
+
+	resp := models.AbsenceResponse{
+		Absence: absence,
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *HandlersRepo) DeleteAbsence(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	var req models.DeleteAbsenceRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "BAD_REQUEST", "invalid json body of request", http.StatusBadRequest)
+		return
+	}
+
+	err := h.db.DeleteAbsence(ctx, req.AbsenceID)
+	if err == sql.ErrNoRows {
+		writeError(w, "ABSENCE_NOT_FOUND", fmt.Sprintf("there is no absence with id=%d", req.AbsenceID), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in delete absence in handler /users/deleteAbsence: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+	h.notifyCapacityFreed()
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(req)
+}
+
+func (h *HandlersRepo) GetUserAbsences(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		writeError(w, "BAD_REQUEST", "empty query parameter user_id", http.StatusBadRequest)
+		return
+	}
+
+	isActive, isAvailable, err := h.db.GetUserAvailability(ctx, userID)
+	if err == sql.ErrNoRows {
+		writeError(w, "USER_NOT_FOUND", fmt.Sprintf("there is no user with id=%s", userID), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in get availability in handler /users/getAbsences: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	absences, err := h.db.GetUserAbsences(ctx, userID)
+	if err != nil {
+		log.Printf("error in get absences in handler /users/getAbsences: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.UserAbsencesResponse{
+		UserID:      userID,
+		IsActive:    isActive,
+		IsAvailable: isAvailable,
+		Absences:    absences,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *HandlersRepo) ImportAbsences(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	var req models.ImportAbsencesRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "BAD_REQUEST", "invalid json body of request", http.StatusBadRequest)
+		return
+	}
+	if req.UserID == "" {
+		writeError(w, "BAD_REQUEST", "empty user_id", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Calendar) == "" {
+		writeError(w, "BAD_REQUEST", "empty calendar", http.StatusBadRequest)
+		return
+	}
+
+	events, skipped, err := ical.Parse(strings.NewReader(req.Calendar))
+	var parseErr *ical.ParseError
+	if errors.As(err, &parseErr) {
+		writeError(w, "BAD_REQUEST", fmt.Sprintf("invalid calendar: %v", parseErr), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		writeError(w, "BAD_REQUEST", "invalid calendar", http.StatusBadRequest)
+		return
+	}
+
+	absences, err := calendarAbsences(events, req.ReassignReviews, time.Now())
+	if err != nil {
+		writeError(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+		return
+	}
+	skipped += len(events) - len(absences)
+
+	imported, err := h.db.ImportAbsences(ctx, req.UserID, absences)
+	if err == sql.ErrNoRows {
+		writeError(w, "USER_NOT_FOUND", fmt.Sprintf("there is no user with id=%s", req.UserID), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in import absences in handler /users/importAbsences: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+	h.notifyCapacityFreed()
+
+	resp := models.ImportAbsencesResponse{
+		UserID:   req.UserID,
+		Imported: len(imported),
+		Skipped:  skipped,
+		Absences: imported,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// calendarAbsences turns the calendar events that have not ended by now into
+// absences. Events without a UID are keyed by their time range, so that
+// importing the same file twice does not duplicate them.
+func calendarAbsences(events []ical.Event, reassign bool, now time.Time) ([]models.Absence, error) {
+	absences := make([]models.Absence, 0, len(events))
+	for _, e := range events {
+		if !e.End.After(now) {
+			continue
+		}
+
+		uid := e.UID
+		if uid == "" {
+			uid = e.Start.Format(time.RFC3339) + "/" + e.End.Format(time.RFC3339)
+		}
+		if len(uid) > maxCalendarUIDLen {
+			return nil, fmt.Errorf("event UID must be at most %d bytes: %s", maxCalendarUIDLen, uid)
+		}
+
+		reason := e.Summary
+		if utf8.RuneCountInString(reason) > maxAbsenceReasonLen {
+			reason = string([]rune(reason)[:maxAbsenceReasonLen])
+		}
+
+		absences = append(absences, models.Absence{
+			StartsAt:        e.Start,
+			EndsAt:          e.End,
+			Reason:          reason,
+			ReassignReviews: reassign,
+			CalendarUID:     uid,
+		})
+	}
+
+	return absences, nil
+}
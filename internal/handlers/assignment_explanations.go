@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+// AssignPreview validates and picks reviewers for a PR exactly as CreatePR does,
+// but keeps nothing: the response is the PR that would be created with the
+// explanation that would be recorded for it.
+func (h *HandlersRepo) AssignPreview(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	var req models.CreatePRRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "BAD_REQUEST", "invalid json body of request", http.StatusBadRequest)
+		return
+	}
+
+	pr, teamName, ok := h.newPR(ctx, w, req, "/pullRequest/assignPreview")
+	if !ok {
+		return
+	}
+
+	pr, explanation, err := h.db.PreviewPR(ctx, pr)
+	if writeInsertPRError(w, err, req, "/pullRequest/assignPreview") {
+		return
+	}
+
+	resp := models.AssignPreviewResponse{
+		CreatePRResponse: createPRResponse(pr, teamName),
+		Explanation:      explanation,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *HandlersRepo) ExplainPR(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	pRID := r.URL.Query().Get("pull_request_id")
+	if pRID == "" {
+		writeError(w, "BAD_REQUEST", "empty query parameter pull_request_id", http.StatusBadRequest)
+		return
+	}
+
+	_, err := h.db.GetPRByID(ctx, pRID)
+	if err == sql.ErrNoRows {
+		writeError(w, "PR_NOT_FOUND", fmt.Sprintf("there is no pull request with id=%s", pRID), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in get pr in handler /pullRequest/explain: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	explanations, err := h.db.GetAssignmentExplanations(ctx, pRID)
+	if err != nil {
+		log.Printf("error in get assignment explanations in handler /pullRequest/explain: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(models.ExplainPRResponse{PRID: pRID, Explanations: explanations})
+}
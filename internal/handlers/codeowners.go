@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/narroworb/pr-review-service/internal/codeowners"
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+func codeownersRules(f *codeowners.File) []models.CodeownersRule {
+	rules := make([]models.CodeownersRule, 0, len(f.Rules))
+	for _, r := range f.Rules {
+		owners := r.Owners
+		if owners == nil {
+			owners = []string{}
+		}
+		rules = append(rules, models.CodeownersRule{Line: r.Line, Pattern: r.Pattern, Owners: owners})
+	}
+	return rules
+}
+
+func (h *HandlersRepo) UploadCodeowners(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	var req models.UploadCodeownersRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "BAD_REQUEST", "invalid json body of request", http.StatusBadRequest)
+		return
+	}
+	if req.Repository == "" {
+		writeError(w, "BAD_REQUEST", "empty repository", http.StatusBadRequest)
+		return
+	}
+
+	f, err := codeowners.Parse(req.Content)
+	if err != nil {
+		writeError(w, "INVALID_CODEOWNERS", fmt.Sprintf("cannot parse CODEOWNERS: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.db.SaveCodeowners(ctx, req.Repository, req.Content); err != nil {
+		log.Printf("error in save codeowners in handler /codeowners/upload: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	saved, err := h.db.GetCodeowners(ctx, req.Repository)
+	if err != nil {
+		log.Printf("error in get codeowners in handler /codeowners/upload: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.GetCodeownersResponse{
+		Repository: saved.Repository,
+		UploadedAt: saved.UploadedAt,
+		Rules:      codeownersRules(f),
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *HandlersRepo) GetCodeowners(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	repository := r.URL.Query().Get("repository")
+	if repository == "" {
+		writeError(w, "BAD_REQUEST", "empty query parameter repository", http.StatusBadRequest)
+		return
+	}
+
+	saved, err := h.db.GetCodeowners(ctx, repository)
+	if err == sql.ErrNoRows {
+		writeError(w, "CODEOWNERS_NOT_FOUND", fmt.Sprintf("there is no CODEOWNERS for repository %s", repository), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in get codeowners in handler /codeowners/get: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	f, err := codeowners.Parse(saved.Content)
+	if err != nil {
+		log.Printf("error in parse stored codeowners of %s in handler /codeowners/get: %v", repository, err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.GetCodeownersResponse{
+		Repository: saved.Repository,
+		UploadedAt: saved.UploadedAt,
+		Content:    saved.Content,
+		Rules:      codeownersRules(f),
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -4,13 +4,16 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"slices"
 	"time"
 
 	"github.com/narroworb/pr-review-service/internal/models"
+	"github.com/narroworb/pr-review-service/internal/workhours"
 )
 
 type DatabaseInterface interface {
@@ -18,33 +21,99 @@ type DatabaseInterface interface {
 	CreateTeam(context.Context, string) (int64, error)
 	GetUserByID(context.Context, string) (models.User, error)
 	CreateUser(context.Context, models.User) error
-	GetUsersInTeam(context.Context, int64) ([]models.User, error)
+	GetUsersInTeam(context.Context, int64) ([]models.Member, error)
 	InsertTeamInTransaction(context.Context, string, []models.User) error
-	GetUserWithTeamByID(context.Context, string) (models.User, string, error)
+	GetUserWithTeamByID(context.Context, string) (models.User, []models.Membership, error)
 	UpdateUserActivity(context.Context, string, bool) error
 	GetPRByID(context.Context, string) (models.PullRequest, error)
-	GetActiveUsersInTeamExcAuthor(context.Context, int64, string) ([]models.User, error)
-	InsertPRInTransaction(context.Context, models.PullRequest) error
-	GetReviewersByPRID(context.Context, string) ([]string, error)
-	SetMergedStatusPR(context.Context, string) (time.Time, error)
-	FoundAvailableReviewerPR(context.Context, string, []string, string) (string, error)
-	SwapReviewerInPR(context.Context, string, string, string) error
+	InsertPRInTransaction(context.Context, models.PullRequest) (models.PullRequest, error)
+	PreviewPR(context.Context, models.PullRequest) (models.PullRequest, models.AssignmentExplanation, error)
+	GetAssignmentExplanations(context.Context, string) ([]models.AssignmentExplanation, error)
 	GetPRByReviewerID(context.Context, string) ([]models.PullRequest, error)
-	GetCountPRStatsByUser(context.Context) ([]models.UserStats, error)
-	GetCountPRStatsByTeam(context.Context) ([]models.TeamStats, error)
-	GetCountReviewerStatsByPR(context.Context) (map[string]int64, error)
+	GetCountPRStatsByUser(context.Context, models.StatsFilter) ([]models.UserStats, error)
+	GetCountPRStatsByTeam(context.Context, models.StatsFilter) ([]models.TeamStats, error)
+	GetCountReviewerStatsByPR(context.Context, models.StatsFilter) ([]models.PRReviewerStats, error)
 	UpdateUsersActivityInTeam(context.Context, int64) ([]models.User, error)
 	UpdateUsersActivityByID(context.Context, map[string]struct{}) ([]models.User, map[string]struct{}, error)
-	FoundAvailableReviewerPRAndSwapReviewerInPR(context.Context, string, []string, string, string) (string, error)
+	MergePRInTransaction(context.Context, string) (models.PullRequest, []string, error)
+	ReassignReviewerInTransaction(context.Context, string, string, string) (models.PullRequest, []string, string, error)
+	AddReviewerInTransaction(context.Context, string, string) (models.PullRequest, []string, error)
+	RemoveReviewerInTransaction(context.Context, string, string) (models.PullRequest, []string, error)
+	AcceptReviewInTransaction(context.Context, string, string) (models.PullRequest, []string, time.Time, error)
+	DeclineReviewInTransaction(context.Context, string, string, string) (models.PullRequest, []string, string, error)
+	GetDeclineStats(context.Context, models.StatsFilter) ([]models.DeclineStats, error)
+	AddMembersToTeamInTransaction(context.Context, int64, []models.Member) error
+	UpdateTeamMember(context.Context, int64, string, models.MemberRole, bool) error
+	RemoveUserFromTeamInTransaction(context.Context, string, int64) ([]models.ReviewHandover, error)
+	MoveUserToTeamInTransaction(context.Context, string, int64, int64) (int64, []models.ReviewHandover, error)
+	GetUserTeamHistory(context.Context, string) ([]models.TeamMembership, error)
+	RenameTeam(context.Context, int64, string) error
+	ArchiveTeamInTransaction(context.Context, int64) ([]models.User, error)
+	DeleteTeamInTransaction(context.Context, int64, bool) ([]models.ReviewHandover, error)
+	SetTeamParent(context.Context, int64, int64, bool) error
+	SetLabelRule(context.Context, string, int64, int) error
+	DeleteLabelRule(context.Context, string, int64) error
+	GetLabelRules(context.Context) ([]models.LabelRule, error)
+	SetPairRule(context.Context, string, string, models.PairRuleKind) (models.PairRule, error)
+	DeletePairRule(context.Context, string, string) error
+	GetPairRules(context.Context, string) ([]models.PairRule, error)
+	SaveCodeowners(context.Context, string, string) error
+	GetCodeowners(context.Context, string) (models.CodeownersFile, error)
+	SetUserSkills(context.Context, string, []string) error
+	GetUserSkills(context.Context, string) ([]string, error)
+	ClosePRInTransaction(context.Context, string) (models.PullRequest, []string, error)
+	SetUserCapacity(context.Context, string, *int) error
+	SetTeamCapacity(context.Context, int64, *int) error
+	GetEvents(context.Context, int64, int) ([]models.Event, error)
+	AddAbsence(context.Context, models.Absence) (models.Absence, error)
+	DeleteAbsence(context.Context, int64) error
+	GetUserAvailability(context.Context, string) (bool, bool, error)
+	GetUserAbsences(context.Context, string) ([]models.Absence, error)
+	ImportAbsences(context.Context, string, []models.Absence) ([]models.Absence, error)
+	SetWorkingHours(context.Context, string, *models.WorkingHours) error
+	GetWorkingHours(context.Context, string) (*models.WorkingHours, error)
+	GetUserSchedule(context.Context, string) (workhours.Schedule, error)
+	SetTeamHolidays(context.Context, int64, []models.Holiday) error
+	GetTeamHolidays(context.Context, int64) ([]models.Holiday, error)
+	SetSeniority(context.Context, string, models.Seniority) error
+	SetReviewPolicy(context.Context, int64, models.ReviewPolicy) error
+	GetReviewPolicy(context.Context, int64) (models.ReviewPolicy, error)
+	SetSLAPolicy(context.Context, int64, models.SLAPolicy) error
+	GetSLAPolicy(context.Context, int64) (models.SLAPolicy, error)
+	GetSLABreaches(context.Context, int64) ([]models.SLABreach, error)
+	SetLoadWeighting(context.Context, models.LoadWeighting) error
+	GetLoadWeighting(context.Context) (models.LoadWeighting, error)
+	ListPRs(context.Context, models.PRListFilter) ([]models.PRSummary, error)
+	GetPRDetails(context.Context, string) (models.PRDetails, error)
+	GetInbox(context.Context, models.InboxFilter) ([]models.InboxItem, error)
+	GetInboxCount(context.Context, string) (models.InboxCount, error)
+	ListTeams(context.Context, models.TeamListFilter) ([]models.TeamSummary, error)
+	ListUsers(context.Context, models.UserListFilter) ([]models.UserSummary, error)
 }
 
 type HandlersRepo struct {
-	db DatabaseInterface
+	db            DatabaseInterface
+	capacityFreed chan struct{}
 }
 
 func NewHandlersRepo(db DatabaseInterface) *HandlersRepo {
 	return &HandlersRepo{
-		db: db,
+		db:            db,
+		capacityFreed: make(chan struct{}, 1),
+	}
+}
+
+// CapacityFreed receives after requests that may have freed review capacity or
+// changed absences, so that absent users' reviews are handed over and the
+// review queue is processed without waiting for the next tick.
+func (h *HandlersRepo) CapacityFreed() <-chan struct{} {
+	return h.capacityFreed
+}
+
+func (h *HandlersRepo) notifyCapacityFreed() {
+	select {
+	case h.capacityFreed <- struct{}{}:
+	default:
 	}
 }
 
@@ -96,7 +165,7 @@ func (h *HandlersRepo) AddTeam(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		if user.ID != "" {
-			writeError(w, "USER_EXISTS", fmt.Sprintf("user with id=%s already belongs to another team", m.UserID), http.StatusBadRequest)
+			writeError(w, "USER_EXISTS", fmt.Sprintf("user with id=%s already exists, use /team/addMembers to add it to the team", m.UserID), http.StatusBadRequest)
 			return
 		}
 
@@ -120,7 +189,12 @@ func (h *HandlersRepo) AddTeam(w http.ResponseWriter, r *http.Request) {
 		// }
 	}
 
-	if err := h.db.InsertTeamInTransaction(ctx, req.TeamName, users); err != nil {
+	err = h.db.InsertTeamInTransaction(ctx, req.TeamName, users)
+	if err == models.ErrTeamExists {
+		writeError(w, "TEAM_EXISTS", fmt.Sprintf("team_name %s already exists", req.TeamName), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
 		log.Printf("error in apply transaction to create team in handler /team/add: %v", err)
 		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
 		return
@@ -152,7 +226,8 @@ func (h *HandlersRepo) GetTeam(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var resp models.GetTeamResponse
-	resp.Team.Name = team.Name
+	resp.Team.Name, resp.Team.IsArchived = team.Name, team.ArchivedAt != nil
+	resp.Team.ParentTeamName, resp.Team.FallbackToSiblings = team.ParentName, team.FallbackToSiblings
 	members, err := h.db.GetUsersInTeam(ctx, team.ID)
 	if err != nil && err != sql.ErrNoRows {
 		log.Printf("error in get users in handler /team/get?team_name=%s: %v", teamName, err)
@@ -177,7 +252,7 @@ func (h *HandlersRepo) SetUserIsActive(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, teamName, err := h.db.GetUserWithTeamByID(ctx, req.UserID)
+	user, memberships, err := h.db.GetUserWithTeamByID(ctx, req.UserID)
 
 	if err == sql.ErrNoRows {
 		writeError(w, "USER_NOT_FOUND", fmt.Sprintf("there is not user with id=%s", req.UserID), http.StatusNotFound)
@@ -190,7 +265,10 @@ func (h *HandlersRepo) SetUserIsActive(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var resp models.SetUserIsActiveResponse
-	resp.User.UserID, resp.User.Username, resp.User.TeamName, resp.User.IsActive = user.ID, user.Name, teamName, req.IsActive
+	resp.User.UserID, resp.User.Username, resp.User.IsActive, resp.User.Teams = user.ID, user.Name, req.IsActive, memberships
+	if len(memberships) > 0 && memberships[0].IsPrimary {
+		resp.User.TeamName = memberships[0].TeamName
+	}
 
 	if user.IsActive == req.IsActive {
 		w.WriteHeader(http.StatusOK)
@@ -204,6 +282,9 @@ func (h *HandlersRepo) SetUserIsActive(w http.ResponseWriter, r *http.Request) {
 		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
 		return
 	}
+	if req.IsActive {
+		h.notifyCapacityFreed()
+	}
 
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(resp)
@@ -221,59 +302,185 @@ func (h *HandlersRepo) CreatePR(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	pr, teamName, ok := h.newPR(ctx, w, req, "/pullRequest/create")
+	if !ok {
+		return
+	}
+
+	pr, err := h.db.InsertPRInTransaction(ctx, pr)
+	if writeInsertPRError(w, err, req, "/pullRequest/create") {
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(createPRResponse(pr, teamName))
+}
+
+// newPR validates the request to create a PR and builds the PR with the name of
+// its team, writing the error response if the request is not valid.
+func (h *HandlersRepo) newPR(ctx context.Context, w http.ResponseWriter, req models.CreatePRRequest, path string) (models.PullRequest, string, bool) {
 	_, err := h.db.GetPRByID(ctx, req.PRID)
 	if err != nil && err != sql.ErrNoRows {
-		log.Printf("error in create pr in handler /pullRequest/create: %v", err)
+		log.Printf("error in create pr in handler %s: %v", path, err)
 		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
-		return
+		return models.PullRequest{}, "", false
 	}
 	if err == nil {
 		writeError(w, "PR_EXISTS", fmt.Sprintf("PR with id=%s already exists", req.PRID), http.StatusBadRequest)
-		return
+		return models.PullRequest{}, "", false
 	}
 
-	user, _, err := h.db.GetUserWithTeamByID(ctx, req.AuthorID)
+	user, memberships, err := h.db.GetUserWithTeamByID(ctx, req.AuthorID)
 	if err == sql.ErrNoRows {
 		writeError(w, "USER_NOT_FOUND", fmt.Sprintf("there is no user with id=%s", req.AuthorID), http.StatusNotFound)
-		return
+		return models.PullRequest{}, "", false
 	}
 	if err != nil {
-		log.Printf("error in get user in handler /pullRequest/create: %v", err)
+		log.Printf("error in get user in handler %s: %v", path, err)
 		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
-		return
+		return models.PullRequest{}, "", false
 	}
 
-	reviewers, err := h.db.GetActiveUsersInTeamExcAuthor(ctx, user.GroupID, user.ID)
+	labels, err := normalizeLabels(req.Labels)
 	if err != nil {
-		log.Printf("error in get reviewers in handler /pullRequest/create: %v", err)
-		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
-		return
+		writeError(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+		return models.PullRequest{}, "", false
+	}
+	tags, err := normalizeTags("tag", req.Tags)
+	if err != nil {
+		writeError(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+		return models.PullRequest{}, "", false
+	}
+	if req.Priority < 0 {
+		writeError(w, "BAD_REQUEST", "priority must not be negative", http.StatusBadRequest)
+		return models.PullRequest{}, "", false
+	}
+	if len(req.ChangedFiles) > 0 && req.Repository == "" {
+		writeError(w, "BAD_REQUEST", "changed_files require repository", http.StatusBadRequest)
+		return models.PullRequest{}, "", false
+	}
+	metadata, err := prMetadata(req)
+	if err != nil {
+		writeError(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+		return models.PullRequest{}, "", false
 	}
 
 	pr := models.PullRequest{
-		ID:        req.PRID,
-		Name:      req.PRName,
-		AuthorID:  req.AuthorID,
-		Status:    models.PRStatusOpen,
-		Reviewers: reviewers,
+		ID:                 req.PRID,
+		Name:               req.PRName,
+		AuthorID:           user.ID,
+		Status:             models.PRStatusOpen,
+		PRMetadata:         metadata,
+		Labels:             labels,
+		ChangedFiles:       req.ChangedFiles,
+		PreferredReviewers: req.Preferred,
+		Tags:               tags,
+		Priority:           req.Priority,
+	}
+
+	teamName := ""
+	if len(memberships) > 0 && memberships[0].IsPrimary {
+		teamName = memberships[0].TeamName
+	}
+	if req.TeamName != "" {
+		team, err := h.db.GetTeamByName(ctx, req.TeamName)
+		if err == sql.ErrNoRows {
+			writeError(w, "TEAM_NOT_FOUND", fmt.Sprintf("there is no team with name: %s", req.TeamName), http.StatusNotFound)
+			return models.PullRequest{}, "", false
+		}
+		if err != nil {
+			log.Printf("error in get team in handler %s: %v", path, err)
+			writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+			return models.PullRequest{}, "", false
+		}
+		if team.ArchivedAt != nil {
+			writeError(w, "TEAM_ARCHIVED", fmt.Sprintf("team %s is archived", team.Name), http.StatusConflict)
+			return models.PullRequest{}, "", false
+		}
+		pr.TeamID, teamName = team.ID, team.Name
 	}
 
-	err = h.db.InsertPRInTransaction(ctx, pr)
-	if err != nil {
-		log.Printf("error in insert pr in handler /pullRequest/create: %v", err)
-		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
-		return
+	return pr, teamName, true
+}
+
+// prMetadata validates the metadata of the PR to create; the time of creation
+// is left zero if the request has none.
+func prMetadata(req models.CreatePRRequest) (models.PRMetadata, error) {
+	if req.LinesAdded < 0 || req.LinesRemoved < 0 || req.FilesCount < 0 {
+		return models.PRMetadata{}, errors.New("lines_added, lines_removed and files_count must not be negative")
+	}
+	if req.URL != "" {
+		u, err := url.Parse(req.URL)
+		if err != nil || u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+			return models.PRMetadata{}, errors.New("url must be an absolute http or https URL")
+		}
+	}
+
+	metadata := models.PRMetadata{
+		Repository:   req.Repository,
+		SourceBranch: req.SourceBranch,
+		TargetBranch: req.TargetBranch,
+		URL:          req.URL,
+		Description:  req.Description,
+		LinesAdded:   req.LinesAdded,
+		LinesRemoved: req.LinesRemoved,
+		FilesCount:   req.FilesCount,
+	}
+	if metadata.FilesCount == 0 {
+		metadata.FilesCount = len(req.ChangedFiles)
+	}
+	if req.CreatedAt != nil {
+		metadata.CreatedAt = *req.CreatedAt
+	}
+	return metadata, nil
+}
+
+// writeInsertPRError writes the response for an error of creating a PR, and
+// returns false if there is none.
+func writeInsertPRError(w http.ResponseWriter, err error, req models.CreatePRRequest, path string) bool {
+	if err == nil {
+		return false
+	}
+	if err == models.ErrPRExists {
+		writeError(w, "PR_EXISTS", fmt.Sprintf("PR with id=%s already exists", req.PRID), http.StatusBadRequest)
+		return true
+	}
+	if err == sql.ErrNoRows {
+		writeError(w, "USER_NOT_FOUND", fmt.Sprintf("there is no user with id=%s", req.AuthorID), http.StatusNotFound)
+		return true
+	}
+	if err == models.ErrNotTeamMember {
+		writeError(w, "NOT_TEAM_MEMBER", fmt.Sprintf("user with id=%s is not a member of team %s", req.AuthorID, req.TeamName), http.StatusConflict)
+		return true
 	}
+	if writePolicyError(w, err) {
+		return true
+	}
+	log.Printf("error in insert pr in handler %s: %v", path, err)
+	writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+	return true
+}
 
+func createPRResponse(pr models.PullRequest, teamName string) models.CreatePRResponse {
 	var resp models.CreatePRResponse
 
 	resp.PR.PRID, resp.PR.PRName, resp.PR.AuthorID, resp.PR.Status, resp.PR.Reviewers = pr.ID, pr.Name, pr.AuthorID, pr.Status, make([]string, 0, 2)
+	if pr.TeamID != 0 {
+		resp.PR.TeamName = teamName
+	}
+	resp.PR.Labels, resp.PR.FallbackReviewers, resp.PR.RequiredReviewers = pr.Labels, pr.FallbackReviewers, pr.RequiredReviewers
+	resp.PR.PRMetadata, resp.PR.CodeownerReviewers = pr.PRMetadata, pr.CodeownerReviewers
+	resp.PR.Tags, resp.PR.SkillMatches = pr.Tags, pr.SkillMatches
+	resp.PR.AssignmentStatus, resp.PR.PendingReviewers = assignmentStatus(pr), pr.PendingReviewers
+	resp.PR.PreferredReviewers = make([]string, 0)
 	for _, u := range pr.Reviewers {
 		resp.PR.Reviewers = append(resp.PR.Reviewers, u.ID)
+		if slices.Contains(pr.PreferredReviewers, u.ID) {
+			resp.PR.PreferredReviewers = append(resp.PR.PreferredReviewers, u.ID)
+		}
 	}
 
-	w.WriteHeader(http.StatusCreated)
-	_ = json.NewEncoder(w).Encode(resp)
+	return resp
 }
 
 func (h *HandlersRepo) MergePR(w http.ResponseWriter, r *http.Request) {
@@ -288,40 +495,25 @@ func (h *HandlersRepo) MergePR(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pr, err := h.db.GetPRByID(ctx, req.PRID)
+	pr, reviewers, err := h.db.MergePRInTransaction(ctx, req.PRID)
 	if err == sql.ErrNoRows {
 		writeError(w, "PR_NOT_FOUND", fmt.Sprintf("there is no pull request with id=%s", req.PRID), http.StatusNotFound)
 		return
 	}
-	if err != nil {
-		log.Printf("error in get pr in handler /pullRequest/merge: %v", err)
-		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+	if err == models.ErrPRClosed {
+		writeError(w, "PR_CLOSED", "cannot merge closed PR", http.StatusConflict)
 		return
 	}
-
-	var resp models.MergePRResponse
-	resp.PR.PRID, resp.PR.PRName, resp.PR.AuthorID, resp.PR.Status = pr.ID, pr.Name, pr.AuthorID, pr.Status
-	resp.PR.Reviewers, err = h.db.GetReviewersByPRID(ctx, pr.ID)
 	if err != nil {
-		log.Printf("error in get reviewers in handler /pullRequest/merge: %v", err)
+		log.Printf("error in merge pr in handler /pullRequest/merge: %v", err)
 		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
 		return
 	}
+	h.notifyCapacityFreed()
 
-	if pr.Status == models.PRStatusMerged {
-		resp.PR.MergedAt = *pr.MergedAt
-		w.WriteHeader(http.StatusOK)
-		_ = json.NewEncoder(w).Encode(resp)
-		return
-	}
-
-	resp.PR.MergedAt, err = h.db.SetMergedStatusPR(ctx, pr.ID)
-	if err != nil {
-		log.Printf("error in update status in handler /pullRequest/merge: %v", err)
-		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
-		return
-	}
-	resp.PR.Status = models.PRStatusMerged
+	var resp models.MergePRResponse
+	resp.PR.PRID, resp.PR.PRName, resp.PR.AuthorID, resp.PR.Status = pr.ID, pr.Name, pr.AuthorID, pr.Status
+	resp.PR.Reviewers, resp.PR.MergedAt, resp.PR.PRMetadata = reviewers, *pr.MergedAt, pr.PRMetadata
 
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(resp)
@@ -354,49 +546,62 @@ func (h *HandlersRepo) ReassignPR(w http.ResponseWriter, r *http.Request) {
 		writeError(w, "PR_MERGED", "cannot reassign on merged PR", http.StatusConflict)
 		return
 	}
+	if pr.Status == models.PRStatusClosed {
+		writeError(w, "PR_CLOSED", "cannot reassign on closed PR", http.StatusConflict)
+		return
+	}
+
+	userIDs := []string{req.OldReviewerID}
+	if req.NewReviewerID != "" {
+		userIDs = append(userIDs, req.NewReviewerID)
+	}
+	for _, userID := range userIDs {
+		_, err = h.db.GetUserByID(ctx, userID)
+		if err == sql.ErrNoRows {
+			writeError(w, "USER_NOT_FOUND", fmt.Sprintf("there is no user with id=%s", userID), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("error in get user in handler /pullRequest/reassign: %v", err)
+			writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+			return
+		}
+	}
 
-	_, err = h.db.GetUserByID(ctx, req.OldReviewerID)
+	pr, reviewers, newReviewerID, err := h.db.ReassignReviewerInTransaction(ctx, req.PRID, req.OldReviewerID, req.NewReviewerID)
 	if err == sql.ErrNoRows {
-		writeError(w, "USER_NOT_FOUND", fmt.Sprintf("there is no user with id=%s", req.OldReviewerID), http.StatusNotFound)
+		writeError(w, "PR_NOT_FOUND", fmt.Sprintf("there is no pull request with id=%s", req.PRID), http.StatusNotFound)
 		return
 	}
-	if err != nil {
-		log.Printf("error in get user in handler /pullRequest/reassign: %v", err)
-		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+	if err == models.ErrPRMerged {
+		writeError(w, "PR_MERGED", "cannot reassign on merged PR", http.StatusConflict)
 		return
 	}
-
-	var resp models.ReassignPRResponse
-	resp.PR.PRID, resp.PR.PRName, resp.PR.AuthorID, resp.PR.Status = pr.ID, pr.Name, pr.AuthorID, pr.Status
-	resp.PR.Reviewers, err = h.db.GetReviewersByPRID(ctx, pr.ID)
-
-	if err != nil {
-		log.Printf("error in get reviewers in handler /pullRequest/reassign: %v", err)
-		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+	if err == models.ErrPRClosed {
+		writeError(w, "PR_CLOSED", "cannot reassign on closed PR", http.StatusConflict)
 		return
 	}
-	if !slices.Contains(resp.PR.Reviewers, req.OldReviewerID) {
+	if err == models.ErrNotAssigned {
 		writeError(w, "NOT_ASSIGNED", fmt.Sprintf("reviewer with id=%s is not assigned to PR with id=%s", req.OldReviewerID, req.PRID), http.StatusConflict)
 		return
 	}
-
-	availableReviewerID, err := h.db.FoundAvailableReviewerPRAndSwapReviewerInPR(ctx, req.PRID, resp.PR.Reviewers, resp.PR.AuthorID, req.OldReviewerID)
-	if err == sql.ErrNoRows {
+	if err == models.ErrNoCandidate {
 		writeError(w, "NO_CANDIDATE", "no active replacement candidate in team", http.StatusConflict)
 		return
 	}
+	if writePolicyError(w, err) || writeChosenReviewerError(w, err, req.NewReviewerID) {
+		return
+	}
 	if err != nil {
 		log.Printf("error in found and swap reviewer in handler /pullRequest/reassign: %v", err)
 		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
 		return
 	}
 
-	if resp.PR.Reviewers[0] == req.OldReviewerID {
-		resp.PR.Reviewers[0] = availableReviewerID
-	} else {
-		resp.PR.Reviewers[1] = availableReviewerID
+	resp := models.ReassignPRResponse{
+		PR:         reviewersPR(pr, reviewers),
+		ReplacedBy: newReviewerID,
 	}
-	resp.ReplacedBy = availableReviewerID
 
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(resp)
@@ -439,62 +644,6 @@ func (h *HandlersRepo) GetReview(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(resp)
 }
 
-func (h *HandlersRepo) GetStatsByUsers(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-
-	ctx := r.Context()
-
-	stats, err := h.db.GetCountPRStatsByUser(ctx)
-	if err != nil && err != sql.ErrNoRows {
-		log.Printf("error in get stats in handler /stats/users: %v", err)
-		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
-		return
-	}
-
-	var resp models.GetStatsUsersResponse
-	resp.PRStats = stats
-	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(resp)
-}
-
-func (h *HandlersRepo) GetStatsByTeams(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-
-	ctx := r.Context()
-
-	stats, err := h.db.GetCountPRStatsByTeam(ctx)
-	if err != nil && err != sql.ErrNoRows {
-		log.Printf("error in get stats in handler /stats/users: %v", err)
-		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
-		return
-	}
-
-	var resp models.GetStatsTeamsResponse
-	resp.PRStats = stats
-	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(resp)
-}
-
-func (h *HandlersRepo) GetStatsByPRs(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-
-	ctx := r.Context()
-
-	stats, err := h.db.GetCountReviewerStatsByPR(ctx)
-	if err != nil && err != sql.ErrNoRows {
-		log.Printf("error in get stats in handler /stats/users: %v", err)
-		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
-		return
-	}
-
-	resp := models.GetStatsPRsResponse{
-		PRStats: stats,
-	}
-
-	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(resp)
-}
-
 func (h *HandlersRepo) DeactivateAllUsersInTeam(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
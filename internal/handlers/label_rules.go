@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+const maxLabelLength = 100
+
+// normalizeLabels trims and lowercases the labels and drops duplicates, so that
+// "DB" on a PR matches a rule for "db".
+func normalizeLabels(labels []string) ([]string, error) {
+	return normalizeTags("label", labels)
+}
+
+// normalizeTags normalizes labels, skills and PR tags alike; kind names the
+// values in errors.
+func normalizeTags(kind string, labels []string) ([]string, error) {
+	normalized := make([]string, 0, len(labels))
+	seen := make(map[string]struct{}, len(labels))
+	for _, l := range labels {
+		l = strings.ToLower(strings.TrimSpace(l))
+		if l == "" {
+			return nil, fmt.Errorf("empty %s", kind)
+		}
+		if len(l) > maxLabelLength {
+			return nil, fmt.Errorf("%s %s is longer than %d characters", kind, l, maxLabelLength)
+		}
+		if _, ok := seen[l]; ok {
+			continue
+		}
+		seen[l] = struct{}{}
+		normalized = append(normalized, l)
+	}
+	return normalized, nil
+}
+
+func (h *HandlersRepo) SetLabelRule(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	var req models.SetLabelRuleRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "BAD_REQUEST", "invalid json body of request", http.StatusBadRequest)
+		return
+	}
+	labels, err := normalizeLabels([]string{req.Label})
+	if err != nil {
+		writeError(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.ReviewersCount < 1 {
+		writeError(w, "BAD_REQUEST", "reviewers_count must be at least 1", http.StatusBadRequest)
+		return
+	}
+
+	team, err := h.db.GetTeamByName(ctx, req.TeamName)
+	if err == sql.ErrNoRows {
+		writeError(w, "TEAM_NOT_FOUND", fmt.Sprintf("there is no team with name: %s", req.TeamName), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in get team in handler /labelRules/set: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+	if team.ArchivedAt != nil {
+		writeError(w, "TEAM_ARCHIVED", fmt.Sprintf("team %s is archived", team.Name), http.StatusConflict)
+		return
+	}
+
+	if err := h.db.SetLabelRule(ctx, labels[0], team.ID, req.ReviewersCount); err != nil {
+		log.Printf("error in set label rule in handler /labelRules/set: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.LabelRule{
+		Label:          labels[0],
+		TeamName:       team.Name,
+		ReviewersCount: req.ReviewersCount,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *HandlersRepo) DeleteLabelRule(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	var req models.DeleteLabelRuleRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "BAD_REQUEST", "invalid json body of request", http.StatusBadRequest)
+		return
+	}
+	label := strings.ToLower(strings.TrimSpace(req.Label))
+
+	team, err := h.db.GetTeamByName(ctx, req.TeamName)
+	if err == sql.ErrNoRows {
+		writeError(w, "TEAM_NOT_FOUND", fmt.Sprintf("there is no team with name: %s", req.TeamName), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in get team in handler /labelRules/delete: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	err = h.db.DeleteLabelRule(ctx, label, team.ID)
+	if err == sql.ErrNoRows {
+		writeError(w, "RULE_NOT_FOUND", fmt.Sprintf("there is no rule for label %s and team %s", label, team.Name), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in delete label rule in handler /labelRules/delete: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.LabelRule{
+		Label:    label,
+		TeamName: team.Name,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *HandlersRepo) GetLabelRules(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	rules, err := h.db.GetLabelRules(ctx)
+	if err != nil {
+		log.Printf("error in get label rules in handler /labelRules/list: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(models.GetLabelRulesResponse{Rules: rules})
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+// validateLoadWeighting returns why the weighting cannot be set, or an empty
+// string.
+func validateLoadWeighting(lw models.LoadWeighting) string {
+	if !lw.Mode.Valid() {
+		return "mode must be one of count, buckets, log"
+	}
+	if lw.LogBaseLines < 0 {
+		return "log_base_lines must not be negative"
+	}
+	if lw.LogBaseLines != 0 && lw.Mode != models.LoadWeightingLog {
+		return "log_base_lines is only used by mode log"
+	}
+	if lw.Mode != models.LoadWeightingBuckets {
+		if len(lw.Buckets) != 0 {
+			return "buckets are only used by mode buckets"
+		}
+		return ""
+	}
+
+	if len(lw.Buckets) == 0 {
+		return "mode buckets needs at least one bucket"
+	}
+	seen := make(map[int]bool, len(lw.Buckets))
+	for _, b := range lw.Buckets {
+		if b.MaxLines < 0 {
+			return "max_lines must not be negative"
+		}
+		if b.Weight <= 0 {
+			return "weight must be positive"
+		}
+		if seen[b.MaxLines] {
+			return "max_lines of buckets must be unique"
+		}
+		seen[b.MaxLines] = true
+	}
+	return ""
+}
+
+func (h *HandlersRepo) SetLoadWeighting(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	var req models.LoadWeighting
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "BAD_REQUEST", "invalid json body of request", http.StatusBadRequest)
+		return
+	}
+	if msg := validateLoadWeighting(req); msg != "" {
+		writeError(w, "BAD_REQUEST", msg, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.db.SetLoadWeighting(ctx, req); err != nil {
+		log.Printf("error in set load weighting in handler /loadWeighting/set: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	if req.Mode == models.LoadWeightingLog && req.LogBaseLines == 0 {
+		req.LogBaseLines = 100
+	}
+	slices.SortFunc(req.Buckets, func(a, b models.LoadWeightBucket) int { return a.MaxLines - b.MaxLines })
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(req)
+}
+
+func (h *HandlersRepo) GetLoadWeighting(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	lw, err := h.db.GetLoadWeighting(r.Context())
+	if err != nil {
+		log.Printf("error in get load weighting in handler /loadWeighting/get: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(lw)
+}
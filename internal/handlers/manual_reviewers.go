@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+// writeChosenReviewerError responds with the reason the user chosen as a
+// reviewer cannot review the PR, and returns false if err is not one.
+func writeChosenReviewerError(w http.ResponseWriter, err error, userID string) bool {
+	switch err {
+	case models.ErrReviewerIsAuthor:
+		writeError(w, "REVIEWER_IS_AUTHOR", fmt.Sprintf("user with id=%s is the author of the PR", userID), http.StatusConflict)
+	case models.ErrAlreadyAssigned:
+		writeError(w, "ALREADY_ASSIGNED", fmt.Sprintf("user with id=%s is already a reviewer of the PR", userID), http.StatusConflict)
+	case models.ErrReviewerUnavailable:
+		writeError(w, "REVIEWER_UNAVAILABLE", fmt.Sprintf("user with id=%s is inactive or absent", userID), http.StatusConflict)
+	case models.ErrReviewerExcluded:
+		writeError(w, "REVIEWER_EXCLUDED", fmt.Sprintf("user with id=%s is excluded from the PR by a pair rule or the team review policy", userID), http.StatusConflict)
+	case models.ErrReviewerDeclined:
+		writeError(w, "REVIEWER_DECLINED", fmt.Sprintf("user with id=%s has declined the PR", userID), http.StatusConflict)
+	case models.ErrReviewerAtCapacity:
+		writeError(w, "REVIEWER_AT_CAPACITY", fmt.Sprintf("user with id=%s is at their open review cap", userID), http.StatusConflict)
+	case models.ErrNotInReviewerPool:
+		writeError(w, "NOT_IN_REVIEWER_POOL", fmt.Sprintf("user with id=%s is not in the PR's team or its reviewer pools", userID), http.StatusConflict)
+	default:
+		return false
+	}
+	return true
+}
+
+func reviewersPR(pr models.PullRequest, reviewers []string) models.ReviewersPR {
+	return models.ReviewersPR{
+		PRID:               pr.ID,
+		PRName:             pr.Name,
+		AuthorID:           pr.AuthorID,
+		Status:             pr.Status,
+		PRMetadata:         pr.PRMetadata,
+		Reviewers:          reviewers,
+		FallbackReviewers:  pr.FallbackReviewers,
+		RequiredReviewers:  pr.RequiredReviewers,
+		CodeownerReviewers: pr.CodeownerReviewers,
+		AssignmentStatus:   assignmentStatus(pr),
+		PendingReviewers:   pr.PendingReviewers,
+	}
+}
+
+func (h *HandlersRepo) AddReviewer(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	var req models.ReviewerRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "BAD_REQUEST", "invalid json body of request", http.StatusBadRequest)
+		return
+	}
+
+	_, err := h.db.GetUserByID(ctx, req.ReviewerID)
+	if err == sql.ErrNoRows {
+		writeError(w, "USER_NOT_FOUND", fmt.Sprintf("there is no user with id=%s", req.ReviewerID), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in get user in handler /pullRequest/addReviewer: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	pr, reviewers, err := h.db.AddReviewerInTransaction(ctx, req.PRID, req.ReviewerID)
+	if writeReviewerChangeError(w, err, req, "/pullRequest/addReviewer") {
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(models.ReviewerResponse{PR: reviewersPR(pr, reviewers)})
+}
+
+func (h *HandlersRepo) RemoveReviewer(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	var req models.ReviewerRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "BAD_REQUEST", "invalid json body of request", http.StatusBadRequest)
+		return
+	}
+
+	pr, reviewers, err := h.db.RemoveReviewerInTransaction(ctx, req.PRID, req.ReviewerID)
+	if writeReviewerChangeError(w, err, req, "/pullRequest/removeReviewer") {
+		return
+	}
+	h.notifyCapacityFreed()
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(models.ReviewerResponse{PR: reviewersPR(pr, reviewers)})
+}
+
+// writeReviewerChangeError writes the response for an error of adding or
+// removing a reviewer, and returns false if there is none.
+func writeReviewerChangeError(w http.ResponseWriter, err error, req models.ReviewerRequest, path string) bool {
+	if err == nil {
+		return false
+	}
+	if err == sql.ErrNoRows {
+		writeError(w, "PR_NOT_FOUND", fmt.Sprintf("there is no pull request with id=%s", req.PRID), http.StatusNotFound)
+		return true
+	}
+	if err == models.ErrPRMerged {
+		writeError(w, "PR_MERGED", "cannot change reviewers of merged PR", http.StatusConflict)
+		return true
+	}
+	if err == models.ErrPRClosed {
+		writeError(w, "PR_CLOSED", "cannot change reviewers of closed PR", http.StatusConflict)
+		return true
+	}
+	if err == models.ErrNotAssigned {
+		writeError(w, "NOT_ASSIGNED", fmt.Sprintf("reviewer with id=%s is not assigned to PR with id=%s", req.ReviewerID, req.PRID), http.StatusConflict)
+		return true
+	}
+	if writePolicyError(w, err) || writeChosenReviewerError(w, err, req.ReviewerID) {
+		return true
+	}
+	log.Printf("error in change reviewers in handler %s: %v", path, err)
+	writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+	return true
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+)
+
+// parseListLimit returns the limit query parameter, defaultListLimit if it is
+// empty.
+func parseListLimit(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("limit")
+	if v == "" {
+		return defaultListLimit, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 || n > maxListLimit {
+		return 0, fmt.Errorf("limit must be between 1 and %d", maxListLimit)
+	}
+	return n, nil
+}
+
+// parseListOrder reports whether the order query parameter asks for a
+// descending listing, defaultDesc if it is empty.
+func parseListOrder(r *http.Request, defaultDesc bool) (bool, error) {
+	switch r.URL.Query().Get("order") {
+	case "":
+		return defaultDesc, nil
+	case "asc":
+		return false, nil
+	case "desc":
+		return true, nil
+	}
+	return false, errors.New("order must be asc or desc")
+}
+
+func encodeListCursor(c models.ListCursor) string {
+	body, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(body)
+}
+
+// decodeListCursor returns the cursor, or false if it was not issued for a
+// listing with this sort and order.
+func decodeListCursor(s, sort string, desc bool) (models.ListCursor, bool) {
+	body, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return models.ListCursor{}, false
+	}
+	var c models.ListCursor
+	if err := json.Unmarshal(body, &c); err != nil || c.Sort != sort || c.Desc != desc || c.ID == "" {
+		return models.ListCursor{}, false
+	}
+	return c, true
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+func (h *HandlersRepo) SetPairRule(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	var req models.SetPairRuleRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "BAD_REQUEST", "invalid json body of request", http.StatusBadRequest)
+		return
+	}
+	if req.ReviewerID == "" || req.AuthorID == "" {
+		writeError(w, "BAD_REQUEST", "reviewer_id and author_id are required", http.StatusBadRequest)
+		return
+	}
+	if req.ReviewerID == req.AuthorID {
+		writeError(w, "BAD_REQUEST", "reviewer_id and author_id must be different users", http.StatusBadRequest)
+		return
+	}
+	if !req.Kind.Valid() {
+		writeError(w, "BAD_REQUEST", fmt.Sprintf("kind must be %s or %s", models.PairRuleNever, models.PairRulePrefer), http.StatusBadRequest)
+		return
+	}
+
+	rule, err := h.db.SetPairRule(ctx, req.ReviewerID, req.AuthorID, req.Kind)
+	if err == sql.ErrNoRows {
+		writeError(w, "USER_NOT_FOUND", fmt.Sprintf("there is no user with id=%s or id=%s", req.ReviewerID, req.AuthorID), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in set pair rule in handler /pairRules/set: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(rule)
+}
+
+func (h *HandlersRepo) DeletePairRule(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	var req models.DeletePairRuleRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "BAD_REQUEST", "invalid json body of request", http.StatusBadRequest)
+		return
+	}
+
+	err := h.db.DeletePairRule(ctx, req.ReviewerID, req.AuthorID)
+	if err == sql.ErrNoRows {
+		writeError(w, "RULE_NOT_FOUND", fmt.Sprintf("there is no rule for reviewer %s and author %s", req.ReviewerID, req.AuthorID), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in delete pair rule in handler /pairRules/delete: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.PairRule{
+		ReviewerID: req.ReviewerID,
+		AuthorID:   req.AuthorID,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// GetPairRules lists the rules of the user given by user_id, as reviewer or
+// author, or all rules without it.
+func (h *HandlersRepo) GetPairRules(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	rules, err := h.db.GetPairRules(ctx, r.URL.Query().Get("user_id"))
+	if err != nil {
+		log.Printf("error in get pair rules in handler /pairRules/list: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(models.GetPairRulesResponse{Rules: rules})
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+// prCursorValue returns the value of the PR that the listing is sorted by, as
+// stored in cursors.
+func prCursorValue(pr models.PRSummary, sort models.PRSort) string {
+	switch sort {
+	case models.PRSortName:
+		return pr.PRName
+	case models.PRSortSize:
+		return strconv.Itoa(pr.LinesAdded + pr.LinesRemoved)
+	default:
+		return pr.CreatedAt.Format(time.RFC3339Nano)
+	}
+}
+
+// parseTimeParam parses the optional RFC 3339 query parameter into *dst.
+func parseTimeParam(r *http.Request, name string, dst **time.Time) error {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return fmt.Errorf("invalid query parameter %s, want RFC 3339 time", name)
+	}
+	*dst = &t
+	return nil
+}
+
+func (h *HandlersRepo) ListPRs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	f := models.PRListFilter{
+		Status:     models.PRStatus(q.Get("status")),
+		AuthorID:   q.Get("author_id"),
+		ReviewerID: q.Get("reviewer_id"),
+		Name:       q.Get("name"),
+		Sort:       models.PRSort(q.Get("sort")),
+	}
+	if f.Status != "" && !f.Status.Valid() {
+		writeError(w, "BAD_REQUEST", "status must be one of OPEN, MERGED, CLOSED", http.StatusBadRequest)
+		return
+	}
+	if f.Sort == "" {
+		f.Sort = models.PRSortCreatedAt
+	}
+	if !f.Sort.Valid() {
+		writeError(w, "BAD_REQUEST", "sort must be one of created_at, name, size", http.StatusBadRequest)
+		return
+	}
+	desc, err := parseListOrder(r, f.Sort != models.PRSortName)
+	if err != nil {
+		writeError(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.Desc = desc
+	limit, err := parseListLimit(r)
+	if err != nil {
+		writeError(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.Limit = limit
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{
+		{"created_from", &f.CreatedFrom},
+		{"created_to", &f.CreatedTo},
+		{"merged_from", &f.MergedFrom},
+		{"merged_to", &f.MergedTo},
+	} {
+		if err := parseTimeParam(r, p.name, p.dst); err != nil {
+			writeError(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if v := q.Get("label"); v != "" {
+		labels, err := normalizeLabels([]string{v})
+		if err != nil {
+			writeError(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.Label = labels[0]
+	}
+	if v := q.Get("cursor"); v != "" {
+		c, ok := decodeListCursor(v, string(f.Sort), f.Desc)
+		if ok && f.Sort == models.PRSortSize {
+			_, err := strconv.Atoi(c.Value)
+			ok = err == nil
+		}
+		if ok && f.Sort == models.PRSortCreatedAt {
+			_, err := time.Parse(time.RFC3339Nano, c.Value)
+			ok = err == nil
+		}
+		if !ok {
+			writeError(w, "INVALID_CURSOR", "cursor is malformed or was issued for another sort or order", http.StatusBadRequest)
+			return
+		}
+		f.After = &c
+	}
+
+	if teamName := q.Get("team_name"); teamName != "" {
+		team, err := h.db.GetTeamByName(ctx, teamName)
+		if err == sql.ErrNoRows {
+			writeError(w, "TEAM_NOT_FOUND", fmt.Sprintf("there is no team with name: %s", teamName), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("error in get team in handler /pullRequest/list: %v", err)
+			writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+			return
+		}
+		f.TeamID = team.ID
+	}
+
+	f.Limit++
+	prs, err := h.db.ListPRs(ctx, f)
+	if err != nil {
+		log.Printf("error in list prs in handler /pullRequest/list: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.ListPRsResponse{
+		PRs: prs,
+	}
+	if len(prs) > limit {
+		resp.PRs = prs[:limit]
+		last := resp.PRs[limit-1]
+		resp.NextCursor = encodeListCursor(models.ListCursor{Sort: string(f.Sort), Desc: f.Desc, Value: prCursorValue(last, f.Sort), ID: last.PRID})
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *HandlersRepo) GetPR(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	pRID := r.URL.Query().Get("pull_request_id")
+
+	pr, err := h.db.GetPRDetails(r.Context(), pRID)
+	if err == sql.ErrNoRows {
+		writeError(w, "PR_NOT_FOUND", fmt.Sprintf("there is no pull request with id=%s", pRID), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in get pr in handler /pullRequest/get: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+	if pr.PendingReviewers > 0 {
+		pr.AssignmentStatus = models.AssignmentPending
+	}
+
+	resp := models.GetPRResponse{
+		PR: pr,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
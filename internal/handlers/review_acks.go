@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+func (h *HandlersRepo) AckReview(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	var req models.AckReviewRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "BAD_REQUEST", "invalid json body of request", http.StatusBadRequest)
+		return
+	}
+	if !req.Decision.Valid() {
+		writeError(w, "BAD_REQUEST", "decision must be accept or decline", http.StatusBadRequest)
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Decision == models.AckDecline && req.Reason == "" {
+		writeError(w, "BAD_REQUEST", "reason is required to decline", http.StatusBadRequest)
+		return
+	}
+
+	resp := models.AckReviewResponse{Decision: req.Decision}
+	var (
+		pr        models.PullRequest
+		reviewers []string
+		err       error
+	)
+	if req.Decision == models.AckAccept {
+		var acceptedAt time.Time
+		pr, reviewers, acceptedAt, err = h.db.AcceptReviewInTransaction(ctx, req.PRID, req.ReviewerID)
+		resp.AcceptedAt = &acceptedAt
+	} else {
+		pr, reviewers, resp.ReplacedBy, err = h.db.DeclineReviewInTransaction(ctx, req.PRID, req.ReviewerID, req.Reason)
+	}
+	if err == sql.ErrNoRows {
+		writeError(w, "PR_NOT_FOUND", fmt.Sprintf("there is no pull request with id=%s", req.PRID), http.StatusNotFound)
+		return
+	}
+	if err == models.ErrPRMerged {
+		writeError(w, "PR_MERGED", "cannot acknowledge review of merged PR", http.StatusConflict)
+		return
+	}
+	if err == models.ErrPRClosed {
+		writeError(w, "PR_CLOSED", "cannot acknowledge review of closed PR", http.StatusConflict)
+		return
+	}
+	if err == models.ErrNotAssigned {
+		writeError(w, "NOT_ASSIGNED", fmt.Sprintf("reviewer with id=%s is not assigned to PR with id=%s", req.ReviewerID, req.PRID), http.StatusConflict)
+		return
+	}
+	if err == models.ErrNoCandidate {
+		writeError(w, "NO_CANDIDATE", "no active replacement candidate in team", http.StatusConflict)
+		return
+	}
+	if writePolicyError(w, err) {
+		return
+	}
+	if err != nil {
+		log.Printf("error in acknowledge review in handler /pullRequest/ack: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+	if req.Decision == models.AckDecline {
+		h.notifyCapacityFreed()
+	}
+
+	resp.PR = reviewersPR(pr, reviewers)
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
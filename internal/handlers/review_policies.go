@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+// writePolicyError responds with the rule of the team's review policy that err
+// reports as broken, and returns false if err is not a policy error.
+func writePolicyError(w http.ResponseWriter, err error) bool {
+	switch err {
+	case models.ErrPolicyMinSeniors:
+		writeError(w, "POLICY_MIN_SENIORS", "not enough senior reviewers available to satisfy the team review policy", http.StatusConflict)
+	case models.ErrPolicySoleJunior:
+		writeError(w, "POLICY_SOLE_JUNIOR", "no mid or senior reviewer available beside a junior as the team review policy requires", http.StatusConflict)
+	default:
+		return false
+	}
+	return true
+}
+
+func (h *HandlersRepo) SetSeniority(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	var req models.SetSeniorityRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "BAD_REQUEST", "invalid json body of request", http.StatusBadRequest)
+		return
+	}
+	if !req.Seniority.Valid() {
+		writeError(w, "BAD_REQUEST", "seniority must be one of junior, mid, senior, lead, bot", http.StatusBadRequest)
+		return
+	}
+
+	err := h.db.SetSeniority(ctx, req.UserID, req.Seniority)
+	if err == sql.ErrNoRows {
+		writeError(w, "USER_NOT_FOUND", fmt.Sprintf("there is no user with id=%s", req.UserID), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in set seniority in handler /users/setSeniority: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.SetSeniorityResponse{
+		UserID:    req.UserID,
+		Seniority: req.Seniority,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *HandlersRepo) SetReviewPolicy(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	var req models.SetReviewPolicyRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "BAD_REQUEST", "invalid json body of request", http.StatusBadRequest)
+		return
+	}
+	if req.MinSeniors < 0 {
+		writeError(w, "BAD_REQUEST", "min_seniors must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	team, err := h.db.GetTeamByName(ctx, req.TeamName)
+	if err == sql.ErrNoRows {
+		writeError(w, "TEAM_NOT_FOUND", fmt.Sprintf("there is no team with name: %s", req.TeamName), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in get team in handler /team/setReviewPolicy: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.db.SetReviewPolicy(ctx, team.ID, req.ReviewPolicy); err != nil {
+		log.Printf("error in set review policy in handler /team/setReviewPolicy: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.ReviewPolicyResponse{
+		TeamName:     team.Name,
+		ReviewPolicy: req.ReviewPolicy,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *HandlersRepo) GetReviewPolicy(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	teamName := r.URL.Query().Get("team_name")
+
+	team, err := h.db.GetTeamByName(ctx, teamName)
+	if err == sql.ErrNoRows {
+		writeError(w, "TEAM_NOT_FOUND", fmt.Sprintf("there is no team with name: %s", teamName), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in get team in handler /team/getReviewPolicy: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	policy, err := h.db.GetReviewPolicy(ctx, team.ID)
+	if err != nil {
+		log.Printf("error in get review policy in handler /team/getReviewPolicy: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.ReviewPolicyResponse{
+		TeamName:     team.Name,
+		ReviewPolicy: policy,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+const (
+	defaultEventsLimit = 100
+	maxEventsLimit     = 1000
+)
+
+// assignmentStatus marks PRs that wait in the review queue.
+func assignmentStatus(pr models.PullRequest) string {
+	if pr.PendingReviewers > 0 {
+		return models.AssignmentPending
+	}
+	return ""
+}
+
+func (h *HandlersRepo) ClosePR(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	var req models.ClosePRRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "BAD_REQUEST", "invalid json body of request", http.StatusBadRequest)
+		return
+	}
+
+	pr, reviewers, err := h.db.ClosePRInTransaction(ctx, req.PRID)
+	if err == sql.ErrNoRows {
+		writeError(w, "PR_NOT_FOUND", fmt.Sprintf("there is no pull request with id=%s", req.PRID), http.StatusNotFound)
+		return
+	}
+	if err == models.ErrPRMerged {
+		writeError(w, "PR_MERGED", "cannot close merged PR", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("error in close pr in handler /pullRequest/close: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+	h.notifyCapacityFreed()
+
+	var resp models.ClosePRResponse
+	resp.PR.PRID, resp.PR.PRName, resp.PR.AuthorID, resp.PR.Status = pr.ID, pr.Name, pr.AuthorID, pr.Status
+	resp.PR.Reviewers, resp.PR.ClosedAt, resp.PR.PRMetadata = reviewers, *pr.ClosedAt, pr.PRMetadata
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *HandlersRepo) SetUserCapacity(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	var req models.SetUserCapacityRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "BAD_REQUEST", "invalid json body of request", http.StatusBadRequest)
+		return
+	}
+	if req.MaxOpenReviews != nil && *req.MaxOpenReviews < 1 {
+		writeError(w, "BAD_REQUEST", "max_open_reviews must be at least 1", http.StatusBadRequest)
+		return
+	}
+
+	err := h.db.SetUserCapacity(ctx, req.UserID, req.MaxOpenReviews)
+	if err == sql.ErrNoRows {
+		writeError(w, "USER_NOT_FOUND", fmt.Sprintf("there is no user with id=%s", req.UserID), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in set capacity in handler /users/setCapacity: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+	h.notifyCapacityFreed()
+
+	resp := models.SetUserCapacityResponse{
+		UserID:         req.UserID,
+		MaxOpenReviews: req.MaxOpenReviews,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *HandlersRepo) SetTeamCapacity(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	var req models.SetTeamCapacityRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "BAD_REQUEST", "invalid json body of request", http.StatusBadRequest)
+		return
+	}
+	if req.MaxOpenReviews != nil && *req.MaxOpenReviews < 1 {
+		writeError(w, "BAD_REQUEST", "max_open_reviews must be at least 1", http.StatusBadRequest)
+		return
+	}
+
+	team, err := h.db.GetTeamByName(ctx, req.TeamName)
+	if err == sql.ErrNoRows {
+		writeError(w, "TEAM_NOT_FOUND", fmt.Sprintf("there is no team with name: %s", req.TeamName), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in get team in handler /team/setCapacity: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.db.SetTeamCapacity(ctx, team.ID, req.MaxOpenReviews); err != nil {
+		log.Printf("error in set capacity in handler /team/setCapacity: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+	h.notifyCapacityFreed()
+
+	resp := models.SetTeamCapacityResponse{
+		TeamName:       team.Name,
+		MaxOpenReviews: req.MaxOpenReviews,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *HandlersRepo) GetEvents(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	var afterID int64
+	if v := r.URL.Query().Get("after_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id < 0 {
+			writeError(w, "BAD_REQUEST", "invalid query parameter after_id", http.StatusBadRequest)
+			return
+		}
+		afterID = id
+	}
+
+	limit := defaultEventsLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxEventsLimit {
+			writeError(w, "BAD_REQUEST", fmt.Sprintf("limit must be between 1 and %d", maxEventsLimit), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	events, err := h.db.GetEvents(ctx, afterID, limit)
+	if err != nil {
+		log.Printf("error in get events in handler /events: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.GetEventsResponse{
+		Events: events,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
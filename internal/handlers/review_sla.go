@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+// validateSLAPolicy returns why the policy cannot be set, or an empty string.
+func validateSLAPolicy(policy models.SLAPolicy) string {
+	ladder := []int{policy.RemindAfterMinutes, policy.ReassignAfterMinutes, policy.NotifyLeadAfterMinutes}
+	if policy.ResponseMinutes < 0 {
+		return "response_minutes must not be negative"
+	}
+
+	last := 0
+	for _, minutes := range ladder {
+		if minutes < 0 {
+			return "escalation thresholds must not be negative"
+		}
+		if minutes == 0 {
+			continue
+		}
+		if policy.ResponseMinutes == 0 {
+			return "escalation needs response_minutes"
+		}
+		if minutes < last {
+			return "escalation thresholds must not decrease from remind to reassign to notify_lead"
+		}
+		last = minutes
+	}
+	return ""
+}
+
+func (h *HandlersRepo) SetSLAPolicy(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	var req models.SetSLAPolicyRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "BAD_REQUEST", "invalid json body of request", http.StatusBadRequest)
+		return
+	}
+	if msg := validateSLAPolicy(req.SLAPolicy); msg != "" {
+		writeError(w, "BAD_REQUEST", msg, http.StatusBadRequest)
+		return
+	}
+
+	team, err := h.db.GetTeamByName(ctx, req.TeamName)
+	if err == sql.ErrNoRows {
+		writeError(w, "TEAM_NOT_FOUND", fmt.Sprintf("there is no team with name: %s", req.TeamName), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in get team in handler /team/setSLA: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.db.SetSLAPolicy(ctx, team.ID, req.SLAPolicy); err != nil {
+		log.Printf("error in set sla policy in handler /team/setSLA: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.SLAPolicyResponse{
+		TeamName:  team.Name,
+		SLAPolicy: req.SLAPolicy,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *HandlersRepo) GetSLAPolicy(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	teamName := r.URL.Query().Get("team_name")
+
+	team, err := h.db.GetTeamByName(ctx, teamName)
+	if err == sql.ErrNoRows {
+		writeError(w, "TEAM_NOT_FOUND", fmt.Sprintf("there is no team with name: %s", teamName), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in get team in handler /team/getSLA: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	policy, err := h.db.GetSLAPolicy(ctx, team.ID)
+	if err != nil {
+		log.Printf("error in get sla policy in handler /team/getSLA: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.SLAPolicyResponse{
+		TeamName:  team.Name,
+		SLAPolicy: policy,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *HandlersRepo) GetSLABreaches(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	var teamID int64
+	if teamName := r.URL.Query().Get("team_name"); teamName != "" {
+		team, err := h.db.GetTeamByName(ctx, teamName)
+		if err == sql.ErrNoRows {
+			writeError(w, "TEAM_NOT_FOUND", fmt.Sprintf("there is no team with name: %s", teamName), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("error in get team in handler /sla/breaches: %v", err)
+			writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+			return
+		}
+		teamID = team.ID
+	}
+
+	breaches, err := h.db.GetSLABreaches(ctx, teamID)
+	if err != nil {
+		log.Printf("error in get sla breaches in handler /sla/breaches: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(models.GetSLABreachesResponse{Breaches: breaches})
+}
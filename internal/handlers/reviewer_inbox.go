@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+// inboxCursorSort is the sort inbox cursors are issued for: the score, highest
+// first.
+const inboxCursorSort = "score"
+
+func (h *HandlersRepo) GetInbox(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	userID := q.Get("user_id")
+	if userID == "" {
+		writeError(w, "BAD_REQUEST", "empty query parameter user_id", http.StatusBadRequest)
+		return
+	}
+
+	f := models.InboxFilter{UserID: userID}
+	if v := q.Get("include_merged"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			writeError(w, "BAD_REQUEST", "invalid query parameter include_merged", http.StatusBadRequest)
+			return
+		}
+		f.IncludeMerged = b
+	}
+	limit, err := parseListLimit(r)
+	if err != nil {
+		writeError(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.Limit = limit
+	if v := q.Get("cursor"); v != "" {
+		c, ok := decodeListCursor(v, inboxCursorSort, false)
+		if ok {
+			status, rankAt, found := strings.Cut(c.Value, " ")
+			_, err := time.Parse(time.RFC3339Nano, rankAt)
+			ok = found && err == nil && (status == string(models.PRStatusOpen) || status == string(models.PRStatusMerged))
+		}
+		if !ok {
+			writeError(w, "INVALID_CURSOR", "cursor is malformed or was issued for another listing", http.StatusBadRequest)
+			return
+		}
+		f.After = &c
+	}
+
+	_, err = h.db.GetUserByID(ctx, userID)
+	if err == sql.ErrNoRows {
+		writeError(w, "USER_NOT_FOUND", fmt.Sprintf("there is no user with id=%s", userID), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in get user in handler /users/inbox: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	f.Limit++
+	items, err := h.db.GetInbox(ctx, f)
+	if err != nil {
+		log.Printf("error in get inbox in handler /users/inbox: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.GetInboxResponse{
+		UserID: userID,
+		Items:  items,
+	}
+	if len(items) > limit {
+		resp.Items = items[:limit]
+		last := resp.Items[limit-1]
+		value := string(last.Status) + " " + last.RankAt.Format(time.RFC3339Nano)
+		resp.NextCursor = encodeListCursor(models.ListCursor{Sort: inboxCursorSort, Value: value, ID: last.PRID})
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *HandlersRepo) GetInboxCount(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		writeError(w, "BAD_REQUEST", "empty query parameter user_id", http.StatusBadRequest)
+		return
+	}
+
+	_, err := h.db.GetUserByID(ctx, userID)
+	if err == sql.ErrNoRows {
+		writeError(w, "USER_NOT_FOUND", fmt.Sprintf("there is no user with id=%s", userID), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in get user in handler /users/inboxCount: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	count, err := h.db.GetInboxCount(ctx, userID)
+	if err != nil {
+		log.Printf("error in get inbox count in handler /users/inboxCount: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.GetInboxCountResponse{
+		UserID:     userID,
+		InboxCount: count,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+func (h *HandlersRepo) SetUserSkills(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	var req models.SetUserSkillsRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "BAD_REQUEST", "invalid json body of request", http.StatusBadRequest)
+		return
+	}
+	if req.UserID == "" {
+		writeError(w, "BAD_REQUEST", "empty user_id", http.StatusBadRequest)
+		return
+	}
+	skills, err := normalizeTags("skill", req.Skills)
+	if err != nil {
+		writeError(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = h.db.SetUserSkills(ctx, req.UserID, skills)
+	if err == sql.ErrNoRows {
+		writeError(w, "USER_NOT_FOUND", fmt.Sprintf("there is no user with id=%s", req.UserID), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in set skills in handler /users/setSkills: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.UserSkillsResponse{
+		UserID: req.UserID,
+		Skills: skills,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *HandlersRepo) GetUserSkills(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		writeError(w, "BAD_REQUEST", "empty query parameter user_id", http.StatusBadRequest)
+		return
+	}
+
+	_, err := h.db.GetUserByID(ctx, userID)
+	if err == sql.ErrNoRows {
+		writeError(w, "USER_NOT_FOUND", fmt.Sprintf("there is no user with id=%s", userID), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in get user in handler /users/getSkills: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	skills, err := h.db.GetUserSkills(ctx, userID)
+	if err != nil {
+		log.Printf("error in get skills in handler /users/getSkills: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.UserSkillsResponse{
+		UserID: userID,
+		Skills: skills,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+// statsFilter reads the window, grouping, team and user of the /stats
+// endpoints, writing the error response if they are not valid.
+func (h *HandlersRepo) statsFilter(w http.ResponseWriter, r *http.Request, path string) (models.StatsFilter, bool) {
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	f := models.StatsFilter{
+		UserID:  q.Get("user_id"),
+		GroupBy: models.StatsGroupBy(q.Get("group_by")),
+	}
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{
+		{"from", &f.From},
+		{"to", &f.To},
+	} {
+		if err := parseTimeParam(r, p.name, p.dst); err != nil {
+			writeError(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+			return models.StatsFilter{}, false
+		}
+	}
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		writeError(w, "BAD_REQUEST", "from must be before to", http.StatusBadRequest)
+		return models.StatsFilter{}, false
+	}
+	if f.GroupBy != "" && !f.GroupBy.Valid() {
+		writeError(w, "BAD_REQUEST", "group_by must be one of day, week, month", http.StatusBadRequest)
+		return models.StatsFilter{}, false
+	}
+
+	if teamName := q.Get("team_name"); teamName != "" {
+		team, err := h.db.GetTeamByName(ctx, teamName)
+		if err == sql.ErrNoRows {
+			writeError(w, "TEAM_NOT_FOUND", fmt.Sprintf("there is no team with name: %s", teamName), http.StatusNotFound)
+			return models.StatsFilter{}, false
+		}
+		if err != nil {
+			log.Printf("error in get team in handler %s: %v", path, err)
+			writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+			return models.StatsFilter{}, false
+		}
+		f.TeamID = team.ID
+	}
+	if f.UserID != "" {
+		_, err := h.db.GetUserByID(ctx, f.UserID)
+		if err == sql.ErrNoRows {
+			writeError(w, "USER_NOT_FOUND", fmt.Sprintf("there is no user with id=%s", f.UserID), http.StatusNotFound)
+			return models.StatsFilter{}, false
+		}
+		if err != nil {
+			log.Printf("error in get user in handler %s: %v", path, err)
+			writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+			return models.StatsFilter{}, false
+		}
+	}
+
+	return f, true
+}
+
+// totals is the filter without grouping, for the totals over the window that
+// come with the buckets.
+func totals(f models.StatsFilter) models.StatsFilter {
+	f.GroupBy = ""
+	return f
+}
+
+func (h *HandlersRepo) GetStatsByUsers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	includeArchived, err := parseIncludeArchived(r)
+	if err != nil {
+		writeError(w, "BAD_REQUEST", "invalid query parameter include_archived", http.StatusBadRequest)
+		return
+	}
+	f, ok := h.statsFilter(w, r, "/stats/users")
+	if !ok {
+		return
+	}
+	f.IncludeArchived = includeArchived
+
+	stats, err := h.db.GetCountPRStatsByUser(ctx, totals(f))
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("error in get stats in handler /stats/users: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	var resp models.GetStatsUsersResponse
+	resp.PRStats = stats
+
+	if f.GroupBy != "" {
+		rows, err := h.db.GetCountPRStatsByUser(ctx, f)
+		if err != nil {
+			log.Printf("error in get stats buckets in handler /stats/users: %v", err)
+			writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+			return
+		}
+		for _, s := range rows {
+			if n := len(resp.Buckets); n == 0 || !resp.Buckets[n-1].Start.Equal(*s.Bucket) {
+				resp.Buckets = append(resp.Buckets, models.UserStatsBucket{Start: s.Bucket.UTC()})
+			}
+			b := &resp.Buckets[len(resp.Buckets)-1]
+			b.PRStats = append(b.PRStats, s)
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *HandlersRepo) GetStatsByTeams(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	includeArchived, err := parseIncludeArchived(r)
+	if err != nil {
+		writeError(w, "BAD_REQUEST", "invalid query parameter include_archived", http.StatusBadRequest)
+		return
+	}
+	f, ok := h.statsFilter(w, r, "/stats/teams")
+	if !ok {
+		return
+	}
+	f.IncludeArchived = includeArchived
+
+	stats, err := h.db.GetCountPRStatsByTeam(ctx, totals(f))
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("error in get stats in handler /stats/teams: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	var resp models.GetStatsTeamsResponse
+	resp.PRStats = stats
+
+	if f.GroupBy != "" {
+		rows, err := h.db.GetCountPRStatsByTeam(ctx, f)
+		if err != nil {
+			log.Printf("error in get stats buckets in handler /stats/teams: %v", err)
+			writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+			return
+		}
+		for _, s := range rows {
+			if n := len(resp.Buckets); n == 0 || !resp.Buckets[n-1].Start.Equal(*s.Bucket) {
+				resp.Buckets = append(resp.Buckets, models.TeamStatsBucket{Start: s.Bucket.UTC()})
+			}
+			b := &resp.Buckets[len(resp.Buckets)-1]
+			b.PRStats = append(b.PRStats, s)
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *HandlersRepo) GetStatsByPRs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	f, ok := h.statsFilter(w, r, "/stats/pullRequests")
+	if !ok {
+		return
+	}
+
+	stats, err := h.db.GetCountReviewerStatsByPR(ctx, totals(f))
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("error in get stats in handler /stats/pullRequests: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.GetStatsPRsResponse{
+		PRStats: make(map[string]int64, len(stats)),
+	}
+	for _, s := range stats {
+		resp.PRStats[s.PRID] = s.Count
+	}
+
+	if f.GroupBy != "" {
+		rows, err := h.db.GetCountReviewerStatsByPR(ctx, f)
+		if err != nil {
+			log.Printf("error in get stats buckets in handler /stats/pullRequests: %v", err)
+			writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+			return
+		}
+		for _, s := range rows {
+			if n := len(resp.Buckets); n == 0 || !resp.Buckets[n-1].Start.Equal(*s.Bucket) {
+				resp.Buckets = append(resp.Buckets, models.PRStatsBucket{Start: s.Bucket.UTC(), PRStats: make(map[string]int64)})
+			}
+			resp.Buckets[len(resp.Buckets)-1].PRStats[s.PRID] = s.Count
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *HandlersRepo) GetStatsByDeclines(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	f, ok := h.statsFilter(w, r, "/stats/declines")
+	if !ok {
+		return
+	}
+
+	stats, err := h.db.GetDeclineStats(ctx, totals(f))
+	if err != nil {
+		log.Printf("error in get decline stats in handler /stats/declines: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.GetStatsDeclinesResponse{
+		DeclinedCount: stats[0].DeclinedCount,
+		ExpiredCount:  stats[0].ExpiredCount,
+		Reasons:       stats[0].Reasons,
+	}
+
+	if f.GroupBy != "" {
+		buckets, err := h.db.GetDeclineStats(ctx, f)
+		if err != nil {
+			log.Printf("error in get decline stats buckets in handler /stats/declines: %v", err)
+			writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+			return
+		}
+		for _, s := range buckets {
+			resp.Buckets = append(resp.Buckets, models.DeclineStatsBucket{
+				Start:         s.Bucket.UTC(),
+				DeclinedCount: s.DeclinedCount,
+				ExpiredCount:  s.ExpiredCount,
+				Reasons:       s.Reasons,
+			})
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
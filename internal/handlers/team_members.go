@@ -0,0 +1,340 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+func (h *HandlersRepo) AddTeamMembers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	var req models.AddTeamMembersRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "BAD_REQUEST", "invalid json body of request", http.StatusBadRequest)
+		return
+	}
+	if len(req.Members) == 0 {
+		writeError(w, "BAD_REQUEST", "empty list of members", http.StatusBadRequest)
+		return
+	}
+
+	team, err := h.db.GetTeamByName(ctx, req.TeamName)
+	if err == sql.ErrNoRows {
+		writeError(w, "TEAM_NOT_FOUND", fmt.Sprintf("there is no team with name: %s", req.TeamName), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in get team in handler /team/addMembers: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+	if team.ArchivedAt != nil {
+		writeError(w, "TEAM_ARCHIVED", fmt.Sprintf("team %s is archived", team.Name), http.StatusConflict)
+		return
+	}
+
+	members := make([]models.Member, 0, len(req.Members))
+	seen := make(map[string]struct{}, len(req.Members))
+	for _, m := range req.Members {
+		if m.UserID == "" {
+			writeError(w, "BAD_REQUEST", "empty user_id in members", http.StatusBadRequest)
+			return
+		}
+		if _, ok := seen[m.UserID]; ok {
+			writeError(w, "BAD_REQUEST", fmt.Sprintf("user with id=%s is listed twice", m.UserID), http.StatusBadRequest)
+			return
+		}
+		seen[m.UserID] = struct{}{}
+		if !validMemberRole(m.Role) {
+			writeError(w, "BAD_REQUEST", fmt.Sprintf("unknown role %s of user with id=%s", m.Role, m.UserID), http.StatusBadRequest)
+			return
+		}
+
+		members = append(members, models.Member{
+			ID:        m.UserID,
+			Name:      m.Name,
+			IsActive:  m.IsActive,
+			Role:      m.Role,
+			IsPrimary: m.IsPrimary,
+		})
+	}
+
+	err = h.db.AddMembersToTeamInTransaction(ctx, team.ID, members)
+	if err == models.ErrUserExists {
+		writeError(w, "USER_EXISTS", fmt.Sprintf("one of the users is already a member of team %s", team.Name), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("error in apply transaction to add members in handler /team/addMembers: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	var resp models.AddTeamMembersResponse
+	resp.Team.Name = team.Name
+	resp.Team.Members, err = h.db.GetUsersInTeam(ctx, team.ID)
+	if err != nil {
+		log.Printf("error in get users in handler /team/addMembers: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *HandlersRepo) RemoveTeamMember(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	var req models.RemoveTeamMemberRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "BAD_REQUEST", "invalid json body of request", http.StatusBadRequest)
+		return
+	}
+
+	team, err := h.db.GetTeamByName(ctx, req.TeamName)
+	if err == sql.ErrNoRows {
+		writeError(w, "TEAM_NOT_FOUND", fmt.Sprintf("there is no team with name: %s", req.TeamName), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in get team in handler /team/removeMember: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	handovers, err := h.db.RemoveUserFromTeamInTransaction(ctx, req.UserID, team.ID)
+	if err == sql.ErrNoRows {
+		writeError(w, "USER_NOT_FOUND", fmt.Sprintf("there is no user with id=%s", req.UserID), http.StatusNotFound)
+		return
+	}
+	if err == models.ErrNotTeamMember {
+		writeError(w, "NOT_TEAM_MEMBER", fmt.Sprintf("user with id=%s is not a member of team %s", req.UserID, team.Name), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("error in apply transaction to remove member in handler /team/removeMember: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.RemoveTeamMemberResponse{
+		TeamName:  team.Name,
+		UserID:    req.UserID,
+		Handovers: handovers,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *HandlersRepo) MoveUserTeam(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	var req models.MoveUserTeamRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "BAD_REQUEST", "invalid json body of request", http.StatusBadRequest)
+		return
+	}
+
+	_, memberships, err := h.db.GetUserWithTeamByID(ctx, req.UserID)
+	if err == sql.ErrNoRows {
+		writeError(w, "USER_NOT_FOUND", fmt.Sprintf("there is no user with id=%s", req.UserID), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in get user in handler /users/moveTeam: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	team, err := h.db.GetTeamByName(ctx, req.TeamName)
+	if err == sql.ErrNoRows {
+		writeError(w, "TEAM_NOT_FOUND", fmt.Sprintf("there is no team with name: %s", req.TeamName), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in get team in handler /users/moveTeam: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+	if team.ArchivedAt != nil {
+		writeError(w, "TEAM_ARCHIVED", fmt.Sprintf("team %s is archived", team.Name), http.StatusConflict)
+		return
+	}
+
+	var fromTeamID int64
+	if req.FromTeamName != "" {
+		fromTeam, err := h.db.GetTeamByName(ctx, req.FromTeamName)
+		if err == sql.ErrNoRows {
+			writeError(w, "TEAM_NOT_FOUND", fmt.Sprintf("there is no team with name: %s", req.FromTeamName), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("error in get team in handler /users/moveTeam: %v", err)
+			writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+			return
+		}
+		fromTeamID = fromTeam.ID
+	}
+
+	fromTeamID, handovers, err := h.db.MoveUserToTeamInTransaction(ctx, req.UserID, fromTeamID, team.ID)
+	if err == sql.ErrNoRows {
+		writeError(w, "USER_NOT_FOUND", fmt.Sprintf("there is no user with id=%s", req.UserID), http.StatusNotFound)
+		return
+	}
+	if err == models.ErrNotTeamMember {
+		writeError(w, "NOT_TEAM_MEMBER", fmt.Sprintf("user with id=%s is not a member of team %s", req.UserID, req.FromTeamName), http.StatusConflict)
+		return
+	}
+	if err == models.ErrAlreadyInTeam {
+		writeError(w, "ALREADY_IN_TEAM", fmt.Sprintf("user with id=%s is already a member of team %s", req.UserID, team.Name), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("error in apply transaction to move user in handler /users/moveTeam: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.MoveUserTeamResponse{
+		UserID:      req.UserID,
+		OldTeamName: membershipTeamName(memberships, fromTeamID),
+		NewTeamName: team.Name,
+		Handovers:   handovers,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *HandlersRepo) UpdateTeamMember(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	var req models.UpdateTeamMemberRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "BAD_REQUEST", "invalid json body of request", http.StatusBadRequest)
+		return
+	}
+	if !validMemberRole(req.Role) {
+		writeError(w, "BAD_REQUEST", fmt.Sprintf("unknown role %s", req.Role), http.StatusBadRequest)
+		return
+	}
+	if req.IsPrimary != nil && !*req.IsPrimary {
+		writeError(w, "BAD_REQUEST", "primary team can only be changed by making another team primary", http.StatusBadRequest)
+		return
+	}
+
+	team, err := h.db.GetTeamByName(ctx, req.TeamName)
+	if err == sql.ErrNoRows {
+		writeError(w, "TEAM_NOT_FOUND", fmt.Sprintf("there is no team with name: %s", req.TeamName), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in get team in handler /team/updateMember: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	err = h.db.UpdateTeamMember(ctx, team.ID, req.UserID, req.Role, req.IsPrimary != nil)
+	if err == sql.ErrNoRows {
+		writeError(w, "USER_NOT_FOUND", fmt.Sprintf("there is no user with id=%s", req.UserID), http.StatusNotFound)
+		return
+	}
+	if err == models.ErrNotTeamMember {
+		writeError(w, "NOT_TEAM_MEMBER", fmt.Sprintf("user with id=%s is not a member of team %s", req.UserID, team.Name), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("error in update member in handler /team/updateMember: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	members, err := h.db.GetUsersInTeam(ctx, team.ID)
+	if err != nil {
+		log.Printf("error in get users in handler /team/updateMember: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.UpdateTeamMemberResponse{TeamName: team.Name}
+	for _, m := range members {
+		if m.ID == req.UserID {
+			resp.Member = m
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *HandlersRepo) GetUserTeamHistory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		writeError(w, "BAD_REQUEST", "empty query parameter user_id", http.StatusBadRequest)
+		return
+	}
+
+	_, err := h.db.GetUserByID(ctx, userID)
+	if err == sql.ErrNoRows {
+		writeError(w, "USER_NOT_FOUND", fmt.Sprintf("there is no user with id=%s", userID), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in get user in handler /users/teamHistory: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	history, err := h.db.GetUserTeamHistory(ctx, userID)
+	if err != nil {
+		log.Printf("error in get team history in handler /users/teamHistory: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.GetUserTeamHistoryResponse{
+		UserID:  userID,
+		History: history,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// validMemberRole accepts the known roles and an empty one, which means the
+// default role for new members and no change for existing ones.
+func validMemberRole(role models.MemberRole) bool {
+	return role == "" || role == models.MemberRoleMember || role == models.MemberRoleLead
+}
+
+func membershipTeamName(memberships []models.Membership, teamID int64) string {
+	for _, m := range memberships {
+		if m.TeamID == teamID {
+			return m.TeamName
+		}
+	}
+	return ""
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+func (h *HandlersRepo) ListTeams(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	f := models.TeamListFilter{
+		NamePrefix: q.Get("name_prefix"),
+		Sort:       models.TeamSort(q.Get("sort")),
+	}
+	includeArchived, err := parseIncludeArchived(r)
+	if err != nil {
+		writeError(w, "BAD_REQUEST", "invalid query parameter include_archived", http.StatusBadRequest)
+		return
+	}
+	f.IncludeArchived = includeArchived
+	if f.Sort == "" {
+		f.Sort = models.TeamSortName
+	}
+	if !f.Sort.Valid() {
+		writeError(w, "BAD_REQUEST", "sort must be one of name, members", http.StatusBadRequest)
+		return
+	}
+	desc, err := parseListOrder(r, f.Sort == models.TeamSortMembers)
+	if err != nil {
+		writeError(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.Desc = desc
+	limit, err := parseListLimit(r)
+	if err != nil {
+		writeError(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.Limit = limit
+	if v := q.Get("cursor"); v != "" {
+		c, ok := decodeListCursor(v, string(f.Sort), f.Desc)
+		if ok {
+			_, err := strconv.ParseInt(c.ID, 10, 64)
+			ok = err == nil
+		}
+		if ok && f.Sort == models.TeamSortMembers {
+			_, err := strconv.ParseInt(c.Value, 10, 64)
+			ok = err == nil
+		}
+		if !ok {
+			writeError(w, "INVALID_CURSOR", "cursor is malformed or was issued for another sort or order", http.StatusBadRequest)
+			return
+		}
+		f.After = &c
+	}
+
+	f.Limit++
+	teams, err := h.db.ListTeams(ctx, f)
+	if err != nil {
+		log.Printf("error in list teams in handler /team/list: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.ListTeamsResponse{
+		Teams: teams,
+	}
+	if len(teams) > limit {
+		resp.Teams = teams[:limit]
+		last := resp.Teams[limit-1]
+		value := last.TeamName
+		if f.Sort == models.TeamSortMembers {
+			value = strconv.FormatInt(last.MembersCount, 10)
+		}
+		resp.NextCursor = encodeListCursor(models.ListCursor{Sort: string(f.Sort), Desc: f.Desc, Value: value, ID: strconv.FormatInt(last.TeamID, 10)})
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *HandlersRepo) ListUsers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	f := models.UserListFilter{
+		Role:       models.MemberRole(q.Get("role")),
+		Seniority:  models.Seniority(q.Get("seniority")),
+		NamePrefix: q.Get("name_prefix"),
+		Sort:       models.UserSort(q.Get("sort")),
+	}
+	if !validMemberRole(f.Role) {
+		writeError(w, "BAD_REQUEST", "role must be one of member, lead", http.StatusBadRequest)
+		return
+	}
+	if f.Seniority != "" && !f.Seniority.Valid() {
+		writeError(w, "BAD_REQUEST", "seniority must be one of junior, mid, senior, lead, bot", http.StatusBadRequest)
+		return
+	}
+	if v := q.Get("is_active"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			writeError(w, "BAD_REQUEST", "invalid query parameter is_active", http.StatusBadRequest)
+			return
+		}
+		f.IsActive = &b
+	}
+	if f.Sort == "" {
+		f.Sort = models.UserSortID
+	}
+	if !f.Sort.Valid() {
+		writeError(w, "BAD_REQUEST", "sort must be one of user_id, username", http.StatusBadRequest)
+		return
+	}
+	desc, err := parseListOrder(r, false)
+	if err != nil {
+		writeError(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.Desc = desc
+	limit, err := parseListLimit(r)
+	if err != nil {
+		writeError(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.Limit = limit
+	if v := q.Get("cursor"); v != "" {
+		c, ok := decodeListCursor(v, string(f.Sort), f.Desc)
+		if !ok {
+			writeError(w, "INVALID_CURSOR", "cursor is malformed or was issued for another sort or order", http.StatusBadRequest)
+			return
+		}
+		f.After = &c
+	}
+
+	if teamName := q.Get("team_name"); teamName != "" {
+		team, err := h.db.GetTeamByName(ctx, teamName)
+		if err == sql.ErrNoRows {
+			writeError(w, "TEAM_NOT_FOUND", fmt.Sprintf("there is no team with name: %s", teamName), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("error in get team in handler /users/list: %v", err)
+			writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+			return
+		}
+		f.TeamID = team.ID
+	}
+
+	f.Limit++
+	users, err := h.db.ListUsers(ctx, f)
+	if err != nil {
+		log.Printf("error in list users in handler /users/list: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.ListUsersResponse{
+		Users: users,
+	}
+	if len(users) > limit {
+		resp.Users = users[:limit]
+		last := resp.Users[limit-1]
+		value := last.UserID
+		if f.Sort == models.UserSortName {
+			value = last.Username
+		}
+		resp.NextCursor = encodeListCursor(models.ListCursor{Sort: string(f.Sort), Desc: f.Desc, Value: value, ID: last.UserID})
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
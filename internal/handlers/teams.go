@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+func parseIncludeArchived(r *http.Request) (bool, error) {
+	v := r.URL.Query().Get("include_archived")
+	if v == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(v)
+}
+
+func (h *HandlersRepo) RenameTeam(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	var req models.RenameTeamRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "BAD_REQUEST", "invalid json body of request", http.StatusBadRequest)
+		return
+	}
+	if req.NewTeamName == "" {
+		writeError(w, "BAD_REQUEST", "empty new_team_name", http.StatusBadRequest)
+		return
+	}
+
+	team, err := h.db.GetTeamByName(ctx, req.TeamName)
+	if err == sql.ErrNoRows {
+		writeError(w, "TEAM_NOT_FOUND", fmt.Sprintf("there is no team with name: %s", req.TeamName), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in get team in handler /team/rename: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.RenameTeamResponse{
+		OldTeamName: team.Name,
+		TeamName:    req.NewTeamName,
+	}
+
+	if team.Name == req.NewTeamName {
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	err = h.db.RenameTeam(ctx, team.ID, req.NewTeamName)
+	if err == sql.ErrNoRows {
+		writeError(w, "TEAM_NOT_FOUND", fmt.Sprintf("there is no team with name: %s", req.TeamName), http.StatusNotFound)
+		return
+	}
+	if err == models.ErrTeamExists {
+		writeError(w, "TEAM_EXISTS", fmt.Sprintf("team_name %s already exists", req.NewTeamName), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("error in rename team in handler /team/rename: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *HandlersRepo) ArchiveTeam(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	var req models.ArchiveTeamRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "BAD_REQUEST", "invalid json body of request", http.StatusBadRequest)
+		return
+	}
+
+	team, err := h.db.GetTeamByName(ctx, req.TeamName)
+	if err == sql.ErrNoRows {
+		writeError(w, "TEAM_NOT_FOUND", fmt.Sprintf("there is no team with name: %s", req.TeamName), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in get team in handler /team/archive: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	users, err := h.db.ArchiveTeamInTransaction(ctx, team.ID)
+	if err != nil {
+		log.Printf("error in apply transaction to archive team in handler /team/archive: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.ArchiveTeamResponse{
+		TeamName: team.Name,
+		Users:    users,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *HandlersRepo) DeleteTeam(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	var req models.DeleteTeamRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "BAD_REQUEST", "invalid json body of request", http.StatusBadRequest)
+		return
+	}
+
+	team, err := h.db.GetTeamByName(ctx, req.TeamName)
+	if err == sql.ErrNoRows {
+		writeError(w, "TEAM_NOT_FOUND", fmt.Sprintf("there is no team with name: %s", req.TeamName), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in get team in handler /team/delete: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	released, err := h.db.DeleteTeamInTransaction(ctx, team.ID, req.Force)
+	if err == sql.ErrNoRows {
+		writeError(w, "TEAM_NOT_FOUND", fmt.Sprintf("there is no team with name: %s", req.TeamName), http.StatusNotFound)
+		return
+	}
+	if err == models.ErrTeamNotEmpty {
+		writeError(w, "TEAM_NOT_EMPTY", fmt.Sprintf("team %s has members, pass force=true to delete it", team.Name), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("error in apply transaction to delete team in handler /team/delete: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.DeleteTeamResponse{
+		TeamName:        team.Name,
+		ReleasedReviews: released,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *HandlersRepo) SetTeamParent(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	var req models.SetTeamParentRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "BAD_REQUEST", "invalid json body of request", http.StatusBadRequest)
+		return
+	}
+
+	team, err := h.db.GetTeamByName(ctx, req.TeamName)
+	if err == sql.ErrNoRows {
+		writeError(w, "TEAM_NOT_FOUND", fmt.Sprintf("there is no team with name: %s", req.TeamName), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in get team in handler /team/setParent: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	var parent models.Team
+	if req.ParentTeamName != "" {
+		parent, err = h.db.GetTeamByName(ctx, req.ParentTeamName)
+		if err == sql.ErrNoRows {
+			writeError(w, "TEAM_NOT_FOUND", fmt.Sprintf("there is no team with name: %s", req.ParentTeamName), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("error in get parent team in handler /team/setParent: %v", err)
+			writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	err = h.db.SetTeamParent(ctx, team.ID, parent.ID, req.FallbackToSiblings)
+	if err == models.ErrTeamCycle {
+		writeError(w, "TEAM_HIERARCHY_CYCLE", fmt.Sprintf("team %s cannot be the parent of team %s, it is the team itself or one of its descendants", parent.Name, team.Name), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("error in set parent team in handler /team/setParent: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.SetTeamParentResponse{
+		TeamName:           team.Name,
+		ParentTeamName:     parent.Name,
+		FallbackToSiblings: req.FallbackToSiblings,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/narroworb/pr-review-service/internal/ical"
+	"github.com/narroworb/pr-review-service/internal/models"
+	"github.com/narroworb/pr-review-service/internal/workhours"
+)
+
+const (
+	maxHolidayNameLen = 200
+	maxTeamHolidays   = 1000
+)
+
+// defaultWorkDays are Monday to Friday.
+var defaultWorkDays = []int{1, 2, 3, 4, 5}
+
+// parseClock reads a local time HH:MM as minutes after midnight. 24:00 is the
+// end of the day.
+func parseClock(s string) (int, error) {
+	var h, m int
+	if _, err := fmt.Sscanf(s, "%d:%d", &h, &m); err != nil || len(s) != len("15:04") {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	if h < 0 || m < 0 || m > 59 || h*60+m > workhours.MinutesPerDay {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	return h*60 + m, nil
+}
+
+func formatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+func (h *HandlersRepo) SetWorkingHours(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	var req models.SetWorkingHoursRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "BAD_REQUEST", "invalid json body of request", http.StatusBadRequest)
+		return
+	}
+	if req.UserID == "" {
+		writeError(w, "BAD_REQUEST", "empty user_id", http.StatusBadRequest)
+		return
+	}
+
+	var wh *models.WorkingHours
+	if req.TimeZone != "" {
+		loc, err := time.LoadLocation(req.TimeZone)
+		if err != nil {
+			writeError(w, "BAD_REQUEST", fmt.Sprintf("unknown time_zone %s", req.TimeZone), http.StatusBadRequest)
+			return
+		}
+		start, err := parseClock(req.Start)
+		if err != nil {
+			writeError(w, "BAD_REQUEST", "start: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		end, err := parseClock(req.End)
+		if err != nil {
+			writeError(w, "BAD_REQUEST", "end: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		days := req.WorkDays
+		if len(days) == 0 {
+			days = defaultWorkDays
+		}
+		days = slices.Clone(days)
+		slices.Sort(days)
+		days = slices.Compact(days)
+
+		s := workhours.Schedule{Location: loc, Start: start, End: end}
+		for _, d := range days {
+			if d < 1 || d > 7 {
+				writeError(w, "BAD_REQUEST", "work_days must be ISO weekdays from 1 (Monday) to 7 (Sunday)", http.StatusBadRequest)
+				return
+			}
+			s.Days[d%7] = true
+		}
+		if err := s.Validate(); err != nil {
+			writeError(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		wh = &models.WorkingHours{TimeZone: loc.String(), WorkDays: days, StartMinute: start, EndMinute: end}
+	}
+
+	err := h.db.SetWorkingHours(ctx, req.UserID, wh)
+	if err == sql.ErrNoRows {
+		writeError(w, "USER_NOT_FOUND", fmt.Sprintf("there is no user with id=%s", req.UserID), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in set working hours in handler /users/setWorkingHours: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeWorkingHours(w, r, req.UserID, "/users/setWorkingHours")
+}
+
+func (h *HandlersRepo) GetWorkingHours(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		writeError(w, "BAD_REQUEST", "empty query parameter user_id", http.StatusBadRequest)
+		return
+	}
+
+	h.writeWorkingHours(w, r, userID, "/users/getWorkingHours")
+}
+
+// writeWorkingHours responds with the user's working hours and when they work
+// next.
+func (h *HandlersRepo) writeWorkingHours(w http.ResponseWriter, r *http.Request, userID, path string) {
+	ctx := r.Context()
+
+	wh, err := h.db.GetWorkingHours(ctx, userID)
+	if err == sql.ErrNoRows {
+		writeError(w, "USER_NOT_FOUND", fmt.Sprintf("there is no user with id=%s", userID), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in get working hours in handler %s: %v", path, err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	schedule, err := h.db.GetUserSchedule(ctx, userID)
+	if err != nil {
+		log.Printf("error in get schedule in handler %s: %v", path, err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now().UTC()
+	resp := models.WorkingHoursResponse{
+		UserID:       userID,
+		IsWorkingNow: schedule.Working(now),
+	}
+	if next, ok := schedule.NextStart(now); ok {
+		next = next.UTC()
+		resp.NextWorkingAt = &next
+	}
+	if wh != nil {
+		resp.TimeZone, resp.WorkDays = wh.TimeZone, wh.WorkDays
+		resp.Start, resp.End = formatClock(wh.StartMinute), formatClock(wh.EndMinute)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *HandlersRepo) SetTeamHolidays(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	var req models.SetTeamHolidaysRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "BAD_REQUEST", "invalid json body of request", http.StatusBadRequest)
+		return
+	}
+
+	holidays, err := teamHolidays(req.Holidays, req.Calendar)
+	if err != nil {
+		writeError(w, "BAD_REQUEST", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	team, err := h.db.GetTeamByName(ctx, req.TeamName)
+	if err == sql.ErrNoRows {
+		writeError(w, "TEAM_NOT_FOUND", fmt.Sprintf("there is no team with name: %s", req.TeamName), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in get team in handler /team/setHolidays: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.db.SetTeamHolidays(ctx, team.ID, holidays); err != nil {
+		log.Printf("error in set holidays in handler /team/setHolidays: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.TeamHolidaysResponse{
+		TeamName: team.Name,
+		Holidays: holidays,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *HandlersRepo) GetTeamHolidays(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := r.Context()
+
+	teamName := r.URL.Query().Get("team_name")
+
+	team, err := h.db.GetTeamByName(ctx, teamName)
+	if err == sql.ErrNoRows {
+		writeError(w, "TEAM_NOT_FOUND", fmt.Sprintf("there is no team with name: %s", teamName), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("error in get team in handler /team/getHolidays: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	holidays, err := h.db.GetTeamHolidays(ctx, team.ID)
+	if err != nil {
+		log.Printf("error in get holidays in handler /team/getHolidays: %v", err)
+		writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.TeamHolidaysResponse{
+		TeamName: team.Name,
+		Holidays: holidays,
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// teamHolidays validates the listed holidays and adds every day of the
+// calendar's all-day events. A day listed twice keeps its first name; the
+// result is sorted by date.
+func teamHolidays(listed []models.Holiday, calendar string) ([]models.Holiday, error) {
+	holidays := make([]models.Holiday, 0, len(listed))
+	seen := make(map[string]bool, len(listed))
+	add := func(day time.Time, name string) {
+		date := day.Format(time.DateOnly)
+		if seen[date] {
+			return
+		}
+		if utf8.RuneCountInString(name) > maxHolidayNameLen {
+			name = string([]rune(name)[:maxHolidayNameLen])
+		}
+		seen[date] = true
+		holidays = append(holidays, models.Holiday{Date: date, Name: name})
+	}
+
+	for _, h := range listed {
+		day, err := time.Parse(time.DateOnly, h.Date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid holiday date %q, expected YYYY-MM-DD", h.Date)
+		}
+		add(day, h.Name)
+	}
+
+	if strings.TrimSpace(calendar) != "" {
+		events, _, err := ical.Parse(strings.NewReader(calendar))
+		var parseErr *ical.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, fmt.Errorf("invalid calendar: %v", parseErr)
+		}
+		if err != nil {
+			return nil, errors.New("invalid calendar")
+		}
+		for _, e := range events {
+			if !e.AllDay {
+				continue
+			}
+			for day := e.Start; day.Before(e.End) && len(holidays) <= maxTeamHolidays; day = day.AddDate(0, 0, 1) {
+				add(day, e.Summary)
+			}
+		}
+	}
+
+	if len(holidays) > maxTeamHolidays {
+		return nil, fmt.Errorf("a team can have at most %d holidays", maxTeamHolidays)
+	}
+	slices.SortFunc(holidays, func(a, b models.Holiday) int { return strings.Compare(a.Date, b.Date) })
+
+	return holidays, nil
+}
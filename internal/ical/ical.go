@@ -0,0 +1,308 @@
+// Package ical reads the events of an iCalendar (RFC 5545) file, as exported by
+// calendar apps, into plain time ranges. Only what is needed to import
+// out-of-office periods is supported: VEVENT components with DTSTART, DTEND or
+// DURATION, SUMMARY and UID. Recurring and cancelled events are reported as
+// skipped rather than expanded.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	// Calendars name their zones by TZID and the service image may have no
+	// zoneinfo.
+	_ "time/tzdata"
+)
+
+// Event is a single occurrence. End is exclusive; for all-day events Start and
+// End are midnights in UTC.
+type Event struct {
+	UID     string
+	Summary string
+	Start   time.Time
+	End     time.Time
+	AllDay  bool
+}
+
+// ParseError points to the line of the file that could not be parsed.
+type ParseError struct {
+	Line int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// property is one content line, NAME;PARAM=VALUE:VALUE.
+type property struct {
+	line   int
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Parse returns the events of the calendar and the number of events it
+// skipped because they recur or are cancelled.
+func Parse(r io.Reader) ([]Event, int, error) {
+	props, err := contentLines(r)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var (
+		events  []Event
+		skipped int
+		cur     []property
+		inEvent bool
+		depth   int
+	)
+	for _, p := range props {
+		switch {
+		case p.name == "BEGIN" && strings.EqualFold(p.value, "VEVENT") && !inEvent:
+			inEvent, depth, cur = true, 0, cur[:0]
+		case !inEvent:
+			continue
+		case p.name == "BEGIN":
+			// Nested components such as VALARM carry their own properties.
+			depth++
+		case p.name == "END" && depth > 0:
+			depth--
+		case p.name == "END" && strings.EqualFold(p.value, "VEVENT"):
+			inEvent = false
+			e, ok, err := buildEvent(cur, p.line)
+			if err != nil {
+				return nil, 0, err
+			}
+			if !ok {
+				skipped++
+				continue
+			}
+			events = append(events, e)
+		case depth == 0:
+			cur = append(cur, p)
+		}
+	}
+	if inEvent {
+		return nil, 0, &ParseError{Line: len(props), Msg: "unterminated VEVENT"}
+	}
+
+	return events, skipped, nil
+}
+
+// contentLines unfolds the lines of the calendar and splits them into
+// properties.
+func contentLines(r io.Reader) ([]property, error) {
+	var (
+		props  []property
+		folded strings.Builder
+		start  int
+	)
+	flush := func() error {
+		if folded.Len() == 0 {
+			return nil
+		}
+		p, err := parseProperty(folded.String(), start)
+		if err != nil {
+			return err
+		}
+		props = append(props, p)
+		folded.Reset()
+		return nil
+	}
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSuffix(sc.Text(), "\r")
+		if strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t") {
+			folded.WriteString(text[1:])
+			continue
+		}
+		if err := flush(); err != nil {
+			return nil, err
+		}
+		if text != "" {
+			folded.WriteString(text)
+			start = line
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	return props, nil
+}
+
+func parseProperty(text string, line int) (property, error) {
+	// The value starts at the first colon outside a quoted parameter value.
+	colon, quoted := -1, false
+	for i, c := range text {
+		if c == '"' {
+			quoted = !quoted
+		} else if c == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon == -1 {
+		return property{}, &ParseError{Line: line, Msg: "missing ':' in content line"}
+	}
+
+	head := strings.Split(text[:colon], ";")
+	p := property{line: line, name: strings.ToUpper(head[0]), params: make(map[string]string), value: text[colon+1:]}
+	for _, param := range head[1:] {
+		k, v, _ := strings.Cut(param, "=")
+		p.params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+	return p, nil
+}
+
+// buildEvent turns the properties of a VEVENT into an Event. ok is false for
+// events that are skipped.
+func buildEvent(props []property, endLine int) (Event, bool, error) {
+	var (
+		e                Event
+		start, end, dur  *property
+		recurs, canceled bool
+	)
+	for i := range props {
+		p := &props[i]
+		switch p.name {
+		case "UID":
+			e.UID = p.value
+		case "SUMMARY":
+			e.Summary = unescapeText(p.value)
+		case "DTSTART":
+			start = p
+		case "DTEND":
+			end = p
+		case "DURATION":
+			dur = p
+		case "RRULE", "RDATE":
+			recurs = true
+		case "STATUS":
+			canceled = strings.EqualFold(p.value, "CANCELLED")
+		}
+	}
+	if recurs || canceled {
+		return Event{}, false, nil
+	}
+	if start == nil {
+		return Event{}, false, &ParseError{Line: endLine, Msg: "VEVENT without DTSTART"}
+	}
+
+	var err error
+	e.Start, e.AllDay, err = parseDateTime(*start)
+	if err != nil {
+		return Event{}, false, err
+	}
+
+	switch {
+	case end != nil:
+		e.End, _, err = parseDateTime(*end)
+	case dur != nil:
+		var d time.Duration
+		d, err = parseDuration(dur.value)
+		if err != nil {
+			err = &ParseError{Line: dur.line, Msg: err.Error()}
+		}
+		e.End = e.Start.Add(d)
+	case e.AllDay:
+		e.End = e.Start.AddDate(0, 0, 1)
+	default:
+		e.End = e.Start
+	}
+	if err != nil {
+		return Event{}, false, err
+	}
+	if !e.End.After(e.Start) {
+		return Event{}, false, &ParseError{Line: start.line, Msg: "event ends before it starts"}
+	}
+
+	return e, true, nil
+}
+
+// parseDateTime reads a DATE or DATE-TIME value. Times without a zone and
+// without TZID are taken as UTC.
+func parseDateTime(p property) (time.Time, bool, error) {
+	if strings.EqualFold(p.params["VALUE"], "DATE") || len(p.value) == len("20060102") {
+		t, err := time.Parse("20060102", p.value)
+		if err != nil {
+			return time.Time{}, false, &ParseError{Line: p.line, Msg: fmt.Sprintf("invalid date %s", p.value)}
+		}
+		return t, true, nil
+	}
+
+	loc := time.UTC
+	if tzid := p.params["TZID"]; tzid != "" && !strings.HasSuffix(p.value, "Z") {
+		l, err := time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, false, &ParseError{Line: p.line, Msg: fmt.Sprintf("unknown time zone %s", tzid)}
+		}
+		loc = l
+	}
+	t, err := time.ParseInLocation("20060102T150405", strings.TrimSuffix(p.value, "Z"), loc)
+	if err != nil {
+		return time.Time{}, false, &ParseError{Line: p.line, Msg: fmt.Sprintf("invalid date-time %s", p.value)}
+	}
+	return t.UTC(), false, nil
+}
+
+// parseDuration reads an RFC 5545 duration such as P1W, P2D or PT1H30M.
+func parseDuration(s string) (time.Duration, error) {
+	rest, ok := strings.CutPrefix(strings.TrimPrefix(s, "+"), "P")
+	// A time designator must be followed by at least one time part.
+	if !ok || rest == "" || strings.HasSuffix(rest, "T") {
+		return 0, fmt.Errorf("invalid duration %s", s)
+	}
+
+	var (
+		d      time.Duration
+		inTime bool
+		num    string
+	)
+	for _, c := range rest {
+		switch {
+		case c >= '0' && c <= '9':
+			num += string(c)
+			continue
+		case c == 'T' && !inTime && num == "":
+			inTime = true
+			continue
+		}
+
+		n, err := strconv.Atoi(num)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %s", s)
+		}
+		num = ""
+
+		unit := map[rune]time.Duration{'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour}
+		if inTime {
+			unit = map[rune]time.Duration{'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+		}
+		u, ok := unit[c]
+		if !ok {
+			return 0, fmt.Errorf("invalid duration %s", s)
+		}
+		d += time.Duration(n) * u
+	}
+	if num != "" {
+		return 0, fmt.Errorf("invalid duration %s", s)
+	}
+
+	return d, nil
+}
+
+func unescapeText(s string) string {
+	r := strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+	return r.Replace(s)
+}
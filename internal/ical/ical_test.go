@@ -0,0 +1,353 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func calendar(lines ...string) string {
+	return "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" + strings.Join(lines, "\r\n") + "\r\nEND:VCALENDAR\r\n"
+}
+
+func utc(year int, month time.Month, day, hour, min int) time.Time {
+	return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		events  []Event
+		skipped int
+	}{
+		{
+			name: "utc event",
+			content: calendar(
+				"BEGIN:VEVENT",
+				"UID:1@example.com",
+				"SUMMARY:Vacation",
+				"DTSTART:20240301T090000Z",
+				"DTEND:20240305T180000Z",
+				"END:VEVENT",
+			),
+			events: []Event{{UID: "1@example.com", Summary: "Vacation", Start: utc(2024, 3, 1, 9, 0), End: utc(2024, 3, 5, 18, 0)}},
+		},
+		{
+			name: "tzid in winter and summer",
+			content: calendar(
+				"BEGIN:VEVENT",
+				"DTSTART;TZID=Europe/Berlin:20240301T090000",
+				"DTEND;TZID=Europe/Berlin:20240701T090000",
+				"END:VEVENT",
+			),
+			events: []Event{{Start: utc(2024, 3, 1, 8, 0), End: utc(2024, 7, 1, 7, 0)}},
+		},
+		{
+			name: "floating time is utc",
+			content: calendar(
+				"BEGIN:VEVENT",
+				"DTSTART:20240301T090000",
+				"DURATION:PT1H30M",
+				"END:VEVENT",
+			),
+			events: []Event{{Start: utc(2024, 3, 1, 9, 0), End: utc(2024, 3, 1, 10, 30)}},
+		},
+		{
+			name: "all-day event with end",
+			content: calendar(
+				"BEGIN:VEVENT",
+				"DTSTART;VALUE=DATE:20240301",
+				"DTEND;VALUE=DATE:20240304",
+				"END:VEVENT",
+			),
+			events: []Event{{Start: utc(2024, 3, 1, 0, 0), End: utc(2024, 3, 4, 0, 0), AllDay: true}},
+		},
+		{
+			name: "all-day event without end lasts a day",
+			content: calendar(
+				"BEGIN:VEVENT",
+				"DTSTART:20241231",
+				"END:VEVENT",
+			),
+			events: []Event{{Start: utc(2024, 12, 31, 0, 0), End: utc(2025, 1, 1, 0, 0), AllDay: true}},
+		},
+		{
+			name: "all-day event with duration",
+			content: calendar(
+				"BEGIN:VEVENT",
+				"DTSTART;VALUE=DATE:20240301",
+				"DURATION:P1W",
+				"END:VEVENT",
+			),
+			events: []Event{{Start: utc(2024, 3, 1, 0, 0), End: utc(2024, 3, 8, 0, 0), AllDay: true}},
+		},
+		{
+			name: "folded and escaped summary",
+			content: calendar(
+				"BEGIN:VEVENT",
+				"SUMMARY:Out of office\\, back",
+				" \\non Monday",
+				"\t\\; really",
+				"DTSTART:20240301T090000Z",
+				"DTEND:20240301T100000Z",
+				"END:VEVENT",
+			),
+			events: []Event{{Summary: "Out of office, back\non Monday; really", Start: utc(2024, 3, 1, 9, 0), End: utc(2024, 3, 1, 10, 0)}},
+		},
+		{
+			name: "quoted parameter with colon",
+			content: calendar(
+				"BEGIN:VEVENT",
+				`DTSTART;X-NOTE="a:b";TZID=Europe/Berlin:20240301T090000`,
+				"DTEND:20240301T100000Z",
+				"END:VEVENT",
+			),
+			events: []Event{{Start: utc(2024, 3, 1, 8, 0), End: utc(2024, 3, 1, 10, 0)}},
+		},
+		{
+			name:    "lowercase names and lf line ends",
+			content: "begin:VCALENDAR\nbegin:vevent\nuid:x\ndtstart:20240301T090000Z\ndtend:20240301T100000Z\nend:vevent\nend:VCALENDAR\n",
+			events:  []Event{{UID: "x", Start: utc(2024, 3, 1, 9, 0), End: utc(2024, 3, 1, 10, 0)}},
+		},
+		{
+			name: "nested alarm is ignored",
+			content: calendar(
+				"BEGIN:VEVENT",
+				"DTSTART:20240301T090000Z",
+				"BEGIN:VALARM",
+				"TRIGGER:-PT15M",
+				"DURATION:PT5M",
+				"SUMMARY:Alarm",
+				"END:VALARM",
+				"DTEND:20240301T100000Z",
+				"SUMMARY:Meeting",
+				"END:VEVENT",
+			),
+			events: []Event{{Summary: "Meeting", Start: utc(2024, 3, 1, 9, 0), End: utc(2024, 3, 1, 10, 0)}},
+		},
+		{
+			name: "recurring and cancelled events are skipped",
+			content: calendar(
+				"BEGIN:VEVENT",
+				"DTSTART:20240301T090000Z",
+				"DTEND:20240301T100000Z",
+				"RRULE:FREQ=WEEKLY",
+				"END:VEVENT",
+				"BEGIN:VEVENT",
+				"DTSTART:20240301T090000Z",
+				"RDATE:20240308T090000Z",
+				"END:VEVENT",
+				"BEGIN:VEVENT",
+				"DTSTART:20240301T090000Z",
+				"STATUS:CANCELLED",
+				"END:VEVENT",
+				"BEGIN:VEVENT",
+				"DTSTART:20240302T090000Z",
+				"DTEND:20240302T100000Z",
+				"STATUS:CONFIRMED",
+				"END:VEVENT",
+			),
+			events:  []Event{{Start: utc(2024, 3, 2, 9, 0), End: utc(2024, 3, 2, 10, 0)}},
+			skipped: 3,
+		},
+		{
+			name:    "no events",
+			content: calendar("BEGIN:VTODO", "DTSTART:20240301T090000Z", "END:VTODO"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, skipped, err := Parse(strings.NewReader(tt.content))
+			require.NoError(t, err)
+			assert.Equal(t, tt.events, events)
+			assert.Equal(t, tt.skipped, skipped)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{
+			name:    "missing colon",
+			content: calendar("BEGIN:VEVENT", "DTSTART;VALUE=DATE"),
+			err:     "line 4: missing ':' in content line",
+		},
+		{
+			name:    "colon only inside quotes",
+			content: calendar("BEGIN:VEVENT", `X-A;P="a:b"`),
+			err:     "line 4: missing ':' in content line",
+		},
+		{
+			name:    "unterminated event",
+			content: "BEGIN:VEVENT\nDTSTART:20240301T090000Z\n",
+			err:     "line 2: unterminated VEVENT",
+		},
+		{
+			name:    "event without start",
+			content: calendar("BEGIN:VEVENT", "DTEND:20240301T100000Z", "END:VEVENT"),
+			err:     "line 5: VEVENT without DTSTART",
+		},
+		{
+			name:    "invalid date",
+			content: calendar("BEGIN:VEVENT", "DTSTART;VALUE=DATE:2024-03-01", "END:VEVENT"),
+			err:     "line 4: invalid date 2024-03-01",
+		},
+		{
+			name:    "invalid date-time",
+			content: calendar("BEGIN:VEVENT", "DTSTART:20240301T0900", "END:VEVENT"),
+			err:     "line 4: invalid date-time 20240301T0900",
+		},
+		{
+			name:    "unknown zone",
+			content: calendar("BEGIN:VEVENT", "DTSTART;TZID=Mars/Olympus:20240301T090000", "END:VEVENT"),
+			err:     "line 4: unknown time zone Mars/Olympus",
+		},
+		{
+			name:    "invalid end",
+			content: calendar("BEGIN:VEVENT", "DTSTART:20240301T090000Z", "DTEND:later", "END:VEVENT"),
+			err:     "line 5: invalid date-time later",
+		},
+		{
+			name:    "invalid duration",
+			content: calendar("BEGIN:VEVENT", "DTSTART:20240301T090000Z", "", "DURATION:PT1X", "END:VEVENT"),
+			err:     "line 6: invalid duration PT1X",
+		},
+		{
+			name:    "end before start",
+			content: calendar("BEGIN:VEVENT", "DTSTART:20240301T090000Z", "DTEND:20240301T080000Z", "END:VEVENT"),
+			err:     "line 4: event ends before it starts",
+		},
+		{
+			name:    "instant event",
+			content: calendar("BEGIN:VEVENT", "DTSTART:20240301T090000Z", "END:VEVENT"),
+			err:     "line 4: event ends before it starts",
+		},
+		{
+			name:    "folded line reports its first line",
+			content: calendar("BEGIN:VEVENT", "DTSTART:2024", " 0301T0900", "END:VEVENT"),
+			err:     "line 4: invalid date-time 20240301T0900",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := Parse(strings.NewReader(tt.content))
+			var parseErr *ParseError
+			require.ErrorAs(t, err, &parseErr)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestParseDateTime(t *testing.T) {
+	tests := []struct {
+		name   string
+		params map[string]string
+		value  string
+		want   time.Time
+		allDay bool
+		err    bool
+	}{
+		{name: "utc", value: "20240301T090000Z", want: utc(2024, 3, 1, 9, 0)},
+		{name: "floating", value: "20240301T090000", want: utc(2024, 3, 1, 9, 0)},
+		{name: "tzid", params: map[string]string{"TZID": "America/New_York"}, value: "20240301T090000", want: utc(2024, 3, 1, 14, 0)},
+		{name: "tzid ignored for utc", params: map[string]string{"TZID": "America/New_York"}, value: "20240301T090000Z", want: utc(2024, 3, 1, 9, 0)},
+		{name: "before spring forward", params: map[string]string{"TZID": "Europe/Berlin"}, value: "20240331T013000", want: utc(2024, 3, 31, 0, 30)},
+		{name: "after spring forward", params: map[string]string{"TZID": "Europe/Berlin"}, value: "20240331T033000", want: utc(2024, 3, 31, 1, 30)},
+		{name: "date by value", params: map[string]string{"VALUE": "DATE"}, value: "20240229", want: utc(2024, 2, 29, 0, 0), allDay: true},
+		{name: "date by length", value: "20240229", want: utc(2024, 2, 29, 0, 0), allDay: true},
+		{name: "date ignores tzid", params: map[string]string{"TZID": "Asia/Tokyo"}, value: "20240229", want: utc(2024, 2, 29, 0, 0), allDay: true},
+		{name: "impossible date", value: "20230229", err: true},
+		{name: "date-time as date", params: map[string]string{"VALUE": "DATE"}, value: "20240301T090000Z", err: true},
+		{name: "garbage", value: "soon", err: true},
+		{name: "empty", value: "", err: true},
+		{name: "unknown zone", params: map[string]string{"TZID": "Nowhere"}, value: "20240301T090000", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := tt.params
+			if params == nil {
+				params = map[string]string{}
+			}
+			got, allDay, err := parseDateTime(property{line: 7, name: "DTSTART", params: params, value: tt.value})
+			if tt.err {
+				var parseErr *ParseError
+				if assert.ErrorAs(t, err, &parseErr) {
+					assert.Equal(t, 7, parseErr.Line)
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, time.UTC, got.Location())
+			assert.Equal(t, tt.allDay, allDay)
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		err  bool
+	}{
+		{in: "P1W", want: 7 * 24 * time.Hour},
+		{in: "P2D", want: 48 * time.Hour},
+		{in: "+P2D", want: 48 * time.Hour},
+		{in: "PT1H30M", want: 90 * time.Minute},
+		{in: "PT45S", want: 45 * time.Second},
+		{in: "P1DT12H", want: 36 * time.Hour},
+		{in: "P0D", want: 0},
+		{in: "PT90M", want: 90 * time.Minute},
+		{in: "", err: true},
+		{in: "P", err: true},
+		{in: "PT", err: true},
+		{in: "P1DT", err: true},
+		{in: "1D", err: true},
+		{in: "-P1D", err: true},
+		{in: "P1H", err: true},
+		{in: "PT1D", err: true},
+		{in: "P1", err: true},
+		{in: "PD", err: true},
+		{in: "PTT1H", err: true},
+		{in: "P1X", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseDuration(tt.in)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestUnescapeText(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "plain", want: "plain"},
+		{in: `a\, b\; c`, want: "a, b; c"},
+		{in: `line\nnext\Nlast`, want: "line\nnext\nlast"},
+		{in: `back\\slash`, want: `back\slash`},
+		{in: `not\\n a newline`, want: `not\n a newline`},
+		{in: `unknown\x`, want: `unknown\x`},
+		{in: `trailing\`, want: `trailing\`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			assert.Equal(t, tt.want, unescapeText(tt.in))
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/narroworb/pr-review-service/internal/models"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	maxIdempotentRequestBytes = 1 << 20
+	// idempotencyStaleAfter is far longer than any request may run under
+	// TimeoutMiddleware, so a key still in progress after it was left behind
+	// by a process that died mid-request.
+	idempotencyStaleAfter = time.Minute
+)
+
+type IdempotencyStore interface {
+	ReserveIdempotencyKey(context.Context, string, string, time.Duration, time.Duration) (models.IdempotencyRecord, bool, error)
+	SaveIdempotencyResponse(context.Context, string, int, []byte) error
+	ReleaseIdempotencyKey(context.Context, string) error
+}
+
+// IdempotencyMiddleware makes POST requests carrying an Idempotency-Key header
+// safe to retry. The first request with a key is executed and its response is
+// kept for ttl; a replay with the same method, path and body gets the stored
+// status and body back without touching the handler. Reusing the key for a
+// different request is rejected with 422. 5xx responses are not stored, so the
+// client can retry them with the same key. A key still in progress after
+// idempotencyStaleAfter is taken over by the next request with it.
+func IdempotencyMiddleware(store IdempotencyStore, ttl time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if r.Method != http.MethodPost || key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+
+			if len(key) > maxIdempotencyKeyLength {
+				writeError(w, "BAD_REQUEST", "Idempotency-Key header is too long", http.StatusBadRequest)
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentRequestBytes+1))
+			if err != nil {
+				writeError(w, "BAD_REQUEST", "cannot read body of request", http.StatusBadRequest)
+				return
+			}
+			if len(body) > maxIdempotentRequestBytes {
+				writeError(w, "BAD_REQUEST", "body of request is too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			hash := sha256.New()
+			hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+			hash.Write(body)
+			requestHash := hex.EncodeToString(hash.Sum(nil))
+
+			rec, reserved, err := store.ReserveIdempotencyKey(r.Context(), key, requestHash, ttl, idempotencyStaleAfter)
+			if err != nil {
+				log.Printf("error in reserve idempotency key %s: %v", key, err)
+				writeError(w, "SERVER_ERROR", "try again later", http.StatusInternalServerError)
+				return
+			}
+
+			if !reserved {
+				switch {
+				case rec.RequestHash != requestHash:
+					writeError(w, "IDEMPOTENCY_KEY_REUSED", "Idempotency-Key was already used with a different request", http.StatusUnprocessableEntity)
+				case rec.StatusCode == 0:
+					writeError(w, "IDEMPOTENCY_IN_PROGRESS", "request with this Idempotency-Key is still in progress", http.StatusConflict)
+				default:
+					w.Header().Set(IdempotentReplayedHeader, "true")
+					w.WriteHeader(rec.StatusCode)
+					_, _ = w.Write(rec.Body)
+				}
+				return
+			}
+
+			rw := &recordingWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(rw, r)
+
+			// The request context may already be cancelled by the timeout
+			// middleware, but the outcome must still be recorded.
+			ctx := context.WithoutCancel(r.Context())
+			if rw.statusCode >= http.StatusInternalServerError {
+				if err := store.ReleaseIdempotencyKey(ctx, key); err != nil {
+					log.Printf("error in release idempotency key %s: %v", key, err)
+				}
+				return
+			}
+			if err := store.SaveIdempotencyResponse(ctx, key, rw.statusCode, rw.body.Bytes()); err != nil {
+				log.Printf("error in save idempotency response for key %s: %v", key, err)
+			}
+		})
+	}
+}
+
+type recordingWriter struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rw *recordingWriter) WriteHeader(statusCode int) {
+	if !rw.wroteHeader {
+		rw.statusCode, rw.wroteHeader = statusCode, true
+	}
+	rw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+func writeError(w http.ResponseWriter, code, message string, statusCode int) {
+	w.WriteHeader(statusCode)
+	var e models.ErrorResponse
+	e.Error.Code = code
+	e.Error.Message = message
+	_ = json.NewEncoder(w).Encode(e)
+}
@@ -0,0 +1,30 @@
+package models
+
+import "errors"
+
+var (
+	ErrPRExists    = errors.New("pull request already exists")
+	ErrPRMerged    = errors.New("pull request is merged")
+	ErrPRClosed    = errors.New("pull request is closed")
+	ErrNotAssigned = errors.New("reviewer is not assigned to pull request")
+	ErrNoCandidate = errors.New("no available reviewer candidate")
+
+	ErrReviewerIsAuthor    = errors.New("reviewer is the author of the pull request")
+	ErrAlreadyAssigned     = errors.New("reviewer is already assigned to pull request")
+	ErrReviewerUnavailable = errors.New("reviewer is inactive or absent")
+	ErrReviewerExcluded    = errors.New("reviewer is excluded from the pull request by a rule")
+	ErrReviewerAtCapacity  = errors.New("reviewer is at their open review cap")
+	ErrNotInReviewerPool   = errors.New("reviewer is not in a reviewer pool of the pull request")
+	ErrReviewerDeclined    = errors.New("reviewer declined the pull request")
+
+	ErrPolicyMinSeniors = errors.New("review policy requires more senior reviewers")
+	ErrPolicySoleJunior = errors.New("review policy forbids a junior as the only reviewer")
+
+	ErrUserExists    = errors.New("user already belongs to a team")
+	ErrNotTeamMember = errors.New("user is not a member of the team")
+	ErrAlreadyInTeam = errors.New("user is already a member of the team")
+
+	ErrTeamExists   = errors.New("team already exists")
+	ErrTeamNotEmpty = errors.New("team has members")
+	ErrTeamCycle    = errors.New("team cannot be its own ancestor")
+)
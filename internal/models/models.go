@@ -1,14 +1,96 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type PRStatus string
 
 const (
 	PRStatusOpen   PRStatus = "OPEN"
 	PRStatusMerged PRStatus = "MERGED"
+	PRStatusClosed PRStatus = "CLOSED"
+)
+
+func (s PRStatus) Valid() bool {
+	return s == PRStatusOpen || s == PRStatusMerged || s == PRStatusClosed
+}
+
+// AssignmentPending marks a PR waiting in the review queue for reviewers who
+// are under their open review cap.
+const AssignmentPending = "PENDING_REVIEWERS"
+
+const (
+	EventReviewersPending  = "pr.reviewers_pending"
+	EventReviewersAssigned = "pr.reviewers_assigned"
+	EventReviewerReplaced  = "pr.reviewer_replaced"
+	EventReviewerAdded     = "pr.reviewer_added"
+	EventReviewerRemoved   = "pr.reviewer_removed"
+	EventReviewAccepted    = "pr.review_accepted"
+	EventReviewDeclined    = "pr.review_declined"
+	EventSLABreached       = "pr.sla_breached"
+	EventSLAEscalated      = "pr.sla_escalated"
+)
+
+// Seniority is a user's level. Seniors and leads count towards a team's
+// min_seniors policy; bots can be excluded from reviews by policy.
+type Seniority string
+
+const (
+	SeniorityJunior Seniority = "junior"
+	SeniorityMid    Seniority = "mid"
+	SenioritySenior Seniority = "senior"
+	SeniorityLead   Seniority = "lead"
+	SeniorityBot    Seniority = "bot"
 )
 
+func (s Seniority) Valid() bool {
+	switch s {
+	case SeniorityJunior, SeniorityMid, SenioritySenior, SeniorityLead, SeniorityBot:
+		return true
+	}
+	return false
+}
+
+// Senior reports whether the level counts as senior.
+func (s Seniority) Senior() bool {
+	return s == SenioritySenior || s == SeniorityLead
+}
+
+// PairRuleKind says how a pair rule treats the reviewer on the author's PRs:
+// never assigns them, or prefers them over otherwise equal candidates.
+type PairRuleKind string
+
+const (
+	PairRuleNever  PairRuleKind = "never"
+	PairRulePrefer PairRuleKind = "prefer"
+)
+
+func (k PairRuleKind) Valid() bool {
+	return k == PairRuleNever || k == PairRulePrefer
+}
+
+// AckDecision is a reviewer's answer to an assignment.
+type AckDecision string
+
+const (
+	AckAccept  AckDecision = "accept"
+	AckDecline AckDecision = "decline"
+)
+
+func (d AckDecision) Valid() bool {
+	return d == AckAccept || d == AckDecline
+}
+
+type MemberRole string
+
+const (
+	MemberRoleMember MemberRole = "member"
+	MemberRoleLead   MemberRole = "lead"
+)
+
+// User.GroupID is the user's primary team, zero for users without one.
 type User struct {
 	ID       string `json:"user_id"`
 	Name     string `json:"username"`
@@ -16,30 +98,636 @@ type User struct {
 	GroupID  int64  `json:"-"`
 }
 
+// Member is a user as seen from one of their teams.
+type Member struct {
+	ID        string     `json:"user_id"`
+	Name      string     `json:"username"`
+	IsActive  bool       `json:"is_active"`
+	Role      MemberRole `json:"role"`
+	IsPrimary bool       `json:"is_primary"`
+}
+
+// Membership is one of the teams a user belongs to.
+type Membership struct {
+	TeamID    int64      `json:"-"`
+	TeamName  string     `json:"team_name"`
+	Role      MemberRole `json:"role"`
+	IsPrimary bool       `json:"is_primary"`
+}
+
+// Team.ParentID is the team reviewers are borrowed from when the team cannot
+// fill a review, zero for root teams.
 type Team struct {
-	ID   int64
-	Name string
+	ID                 int64
+	Name               string
+	ArchivedAt         *time.Time
+	ParentID           int64
+	ParentName         string
+	FallbackToSiblings bool
 }
 
+// PullRequest.TeamID is the team reviewers are picked from, zero if the PR has
+// no team. FallbackReviewers lists the reviewers borrowed from other teams,
+// RequiredReviewers the ones filling label rule slots and CodeownerReviewers the
+// ones required by the repository's CODEOWNERS for ChangedFiles.
+// PreferredReviewers are tried first, in order, for the slots of the PR's team.
+// Reviewers whose skills cover more of Tags are preferred over less loaded ones;
+// SkillMatches tells how well each reviewer covers them. PendingReviewers is the
+// number of team slots waiting in the review queue, queued with Priority.
 type PullRequest struct {
-	ID        string     `json:"pull_request_id"`
-	Name      string     `json:"pull_request_name"`
-	AuthorID  string     `json:"author_id"`
-	Status    PRStatus   `json:"status"`
-	Reviewers []User     `json:"-"`
-	MergedAt  *time.Time `json:"-"`
+	ID       string   `json:"pull_request_id"`
+	Name     string   `json:"pull_request_name"`
+	AuthorID string   `json:"author_id"`
+	Status   PRStatus `json:"status"`
+	PRMetadata
+	TeamID             int64               `json:"-"`
+	Reviewers          []User              `json:"-"`
+	FallbackReviewers  []FallbackReviewer  `json:"-"`
+	Labels             []string            `json:"-"`
+	RequiredReviewers  []RequiredReviewer  `json:"-"`
+	ChangedFiles       []string            `json:"-"`
+	CodeownerReviewers []CodeownerReviewer `json:"-"`
+	PreferredReviewers []string            `json:"-"`
+	Tags               []string            `json:"-"`
+	SkillMatches       []SkillMatch        `json:"-"`
+	Priority           int                 `json:"-"`
+	PendingReviewers   int                 `json:"-"`
+	MergedAt           *time.Time          `json:"-"`
+	ClosedAt           *time.Time          `json:"-"`
+}
+
+// PRSummary is a PR as listed by /pullRequest/list. TeamName is empty for PRs
+// whose team was deleted.
+type PRSummary struct {
+	PRID     string   `json:"pull_request_id"`
+	PRName   string   `json:"pull_request_name"`
+	AuthorID string   `json:"author_id"`
+	Status   PRStatus `json:"status"`
+	TeamName string   `json:"team_name,omitempty"`
+	Priority int      `json:"priority"`
+	PRMetadata
+	Labels    []string   `json:"labels"`
+	Reviewers []string   `json:"assigned_reviewers"`
+	MergedAt  *time.Time `json:"merged_at,omitempty"`
+	ClosedAt  *time.Time `json:"closed_at,omitempty"`
+}
+
+// PRDetails is a PR with everything known about its reviewers.
+type PRDetails struct {
+	PRSummary
+	Tags               []string            `json:"tags"`
+	FallbackReviewers  []FallbackReviewer  `json:"fallback_reviewers"`
+	RequiredReviewers  []RequiredReviewer  `json:"required_reviewers"`
+	CodeownerReviewers []CodeownerReviewer `json:"codeowner_reviewers"`
+	AssignmentStatus   string              `json:"assignment_status,omitempty"`
+	PendingReviewers   int                 `json:"pending_reviewers"`
+}
+
+// Ack states of a review in the inbox.
+const (
+	AckStatePending  = "pending"
+	AckStateAccepted = "accepted"
+)
+
+// InboxItem is a review in a user's inbox. AgeMinutes is the reviewer's
+// working time since the assignment, until the merge for merged PRs.
+// SLADeadline is when the review breaches its team's SLA; it is only set for
+// reviews of open PRs of teams with an SLA awaiting a first response. Score
+// orders the inbox: the minutes since the assignment plus InboxPriorityMinutes
+// per point of priority, plus InboxBreachMinutes once the SLA is breached, zero
+// for merged PRs. RankAt is the time Score counts from, which pages the inbox.
+type InboxItem struct {
+	PRID            string     `json:"pull_request_id"`
+	PRName          string     `json:"pull_request_name"`
+	AuthorID        string     `json:"author_id"`
+	Status          PRStatus   `json:"status"`
+	TeamName        string     `json:"team_name,omitempty"`
+	URL             string     `json:"url,omitempty"`
+	Priority        int        `json:"priority"`
+	AssignedAt      time.Time  `json:"assigned_at"`
+	AgeMinutes      int        `json:"age_minutes"`
+	AckState        string     `json:"ack_state"`
+	AcceptedAt      *time.Time `json:"accepted_at,omitempty"`
+	FirstResponseAt *time.Time `json:"first_response_at,omitempty"`
+	SLADeadline     *time.Time `json:"sla_deadline,omitempty"`
+	SLABreached     bool       `json:"sla_breached"`
+	Score           int        `json:"score"`
+	RankAt          time.Time  `json:"-"`
 }
 
+const (
+	InboxPriorityMinutes = 60
+	InboxBreachMinutes   = 480
+)
+
+// InboxFilter selects a page of the user's inbox. After continues it past its
+// cursor.
+type InboxFilter struct {
+	UserID        string
+	IncludeMerged bool
+	After         *ListCursor
+	Limit         int
+}
+
+// InboxCount counts a user's reviews of open PRs for badges.
+type InboxCount struct {
+	Open        int64 `json:"open"`
+	Unaccepted  int64 `json:"unaccepted"`
+	SLABreached int64 `json:"sla_breached"`
+}
+
+// PRSort is the order of /pullRequest/list: by creation time, by name or by
+// size, the lines added and removed. Ties are broken by PR ID.
+type PRSort string
+
+const (
+	PRSortCreatedAt PRSort = "created_at"
+	PRSortName      PRSort = "name"
+	PRSortSize      PRSort = "size"
+)
+
+func (s PRSort) Valid() bool {
+	return s == PRSortCreatedAt || s == PRSortName || s == PRSortSize
+}
+
+// ListCursor is where a page of a listing ends: the sort value and ID of its
+// last entry under the sort it was listed with.
+type ListCursor struct {
+	Sort  string `json:"sort"`
+	Desc  bool   `json:"desc"`
+	Value string `json:"value"`
+	ID    string `json:"id"`
+}
+
+// PRListFilter selects the PRs of /pullRequest/list; zero fields match every
+// PR. Name matches a substring of the PR name without case, Label one of its
+// labels. The time ranges include From and exclude To. After continues a
+// listing past its cursor, which must have the same Sort and Desc.
+type PRListFilter struct {
+	Status      PRStatus
+	AuthorID    string
+	ReviewerID  string
+	TeamID      int64
+	Label       string
+	Name        string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	MergedFrom  *time.Time
+	MergedTo    *time.Time
+	Sort        PRSort
+	Desc        bool
+	After       *ListCursor
+	Limit       int
+}
+
+// TeamSort is the order of /team/list: by name or by member count. Ties are
+// broken by team ID.
+type TeamSort string
+
+const (
+	TeamSortName    TeamSort = "name"
+	TeamSortMembers TeamSort = "members"
+)
+
+func (s TeamSort) Valid() bool {
+	return s == TeamSortName || s == TeamSortMembers
+}
+
+// TeamListFilter selects the teams of /team/list. NamePrefix matches the start
+// of the team name without case; archived teams are skipped unless
+// IncludeArchived.
+type TeamListFilter struct {
+	NamePrefix      string
+	IncludeArchived bool
+	Sort            TeamSort
+	Desc            bool
+	After           *ListCursor
+	Limit           int
+}
+
+// TeamSummary is a team as listed by /team/list.
+type TeamSummary struct {
+	TeamID         int64  `json:"-"`
+	TeamName       string `json:"team_name"`
+	IsArchived     bool   `json:"is_archived"`
+	ParentTeamName string `json:"parent_team_name,omitempty"`
+	MembersCount   int64  `json:"members_count"`
+	ActiveCount    int64  `json:"active_count"`
+}
+
+// UserSort is the order of /users/list: by ID or by name. Ties are broken by
+// user ID.
+type UserSort string
+
+const (
+	UserSortID   UserSort = "user_id"
+	UserSortName UserSort = "username"
+)
+
+func (s UserSort) Valid() bool {
+	return s == UserSortID || s == UserSortName
+}
+
+// UserListFilter selects the users of /users/list; zero fields match every
+// user. Role matches the role in TeamID, or in any team without one.
+// Seniority matches the user's level, not their role in a team. NamePrefix
+// matches the start of the username without case.
+type UserListFilter struct {
+	TeamID     int64
+	IsActive   *bool
+	Role       MemberRole
+	Seniority  Seniority
+	NamePrefix string
+	Sort       UserSort
+	Desc       bool
+	After      *ListCursor
+	Limit      int
+}
+
+// UserSummary is a user as listed by /users/list, with all their teams.
+type UserSummary struct {
+	UserID    string       `json:"user_id"`
+	Username  string       `json:"username"`
+	IsActive  bool         `json:"is_active"`
+	Seniority Seniority    `json:"seniority"`
+	Teams     []Membership `json:"teams"`
+}
+
+// PRMetadata describes the change of a PR as its creator reported it. The size
+// fields are zero when unknown; CreatedAt defaults to when the PR was stored.
+type PRMetadata struct {
+	Repository   string    `json:"repository,omitempty"`
+	SourceBranch string    `json:"source_branch,omitempty"`
+	TargetBranch string    `json:"target_branch,omitempty"`
+	URL          string    `json:"url,omitempty"`
+	Description  string    `json:"description,omitempty"`
+	LinesAdded   int       `json:"lines_added"`
+	LinesRemoved int       `json:"lines_removed"`
+	FilesCount   int       `json:"files_count"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ReviewDecline is a review given up by its reviewer. Expired is set when the
+// reviewer did not accept it before the deadline and Reason is empty then.
+// AssignedAt and FirstResponseAt are those of the given up assignment; the
+// decline is the first response unless the reviewer accepted before.
+type ReviewDecline struct {
+	PRID            string     `json:"pull_request_id"`
+	ReviewerID      string     `json:"reviewer_id"`
+	Reason          string     `json:"reason,omitempty"`
+	Expired         bool       `json:"expired"`
+	DeclinedAt      time.Time  `json:"declined_at"`
+	AssignedAt      *time.Time `json:"assigned_at,omitempty"`
+	FirstResponseAt *time.Time `json:"first_response_at,omitempty"`
+}
+
+// ReviewHandover describes an open review taken from a user who left a team.
+// NewReviewerID is empty when nobody in the team could take the review over
+// and it was released.
+type ReviewHandover struct {
+	PRID          string `json:"pull_request_id"`
+	OldReviewerID string `json:"old_reviewer_id"`
+	NewReviewerID string `json:"new_reviewer_id,omitempty"`
+}
+
+// FallbackReviewer is a reviewer borrowed from another team because the PR's
+// own team could not provide enough reviewers.
+type FallbackReviewer struct {
+	UserID   string `json:"user_id"`
+	TeamName string `json:"team_name"`
+}
+
+// RequiredReviewer is a reviewer filling a slot that a label rule reserves for
+// their team.
+type RequiredReviewer struct {
+	UserID   string `json:"user_id"`
+	TeamName string `json:"team_name"`
+}
+
+// CodeownerReviewer is a reviewer required by the CODEOWNERS rule on Line.
+type CodeownerReviewer struct {
+	UserID  string `json:"user_id"`
+	Owner   string `json:"owner"`
+	Pattern string `json:"pattern"`
+	Line    int    `json:"line"`
+}
+
+// SkillMatch lists the PR tags covered by a reviewer's skills. Score is the
+// covered share of the tags.
+type SkillMatch struct {
+	UserID      string   `json:"user_id"`
+	MatchedTags []string `json:"matched_tags"`
+	Score       float64  `json:"score"`
+}
+
+type CodeownersFile struct {
+	Repository string    `json:"repository"`
+	Content    string    `json:"content"`
+	UploadedAt time.Time `json:"uploaded_at"`
+}
+
+// LabelRule requires ReviewersCount reviewers from the team on every PR with the
+// label.
+type LabelRule struct {
+	Label          string `json:"label"`
+	TeamID         int64  `json:"-"`
+	TeamName       string `json:"team_name"`
+	ReviewersCount int    `json:"reviewers_count"`
+}
+
+type PairRule struct {
+	ReviewerID string       `json:"reviewer_id"`
+	AuthorID   string       `json:"author_id"`
+	Kind       PairRuleKind `json:"kind"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+// Sources of a reviewer's place on a PR, as recorded in explanations.
+const (
+	SourceLabelRule    = "label_rule"
+	SourceCodeowners   = "codeowners"
+	SourcePreferred    = "preferred"
+	SourceFallbackPool = "fallback_pool"
+	SourceTeam         = "team"
+	SourceManual       = "manual"
+)
+
+// Assignment actions an explanation is recorded for.
+const (
+	AssignmentCreate   = "create"
+	AssignmentReassign = "reassign"
+	AssignmentQueue    = "queue"
+	AssignmentHandover = "handover"
+	AssignmentAdd      = "add"
+	AssignmentDecline  = "decline"
+	AssignmentExpire   = "expire"
+	AssignmentEscalate = "escalate"
+)
+
+// Reasons a team member cannot be picked as a reviewer of a PR.
+const (
+	IneligibleInactive       = "inactive"
+	IneligibleAbsent         = "absent"
+	IneligibleAuthor         = "author"
+	IneligibleAssigned       = "already_assigned"
+	IneligibleExcludedByRule = "excluded_by_rule"
+	IneligibleOverCapacity   = "over_capacity"
+	IneligibleDeclined       = "declined"
+)
+
+type AssignedReviewer struct {
+	UserID string `json:"user_id"`
+	Source string `json:"source"`
+}
+
+// AssignmentCandidate is a member of the PR's team as seen when reviewers were
+// picked. Eligible members are ranked by TagCoverage, then WaitMinutes until
+// their working hours, then Load, the number of open reviews they have weighted
+// by the LoadWeighting; mentors of the author win ties. Rank is the 1-based place
+// among eligible members, zero for the others.
+type AssignmentCandidate struct {
+	UserID      string   `json:"user_id"`
+	Eligible    bool     `json:"eligible"`
+	Reasons     []string `json:"ineligible_reasons"`
+	Rank        int      `json:"rank"`
+	Load        float64  `json:"load"`
+	TagCoverage int      `json:"tag_coverage"`
+	WaitMinutes int      `json:"wait_minutes"`
+	Mentor      bool     `json:"mentor"`
+	Chosen      bool     `json:"chosen"`
+}
+
+// AssignmentExplanation records why reviewers were put on a PR. ReplacedUserID
+// is set for reassignments and handovers; RecordedAt is nil in previews.
+type AssignmentExplanation struct {
+	Action         string                `json:"action"`
+	ReplacedUserID string                `json:"replaced_user_id,omitempty"`
+	Reviewers      []AssignedReviewer    `json:"reviewers"`
+	Candidates     []AssignmentCandidate `json:"candidates"`
+	RecordedAt     *time.Time            `json:"recorded_at,omitempty"`
+}
+
+type TeamMembership struct {
+	TeamName string     `json:"team_name"`
+	JoinedAt time.Time  `json:"joined_at"`
+	LeftAt   *time.Time `json:"left_at"`
+}
+
+// UserStats.TagCoverage is the share of the tags of the tagged PRs the user
+// reviews that their skills cover, zero without such PRs. DeclinedCount counts
+// the reviews the user declined, ExpiredCount the ones taken from them for not
+// being accepted in time. WeightedLoad is the current count of the user's open
+// reviews weighted by the LoadWeighting, the load reviewers are picked by,
+// whatever the stats window.
 type UserStats struct {
-	UserID          string `json:"user_id"`
-	PRReviewerCount int64  `json:"count_pr_reviewer"`
-	PRAuthorCount   int64  `json:"count_pr_author"`
+	UserID                string     `json:"user_id"`
+	PRReviewerCount       int64      `json:"count_pr_reviewer"`
+	PRAuthorCount         int64      `json:"count_pr_author"`
+	TaggedPRReviewerCount int64      `json:"count_tagged_pr_reviewer"`
+	TagCoverage           float64    `json:"tag_coverage"`
+	DeclinedCount         int64      `json:"count_declined"`
+	ExpiredCount          int64      `json:"count_ack_expired"`
+	WeightedLoad          float64    `json:"weighted_load"`
+	Bucket                *time.Time `json:"-"`
 }
 
+// DeclineReasonStats counts the declines given for a reason, compared without
+// case.
+type DeclineReasonStats struct {
+	Reason string `json:"reason"`
+	Count  int64  `json:"count"`
+}
+
+// DeclineStats counts the declined and expired reviews of a bucket, nil without
+// grouping, and its declines by reason, the most common first.
+type DeclineStats struct {
+	Bucket        *time.Time
+	DeclinedCount int64
+	ExpiredCount  int64
+	Reasons       []DeclineReasonStats
+}
+
+// PRReviewerStats counts the reviewers of a PR assigned in a bucket, nil
+// without grouping.
+type PRReviewerStats struct {
+	PRID   string
+	Bucket *time.Time
+	Count  int64
+}
+
+// TeamStats.AvgTimeToMergeMinutes is the mean time from creation to merge of
+// the team's merged PRs, zero without them.
 type TeamStats struct {
-	TeamName      string `json:"team_name"`
-	UsersCount    int64  `json:"users_count"`
-	AllPRCount    int64  `json:"all_pr_count"`
-	MergedPRCount int64  `json:"merged_pr_count"`
-	OpenPRCount   int64  `json:"open_pr_count"`
+	TeamName              string     `json:"team_name"`
+	UsersCount            int64      `json:"users_count"`
+	AllPRCount            int64      `json:"all_pr_count"`
+	MergedPRCount         int64      `json:"merged_pr_count"`
+	OpenPRCount           int64      `json:"open_pr_count"`
+	AvgTimeToMergeMinutes float64    `json:"avg_time_to_merge_minutes"`
+	Bucket                *time.Time `json:"-"`
+}
+
+// StatsGroupBy splits /stats into buckets of UTC days, ISO weeks starting on
+// Monday or calendar months.
+type StatsGroupBy string
+
+const (
+	StatsGroupByDay   StatsGroupBy = "day"
+	StatsGroupByWeek  StatsGroupBy = "week"
+	StatsGroupByMonth StatsGroupBy = "month"
+)
+
+func (g StatsGroupBy) Valid() bool {
+	return g == StatsGroupByDay || g == StatsGroupByWeek || g == StatsGroupByMonth
+}
+
+// StatsFilter selects what /stats counts; zero fields count everything. Each
+// count is windowed and grouped by its own time: PRs by created_at, merges by
+// merged_at, reviews by assigned_at and declines by declined_at. The window
+// includes From and excludes To. TeamID and UserID keep the PRs of the team
+// and the PRs the user authored or reviews. With GroupBy, every stats row has
+// the start of its bucket in Bucket.
+type StatsFilter struct {
+	From            *time.Time
+	To              *time.Time
+	TeamID          int64
+	UserID          string
+	GroupBy         StatsGroupBy
+	IncludeArchived bool
+}
+
+// Event is a notification about a pull request, stored so that consumers can
+// poll for new ones by ID.
+type Event struct {
+	ID        int64           `json:"event_id"`
+	Type      string          `json:"event_type"`
+	PRID      string          `json:"pull_request_id,omitempty"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// Absence is a period during which the user is not picked as a reviewer.
+// CalendarUID is set for periods imported from an iCalendar file. With
+// ReassignReviews the user's open reviews are handed over once the period
+// starts; HandedOverAt records when that happened.
+type Absence struct {
+	ID              int64      `json:"absence_id"`
+	UserID          string     `json:"user_id"`
+	StartsAt        time.Time  `json:"starts_at"`
+	EndsAt          time.Time  `json:"ends_at"`
+	Reason          string     `json:"reason"`
+	ReassignReviews bool       `json:"reassign_reviews"`
+	CalendarUID     string     `json:"calendar_uid,omitempty"`
+	HandedOverAt    *time.Time `json:"handed_over_at,omitempty"`
+}
+
+// WorkingHours is a user's weekly working window. WorkDays are ISO weekdays,
+// 1 for Monday to 7 for Sunday; StartMinute and EndMinute count from local
+// midnight in TimeZone.
+type WorkingHours struct {
+	TimeZone    string
+	WorkDays    []int
+	StartMinute int
+	EndMinute   int
+}
+
+// ReviewPolicy constrains the reviewer set of a team's PRs: at least MinSeniors
+// senior reviewers, no junior without a mid or senior reviewer beside them,
+// and no bots.
+type ReviewPolicy struct {
+	MinSeniors   int  `json:"min_seniors"`
+	NoSoleJunior bool `json:"no_sole_junior"`
+	ExcludeBots  bool `json:"exclude_bots"`
+}
+
+// SLAPolicy sets how long, in the reviewer's working time, a review of the
+// team's PRs may stay unaccepted. ResponseMinutes marks the review as breached;
+// the escalation ladder then reminds the reviewer, reassigns the review and
+// notifies the team leads once their thresholds pass. A zero threshold skips
+// its step.
+type SLAPolicy struct {
+	ResponseMinutes        int `json:"response_minutes"`
+	RemindAfterMinutes     int `json:"remind_after_minutes"`
+	ReassignAfterMinutes   int `json:"reassign_after_minutes"`
+	NotifyLeadAfterMinutes int `json:"notify_lead_after_minutes"`
+}
+
+// Steps of the SLA escalation ladder, in order.
+const (
+	SLAStepRemind     = "remind"
+	SLAStepReassign   = "reassign"
+	SLAStepNotifyLead = "notify_lead"
+)
+
+// SLAEscalation is a step of the escalation ladder taken for a review.
+// NewReviewerID is set when the review was reassigned, LeadIDs when the team
+// leads were notified.
+type SLAEscalation struct {
+	ReviewerID    string   `json:"reviewer_id"`
+	Step          string   `json:"step"`
+	AgeMinutes    int      `json:"age_minutes"`
+	NewReviewerID string   `json:"new_reviewer_id,omitempty"`
+	LeadIDs       []string `json:"lead_ids,omitempty"`
+}
+
+// SLABreach is an open review past its team's response time. AgeMinutes is the
+// reviewer's working time since the assignment; Escalation is the last step of
+// the ladder taken, empty if none.
+type SLABreach struct {
+	PRID       string    `json:"pull_request_id"`
+	PRName     string    `json:"pull_request_name"`
+	TeamName   string    `json:"team_name"`
+	ReviewerID string    `json:"reviewer_id"`
+	AssignedAt time.Time `json:"assigned_at"`
+	BreachedAt time.Time `json:"breached_at"`
+	AgeMinutes int       `json:"age_minutes"`
+	Escalation string    `json:"escalation,omitempty"`
+}
+
+// LoadWeightingMode is how an open review adds to its reviewer's load when
+// reviewers are picked.
+type LoadWeightingMode string
+
+const (
+	// LoadWeightingCount counts every review as one.
+	LoadWeightingCount LoadWeightingMode = "count"
+	// LoadWeightingBuckets weighs a review by the bucket of its lines changed.
+	LoadWeightingBuckets LoadWeightingMode = "buckets"
+	// LoadWeightingLog weighs a review by 1 + log2(1 + lines changed / LogBaseLines).
+	LoadWeightingLog LoadWeightingMode = "log"
+)
+
+func (m LoadWeightingMode) Valid() bool {
+	return m == LoadWeightingCount || m == LoadWeightingBuckets || m == LoadWeightingLog
+}
+
+// LoadWeightBucket weighs the reviews of PRs with up to MaxLines lines added
+// and removed that no smaller bucket takes.
+type LoadWeightBucket struct {
+	MaxLines int     `json:"max_lines"`
+	Weight   float64 `json:"weight"`
+}
+
+// LoadWeighting sets the weight of open reviews in the reviewer load. PRs
+// larger than every bucket weigh as the largest one. Reviews of merged and
+// closed PRs always count as one.
+type LoadWeighting struct {
+	Mode         LoadWeightingMode  `json:"mode"`
+	LogBaseLines int                `json:"log_base_lines,omitempty"`
+	Buckets      []LoadWeightBucket `json:"buckets,omitempty"`
+}
+
+// Holiday is a day off for a whole team, Date in the form 2006-01-02.
+type Holiday struct {
+	Date string `json:"date"`
+	Name string `json:"name"`
+}
+
+// IdempotencyRecord is a stored response for an Idempotency-Key. StatusCode is
+// zero while the first request with the key is still being processed.
+type IdempotencyRecord struct {
+	Key         string
+	RequestHash string
+	StatusCode  int
+	Body        []byte
 }
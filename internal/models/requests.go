@@ -1,12 +1,18 @@
 package models
 
+import "time"
+
+type TeamMember struct {
+	UserID    string     `json:"user_id"`
+	Name      string     `json:"username"`
+	IsActive  bool       `json:"is_active"`
+	Role      MemberRole `json:"role,omitempty"`
+	IsPrimary bool       `json:"is_primary,omitempty"`
+}
+
 type AddTeamRequest struct {
-	TeamName string `json:"team_name"`
-	Members  []struct {
-		UserID   string `json:"user_id"`
-		Name     string `json:"username"`
-		IsActive bool   `json:"is_active"`
-	} `json:"members"`
+	TeamName string       `json:"team_name"`
+	Members  []TeamMember `json:"members"`
 }
 
 type SetUserIsActiveRequest struct {
@@ -14,19 +20,52 @@ type SetUserIsActiveRequest struct {
 	IsActive bool   `json:"is_active"`
 }
 
+// CreatedAt is when the PR was opened in the repository, now if empty.
+// FilesCount defaults to the number of ChangedFiles.
 type CreatePRRequest struct {
-	PRID     string `json:"pull_request_id"`
-	PRName   string `json:"pull_request_name"`
-	AuthorID string `json:"author_id"`
+	PRID         string     `json:"pull_request_id"`
+	PRName       string     `json:"pull_request_name"`
+	AuthorID     string     `json:"author_id"`
+	TeamName     string     `json:"team_name,omitempty"`
+	Labels       []string   `json:"labels,omitempty"`
+	Repository   string     `json:"repository,omitempty"`
+	ChangedFiles []string   `json:"changed_files,omitempty"`
+	Preferred    []string   `json:"preferred_reviewers,omitempty"`
+	Tags         []string   `json:"tags,omitempty"`
+	Priority     int        `json:"priority,omitempty"`
+	SourceBranch string     `json:"source_branch,omitempty"`
+	TargetBranch string     `json:"target_branch,omitempty"`
+	URL          string     `json:"url,omitempty"`
+	Description  string     `json:"description,omitempty"`
+	LinesAdded   int        `json:"lines_added,omitempty"`
+	LinesRemoved int        `json:"lines_removed,omitempty"`
+	FilesCount   int        `json:"files_count,omitempty"`
+	CreatedAt    *time.Time `json:"created_at,omitempty"`
 }
 
 type MergePRRequest struct {
 	PRID string `json:"pull_request_id"`
 }
 
+// NewReviewerID is optional; without it the replacement is picked
+// automatically.
 type ReassignPRRequest struct {
 	PRID          string `json:"pull_request_id"`
 	OldReviewerID string `json:"old_reviewer_id"`
+	NewReviewerID string `json:"new_reviewer_id"`
+}
+
+type ReviewerRequest struct {
+	PRID       string `json:"pull_request_id"`
+	ReviewerID string `json:"reviewer_id"`
+}
+
+// Reason is required to decline.
+type AckReviewRequest struct {
+	PRID       string      `json:"pull_request_id"`
+	ReviewerID string      `json:"reviewer_id"`
+	Decision   AckDecision `json:"decision"`
+	Reason     string      `json:"reason"`
 }
 
 type DeactivateAllUsersInTeamRequest struct {
@@ -36,3 +75,145 @@ type DeactivateAllUsersInTeamRequest struct {
 type DeactivateUsersByIDRequest struct {
 	UserNames []string `json:"user_names"`
 }
+
+type AddTeamMembersRequest struct {
+	TeamName string       `json:"team_name"`
+	Members  []TeamMember `json:"members"`
+}
+
+type RemoveTeamMemberRequest struct {
+	TeamName string `json:"team_name"`
+	UserID   string `json:"user_id"`
+}
+
+type MoveUserTeamRequest struct {
+	UserID       string `json:"user_id"`
+	FromTeamName string `json:"from_team_name,omitempty"`
+	TeamName     string `json:"team_name"`
+}
+
+type UpdateTeamMemberRequest struct {
+	TeamName  string     `json:"team_name"`
+	UserID    string     `json:"user_id"`
+	Role      MemberRole `json:"role,omitempty"`
+	IsPrimary *bool      `json:"is_primary,omitempty"`
+}
+
+type RenameTeamRequest struct {
+	TeamName    string `json:"team_name"`
+	NewTeamName string `json:"new_team_name"`
+}
+
+type ArchiveTeamRequest struct {
+	TeamName string `json:"team_name"`
+}
+
+type DeleteTeamRequest struct {
+	TeamName string `json:"team_name"`
+	Force    bool   `json:"force"`
+}
+
+type SetTeamParentRequest struct {
+	TeamName           string `json:"team_name"`
+	ParentTeamName     string `json:"parent_team_name"`
+	FallbackToSiblings bool   `json:"fallback_to_siblings"`
+}
+
+type SetLabelRuleRequest struct {
+	Label          string `json:"label"`
+	TeamName       string `json:"team_name"`
+	ReviewersCount int    `json:"reviewers_count"`
+}
+
+type DeleteLabelRuleRequest struct {
+	Label    string `json:"label"`
+	TeamName string `json:"team_name"`
+}
+
+type UploadCodeownersRequest struct {
+	Repository string `json:"repository"`
+	Content    string `json:"content"`
+}
+
+type SetUserSkillsRequest struct {
+	UserID string   `json:"user_id"`
+	Skills []string `json:"skills"`
+}
+
+type ClosePRRequest struct {
+	PRID string `json:"pull_request_id"`
+}
+
+// MaxOpenReviews nil removes the cap.
+type SetUserCapacityRequest struct {
+	UserID         string `json:"user_id"`
+	MaxOpenReviews *int   `json:"max_open_reviews"`
+}
+
+type SetTeamCapacityRequest struct {
+	TeamName       string `json:"team_name"`
+	MaxOpenReviews *int   `json:"max_open_reviews"`
+}
+
+type AddAbsenceRequest struct {
+	UserID          string    `json:"user_id"`
+	StartsAt        time.Time `json:"starts_at"`
+	EndsAt          time.Time `json:"ends_at"`
+	Reason          string    `json:"reason"`
+	ReassignReviews bool      `json:"reassign_reviews"`
+}
+
+type DeleteAbsenceRequest struct {
+	AbsenceID int64 `json:"absence_id"`
+}
+
+// Calendar is the content of an iCalendar (.ics) file.
+type ImportAbsencesRequest struct {
+	UserID          string `json:"user_id"`
+	Calendar        string `json:"calendar"`
+	ReassignReviews bool   `json:"reassign_reviews"`
+}
+
+// Start and End are local times HH:MM, End may be 24:00. An empty TimeZone
+// removes the working hours.
+type SetWorkingHoursRequest struct {
+	UserID   string `json:"user_id"`
+	TimeZone string `json:"time_zone"`
+	WorkDays []int  `json:"work_days"`
+	Start    string `json:"start"`
+	End      string `json:"end"`
+}
+
+// Calendar is the content of an iCalendar (.ics) file whose all-day events are
+// added to Holidays.
+type SetTeamHolidaysRequest struct {
+	TeamName string    `json:"team_name"`
+	Holidays []Holiday `json:"holidays"`
+	Calendar string    `json:"calendar"`
+}
+
+type SetSeniorityRequest struct {
+	UserID    string    `json:"user_id"`
+	Seniority Seniority `json:"seniority"`
+}
+
+type SetReviewPolicyRequest struct {
+	TeamName string `json:"team_name"`
+	ReviewPolicy
+}
+
+type SetSLAPolicyRequest struct {
+	TeamName string `json:"team_name"`
+	SLAPolicy
+}
+
+type SetPairRuleRequest struct {
+	ReviewerID string       `json:"reviewer_id"`
+	AuthorID   string       `json:"author_id"`
+	Kind       PairRuleKind `json:"kind"`
+}
+
+type DeletePairRuleRequest struct {
+	ReviewerID string `json:"reviewer_id"`
+	AuthorID   string `json:"author_id"`
+}
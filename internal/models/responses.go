@@ -17,27 +17,42 @@ type ErrorResponse struct {
 
 type GetTeamResponse struct {
 	Team struct {
-		Name    string `json:"team_name"`
-		Members []User `json:"members"`
+		Name               string   `json:"team_name"`
+		IsArchived         bool     `json:"is_archived"`
+		ParentTeamName     string   `json:"parent_team_name,omitempty"`
+		FallbackToSiblings bool     `json:"fallback_to_siblings"`
+		Members            []Member `json:"members"`
 	} `json:"team"`
 }
 
 type SetUserIsActiveResponse struct {
 	User struct {
-		UserID   string `json:"user_id"`
-		Username string `json:"username"`
-		TeamName string `json:"team_name"`
-		IsActive bool   `json:"is_active"`
+		UserID   string       `json:"user_id"`
+		Username string       `json:"username"`
+		TeamName string       `json:"team_name"`
+		IsActive bool         `json:"is_active"`
+		Teams    []Membership `json:"teams"`
 	} `json:"user"`
 }
 
 type CreatePRResponse struct {
 	PR struct {
-		PRID      string   `json:"pull_request_id"`
-		PRName    string   `json:"pull_request_name"`
-		AuthorID  string   `json:"author_id"`
-		Status    PRStatus `json:"status"`
-		Reviewers []string `json:"assigned_reviewers"`
+		PRID     string   `json:"pull_request_id"`
+		PRName   string   `json:"pull_request_name"`
+		AuthorID string   `json:"author_id"`
+		Status   PRStatus `json:"status"`
+		TeamName string   `json:"team_name,omitempty"`
+		Labels   []string `json:"labels"`
+		PRMetadata
+		Reviewers          []string            `json:"assigned_reviewers"`
+		FallbackReviewers  []FallbackReviewer  `json:"fallback_reviewers"`
+		RequiredReviewers  []RequiredReviewer  `json:"required_reviewers"`
+		CodeownerReviewers []CodeownerReviewer `json:"codeowner_reviewers"`
+		PreferredReviewers []string            `json:"preferred_reviewers"`
+		Tags               []string            `json:"tags"`
+		SkillMatches       []SkillMatch        `json:"skill_matches"`
+		AssignmentStatus   string              `json:"assignment_status,omitempty"`
+		PendingReviewers   int                 `json:"pending_reviewers"`
 	} `json:"pr"`
 }
 
@@ -49,18 +64,41 @@ type MergePRResponse struct {
 		Status    PRStatus  `json:"status"`
 		Reviewers []string  `json:"assigned_reviewers"`
 		MergedAt  time.Time `json:"mergedAt"`
+		PRMetadata
 	} `json:"pr"`
 }
 
+// ReviewersPR is a PR as returned after its reviewers change.
+type ReviewersPR struct {
+	PRID     string   `json:"pull_request_id"`
+	PRName   string   `json:"pull_request_name"`
+	AuthorID string   `json:"author_id"`
+	Status   PRStatus `json:"status"`
+	PRMetadata
+	Reviewers          []string            `json:"assigned_reviewers"`
+	FallbackReviewers  []FallbackReviewer  `json:"fallback_reviewers"`
+	RequiredReviewers  []RequiredReviewer  `json:"required_reviewers"`
+	CodeownerReviewers []CodeownerReviewer `json:"codeowner_reviewers"`
+	AssignmentStatus   string              `json:"assignment_status,omitempty"`
+	PendingReviewers   int                 `json:"pending_reviewers"`
+}
+
 type ReassignPRResponse struct {
-	PR struct {
-		PRID      string   `json:"pull_request_id"`
-		PRName    string   `json:"pull_request_name"`
-		AuthorID  string   `json:"author_id"`
-		Status    PRStatus `json:"status"`
-		Reviewers []string `json:"assigned_reviewers"`
-	} `json:"pr"`
-	ReplacedBy string `json:"replaced_by"`
+	PR         ReviewersPR `json:"pr"`
+	ReplacedBy string      `json:"replaced_by"`
+}
+
+type ReviewerResponse struct {
+	PR ReviewersPR `json:"pr"`
+}
+
+// AckReviewResponse.AcceptedAt is set for an accepted review, ReplacedBy for a
+// declined one that found a replacement.
+type AckReviewResponse struct {
+	PR         ReviewersPR `json:"pr"`
+	Decision   AckDecision `json:"decision"`
+	AcceptedAt *time.Time  `json:"accepted_at,omitempty"`
+	ReplacedBy string      `json:"replaced_by,omitempty"`
 }
 
 type GetReviewResponse struct {
@@ -69,15 +107,47 @@ type GetReviewResponse struct {
 }
 
 type GetStatsUsersResponse struct {
+	PRStats []UserStats       `json:"statistic"`
+	Buckets []UserStatsBucket `json:"buckets,omitempty"`
+}
+
+type UserStatsBucket struct {
+	Start   time.Time   `json:"bucket_start"`
 	PRStats []UserStats `json:"statistic"`
 }
 
 type GetStatsTeamsResponse struct {
+	PRStats []TeamStats       `json:"statistic"`
+	Buckets []TeamStatsBucket `json:"buckets,omitempty"`
+}
+
+type TeamStatsBucket struct {
+	Start   time.Time   `json:"bucket_start"`
 	PRStats []TeamStats `json:"statistic"`
 }
 
+type GetStatsDeclinesResponse struct {
+	DeclinedCount int64                `json:"count_declined"`
+	ExpiredCount  int64                `json:"count_ack_expired"`
+	Reasons       []DeclineReasonStats `json:"statistic"`
+	Buckets       []DeclineStatsBucket `json:"buckets,omitempty"`
+}
+
+type DeclineStatsBucket struct {
+	Start         time.Time            `json:"bucket_start"`
+	DeclinedCount int64                `json:"count_declined"`
+	ExpiredCount  int64                `json:"count_ack_expired"`
+	Reasons       []DeclineReasonStats `json:"statistic"`
+}
+
 type GetStatsPRsResponse struct {
 	PRStats map[string]int64 `json:"statistic_count_reviewers"`
+	Buckets []PRStatsBucket  `json:"buckets,omitempty"`
+}
+
+type PRStatsBucket struct {
+	Start   time.Time        `json:"bucket_start"`
+	PRStats map[string]int64 `json:"statistic_count_reviewers"`
 }
 
 type DeactivateAllUsersInTeamResponse struct {
@@ -89,3 +159,201 @@ type DeactivateUsersByIDResponse struct {
 	Users         []User   `json:"users"`
 	NotFoundUsers []string `json:"not_found_users"`
 }
+
+type AddTeamMembersResponse struct {
+	Team struct {
+		Name    string   `json:"team_name"`
+		Members []Member `json:"members"`
+	} `json:"team"`
+}
+
+type RemoveTeamMemberResponse struct {
+	TeamName  string           `json:"team_name"`
+	UserID    string           `json:"user_id"`
+	Handovers []ReviewHandover `json:"reassigned_reviews"`
+}
+
+type MoveUserTeamResponse struct {
+	UserID      string           `json:"user_id"`
+	OldTeamName string           `json:"old_team_name"`
+	NewTeamName string           `json:"new_team_name"`
+	Handovers   []ReviewHandover `json:"reassigned_reviews"`
+}
+
+type GetUserTeamHistoryResponse struct {
+	UserID  string           `json:"user_id"`
+	History []TeamMembership `json:"history"`
+}
+
+type RenameTeamResponse struct {
+	OldTeamName string `json:"old_team_name"`
+	TeamName    string `json:"team_name"`
+}
+
+type ArchiveTeamResponse struct {
+	TeamName string `json:"team_name"`
+	Users    []User `json:"users"`
+}
+
+type DeleteTeamResponse struct {
+	TeamName        string           `json:"team_name"`
+	ReleasedReviews []ReviewHandover `json:"released_reviews"`
+}
+
+type UpdateTeamMemberResponse struct {
+	TeamName string `json:"team_name"`
+	Member   Member `json:"member"`
+}
+
+type SetTeamParentResponse struct {
+	TeamName           string `json:"team_name"`
+	ParentTeamName     string `json:"parent_team_name,omitempty"`
+	FallbackToSiblings bool   `json:"fallback_to_siblings"`
+}
+
+type GetLabelRulesResponse struct {
+	Rules []LabelRule `json:"rules"`
+}
+
+type CodeownersRule struct {
+	Line    int      `json:"line"`
+	Pattern string   `json:"pattern"`
+	Owners  []string `json:"owners"`
+}
+
+type GetCodeownersResponse struct {
+	Repository string           `json:"repository"`
+	UploadedAt time.Time        `json:"uploaded_at"`
+	Content    string           `json:"content,omitempty"`
+	Rules      []CodeownersRule `json:"rules"`
+}
+
+type UserSkillsResponse struct {
+	UserID string   `json:"user_id"`
+	Skills []string `json:"skills"`
+}
+
+type ClosePRResponse struct {
+	PR struct {
+		PRID      string    `json:"pull_request_id"`
+		PRName    string    `json:"pull_request_name"`
+		AuthorID  string    `json:"author_id"`
+		Status    PRStatus  `json:"status"`
+		Reviewers []string  `json:"assigned_reviewers"`
+		ClosedAt  time.Time `json:"closedAt"`
+		PRMetadata
+	} `json:"pr"`
+}
+
+type SetUserCapacityResponse struct {
+	UserID         string `json:"user_id"`
+	MaxOpenReviews *int   `json:"max_open_reviews"`
+}
+
+type SetTeamCapacityResponse struct {
+	TeamName       string `json:"team_name"`
+	MaxOpenReviews *int   `json:"max_open_reviews"`
+}
+
+type GetEventsResponse struct {
+	Events []Event `json:"events"`
+}
+
+type AbsenceResponse struct {
+	Absence Absence `json:"absence"`
+}
+
+// IsAvailable is false while the user is inactive or inside an absence.
+type UserAbsencesResponse struct {
+	UserID      string    `json:"user_id"`
+	IsActive    bool      `json:"is_active"`
+	IsAvailable bool      `json:"is_available"`
+	Absences    []Absence `json:"absences"`
+}
+
+type ImportAbsencesResponse struct {
+	UserID   string    `json:"user_id"`
+	Imported int       `json:"imported"`
+	Skipped  int       `json:"skipped"`
+	Absences []Absence `json:"absences"`
+}
+
+// NextWorkingAt is the start of the user's next working window, or now if they
+// are working. Users without working hours are always working.
+type WorkingHoursResponse struct {
+	UserID        string     `json:"user_id"`
+	TimeZone      string     `json:"time_zone,omitempty"`
+	WorkDays      []int      `json:"work_days,omitempty"`
+	Start         string     `json:"start,omitempty"`
+	End           string     `json:"end,omitempty"`
+	IsWorkingNow  bool       `json:"is_working_now"`
+	NextWorkingAt *time.Time `json:"next_working_at,omitempty"`
+}
+
+type TeamHolidaysResponse struct {
+	TeamName string    `json:"team_name"`
+	Holidays []Holiday `json:"holidays"`
+}
+
+type SetSeniorityResponse struct {
+	UserID    string    `json:"user_id"`
+	Seniority Seniority `json:"seniority"`
+}
+
+type ReviewPolicyResponse struct {
+	TeamName string `json:"team_name"`
+	ReviewPolicy
+}
+
+type SLAPolicyResponse struct {
+	TeamName string `json:"team_name"`
+	SLAPolicy
+}
+
+type GetSLABreachesResponse struct {
+	Breaches []SLABreach `json:"breaches"`
+}
+
+type GetPairRulesResponse struct {
+	Rules []PairRule `json:"rules"`
+}
+
+type AssignPreviewResponse struct {
+	CreatePRResponse
+	Explanation AssignmentExplanation `json:"explanation"`
+}
+
+type ExplainPRResponse struct {
+	PRID         string                  `json:"pull_request_id"`
+	Explanations []AssignmentExplanation `json:"explanations"`
+}
+
+type ListPRsResponse struct {
+	PRs        []PRSummary `json:"pull_requests"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+type ListTeamsResponse struct {
+	Teams      []TeamSummary `json:"teams"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+type ListUsersResponse struct {
+	Users      []UserSummary `json:"users"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+type GetPRResponse struct {
+	PR PRDetails `json:"pr"`
+}
+
+type GetInboxResponse struct {
+	UserID     string      `json:"user_id"`
+	Items      []InboxItem `json:"reviews"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+type GetInboxCountResponse struct {
+	UserID string `json:"user_id"`
+	InboxCount
+}
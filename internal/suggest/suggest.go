@@ -0,0 +1,467 @@
+// Package suggest ranks reviewers for a commit range of a local git clone by
+// who wrote the lines the range changes and who recently worked on the same
+// files. It only runs git against the working copy and never talks to a
+// remote, so it can be used offline on CI runners.
+package suggest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Options tune the ranking. Every changed line last touched by a person gives
+// them LineWeight points, every commit of theirs to a changed file within
+// Window before the head commit gives up to CommitWeight points, decaying
+// linearly with the commit's age.
+type Options struct {
+	Window       time.Duration
+	LineWeight   float64
+	CommitWeight float64
+}
+
+var DefaultOptions = Options{
+	Window:       180 * 24 * time.Hour,
+	LineWeight:   1,
+	CommitWeight: 5,
+}
+
+// Candidate is a person who knows the changed code. UserID is empty when the
+// email has no mapping.
+type Candidate struct {
+	Email   string
+	UserID  string
+	Score   float64
+	Lines   int
+	Commits int
+}
+
+// Result is the analysis of a commit range. Authors are the emails of the
+// commits in the range, they are never suggested.
+type Result struct {
+	Base         string
+	Head         string
+	ChangedFiles []string
+	Authors      []string
+	Candidates   []Candidate
+}
+
+// lineRange is an inclusive range of line numbers in the base version of a file.
+type lineRange struct {
+	from, to int
+}
+
+// fileChange is a file changed by the range. oldPath is empty for added files.
+type fileChange struct {
+	path    string
+	oldPath string
+	ranges  []lineRange
+}
+
+// ParseRange splits "base..head" into its ends. A missing head means HEAD.
+func ParseRange(s string) (base, head string, err error) {
+	if strings.Contains(s, "...") {
+		return "", "", fmt.Errorf("invalid range %q: use base..head", s)
+	}
+	base, head, ok := strings.Cut(s, "..")
+	if !ok || base == "" {
+		return "", "", fmt.Errorf("invalid range %q: use base..head", s)
+	}
+	if head == "" {
+		head = "HEAD"
+	}
+	return base, head, nil
+}
+
+// Analyze ranks the people who know the code changed between base and head in
+// the repository at repoPath. Changes are taken relative to the merge base, as
+// a pull request of head into base would show them. emails maps lowercase
+// commit emails to user ids.
+func Analyze(ctx context.Context, repoPath, base, head string, emails map[string]string, opts Options) (*Result, error) {
+	g := gitRepo{path: repoPath}
+
+	headSHA, err := g.revParse(ctx, head)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := g.revParse(ctx, base); err != nil {
+		return nil, err
+	}
+	mergeBase, err := g.output(ctx, "merge-base", base, headSHA)
+	if err != nil {
+		return nil, err
+	}
+	mergeBase = strings.TrimSpace(mergeBase)
+
+	diff, err := g.output(ctx, "diff", "--no-color", "--no-ext-diff", "--unified=0", "-M", mergeBase, headSHA)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := parseDiff(strings.NewReader(diff))
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{Base: mergeBase, Head: headSHA, ChangedFiles: make([]string, 0, len(changes))}
+	for _, c := range changes {
+		res.ChangedFiles = append(res.ChangedFiles, c.path)
+	}
+
+	authors, err := g.output(ctx, "log", "--format=%aE", mergeBase+".."+headSHA)
+	if err != nil {
+		return nil, err
+	}
+	excluded := make(map[string]struct{})
+	for _, a := range strings.Fields(authors) {
+		a = normalizeEmail(a)
+		if _, ok := excluded[a]; !ok {
+			excluded[a] = struct{}{}
+			res.Authors = append(res.Authors, a)
+		}
+	}
+	sort.Strings(res.Authors)
+
+	scores := make(map[string]*Candidate)
+	candidate := func(email string) *Candidate {
+		c, ok := scores[email]
+		if !ok {
+			c = &Candidate{Email: email, UserID: emails[email]}
+			scores[email] = c
+		}
+		return c
+	}
+
+	oldPaths := make([]string, 0, len(changes))
+	for _, c := range changes {
+		if c.oldPath == "" {
+			continue
+		}
+		oldPaths = append(oldPaths, c.oldPath)
+		if len(c.ranges) == 0 {
+			continue
+		}
+		lines, err := g.blame(ctx, mergeBase, c.oldPath, c.ranges)
+		if err != nil {
+			return nil, err
+		}
+		for email, n := range lines {
+			candidate(email).Lines += n
+		}
+	}
+
+	if len(oldPaths) > 0 && opts.Window > 0 {
+		headTime, err := g.commitTime(ctx, headSHA)
+		if err != nil {
+			return nil, err
+		}
+
+		// Filtering on author dates here rather than with --since, which looks
+		// at committer dates and stops at the first old commit it walks into.
+		args := []string{"log", "--no-merges", "--format=%aE %at", mergeBase, "--"}
+		out, err := g.output(ctx, append(args, oldPaths...)...)
+		if err != nil {
+			return nil, err
+		}
+		sc := bufio.NewScanner(strings.NewReader(out))
+		for sc.Scan() {
+			email, ts, ok := strings.Cut(sc.Text(), " ")
+			if !ok {
+				continue
+			}
+			sec, err := strconv.ParseInt(ts, 10, 64)
+			if err != nil {
+				continue
+			}
+			age := headTime.Sub(time.Unix(sec, 0))
+			if age >= opts.Window {
+				continue
+			}
+			if age < 0 {
+				age = 0
+			}
+			c := candidate(normalizeEmail(email))
+			c.Commits++
+			c.Score += opts.CommitWeight * (1 - float64(age)/float64(opts.Window))
+		}
+	}
+
+	res.Candidates = make([]Candidate, 0, len(scores))
+	for email, c := range scores {
+		if _, ok := excluded[email]; ok {
+			continue
+		}
+		c.Score += opts.LineWeight * float64(c.Lines)
+		res.Candidates = append(res.Candidates, *c)
+	}
+	sort.Slice(res.Candidates, func(i, j int) bool {
+		a, b := res.Candidates[i], res.Candidates[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.Email < b.Email
+	})
+
+	return res, nil
+}
+
+// UserIDs returns the mapped user ids of the candidates in rank order, at most
+// limit of them (all if limit is not positive).
+func (r *Result) UserIDs(limit int) []string {
+	ids := make([]string, 0)
+	seen := make(map[string]struct{})
+	for _, c := range r.Candidates {
+		if limit > 0 && len(ids) == limit {
+			break
+		}
+		if c.UserID == "" {
+			continue
+		}
+		if _, ok := seen[c.UserID]; ok {
+			continue
+		}
+		seen[c.UserID] = struct{}{}
+		ids = append(ids, c.UserID)
+	}
+	return ids
+}
+
+// ReadEmails reads an email to user id mapping, one "email user_id" pair per
+// line. Empty lines and lines starting with # are skipped.
+func ReadEmails(r io.Reader) (map[string]string, error) {
+	emails := make(map[string]string)
+
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected \"email user_id\"", line)
+		}
+		emails[normalizeEmail(fields[0])] = fields[1]
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return emails, nil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.Trim(strings.TrimSpace(email), "<>"))
+}
+
+// parseDiff reads the output of git diff --unified=0 and returns the changed
+// files with the base line ranges the change replaces. A pure insertion is
+// attributed to the line it follows.
+func parseDiff(r io.Reader) ([]fileChange, error) {
+	var (
+		changes []fileChange
+		cur     *fileChange
+		inHunks bool
+	)
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			path := headerPath(line[len("diff --git "):])
+			changes = append(changes, fileChange{path: path, oldPath: path})
+			cur = &changes[len(changes)-1]
+			inHunks = false
+		case cur == nil:
+			continue
+		case strings.HasPrefix(line, "@@ "):
+			inHunks = true
+			start, count, err := parseHunkOld(line)
+			if err != nil {
+				return nil, err
+			}
+			if count == 0 {
+				if start == 0 {
+					continue
+				}
+				count = 1
+			}
+			cur.ranges = append(cur.ranges, lineRange{from: start, to: start + count - 1})
+		case inHunks:
+			// Removed lines may look like file headers.
+			continue
+		case strings.HasPrefix(line, "new file mode "):
+			cur.oldPath = ""
+		case strings.HasPrefix(line, "--- "):
+			cur.oldPath = diffPath(line[4:], "a/")
+		case strings.HasPrefix(line, "+++ "):
+			cur.path = diffPath(line[4:], "b/")
+			if cur.path == "" {
+				cur.path = cur.oldPath
+			}
+		case strings.HasPrefix(line, "rename from "):
+			cur.oldPath = unquote(line[len("rename from "):])
+		case strings.HasPrefix(line, "rename to "):
+			cur.path = unquote(line[len("rename to "):])
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	// The header path is ambiguous for names with spaces; such a file is
+	// only found through its ---/+++ or rename lines.
+	files := changes[:0]
+	for _, c := range changes {
+		if c.path != "" {
+			files = append(files, c)
+		}
+	}
+	return files, nil
+}
+
+// parseHunkOld returns the base side of a hunk header "@@ -start,count +... @@".
+func parseHunkOld(line string) (start, count int, err error) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 || !strings.HasPrefix(fields[1], "-") {
+		return 0, 0, fmt.Errorf("invalid hunk header %q", line)
+	}
+	s, c, ok := strings.Cut(fields[1][1:], ",")
+	start, err = strconv.Atoi(s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid hunk header %q", line)
+	}
+	count = 1
+	if ok {
+		count, err = strconv.Atoi(c)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid hunk header %q", line)
+		}
+	}
+	return start, count, nil
+}
+
+// headerPath returns the path of "a/path b/path", the part of a diff --git
+// line that is the only source of the name for binary files and mode changes.
+func headerPath(s string) string {
+	if strings.HasPrefix(s, `"`) {
+		return ""
+	}
+	n := len(s) - len(" b/") - len("a/")
+	if n <= 0 || n%2 != 0 {
+		return ""
+	}
+	path := s[len("a/") : len("a/")+n/2]
+	if s != "a/"+path+" b/"+path {
+		return ""
+	}
+	return path
+}
+
+func diffPath(s, prefix string) string {
+	s = unquote(strings.TrimSuffix(s, "\t"))
+	if s == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(s, prefix)
+}
+
+func unquote(s string) string {
+	if strings.HasPrefix(s, `"`) {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+	}
+	return s
+}
+
+type gitRepo struct {
+	path string
+}
+
+func (g gitRepo) output(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", g.path, "-c", "core.quotePath=false"}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(stderr.String()))
+		}
+		return "", err
+	}
+	return string(out), nil
+}
+
+func (g gitRepo) revParse(ctx context.Context, rev string) (string, error) {
+	out, err := g.output(ctx, "rev-parse", "--verify", "--end-of-options", rev+"^{commit}")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func (g gitRepo) commitTime(ctx context.Context, rev string) (time.Time, error) {
+	out, err := g.output(ctx, "show", "-s", "--format=%ct", rev)
+	if err != nil {
+		return time.Time{}, err
+	}
+	sec, err := strconv.ParseInt(strings.TrimSpace(out), 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(sec, 0), nil
+}
+
+// blame counts the lines of the ranges of path at rev last changed by each
+// author email.
+func (g gitRepo) blame(ctx context.Context, rev, path string, ranges []lineRange) (map[string]int, error) {
+	args := []string{"blame", "--porcelain", "-w"}
+	for _, r := range ranges {
+		args = append(args, "-L", fmt.Sprintf("%d,%d", r.from, r.to))
+	}
+	out, err := g.output(ctx, append(args, rev, "--", path)...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Porcelain output prints a commit's headers only on its first line, so
+	// later lines are resolved through the commit hash.
+	commitEmails := make(map[string]string)
+	lines := make(map[string]int)
+	var commit string
+
+	sc := bufio.NewScanner(strings.NewReader(out))
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	expectHeader := true
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case expectHeader:
+			commit, _, _ = strings.Cut(line, " ")
+			expectHeader = false
+		case strings.HasPrefix(line, "\t"):
+			if email, ok := commitEmails[commit]; ok {
+				lines[email]++
+			}
+			expectHeader = true
+		case strings.HasPrefix(line, "author-mail "):
+			commitEmails[commit] = normalizeEmail(line[len("author-mail "):])
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
@@ -0,0 +1,295 @@
+package suggest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRepo is a throwaway git repository whose commits get fixed dates, so
+// the decayed commit scores are exact.
+type testRepo struct {
+	t   *testing.T
+	dir string
+}
+
+func newTestRepo(t *testing.T) *testRepo {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	r := &testRepo{t: t, dir: t.TempDir()}
+	r.git(time.Time{}, "", "init", "-q")
+	r.git(time.Time{}, "", "checkout", "-q", "-b", "main")
+	return r
+}
+
+func (r *testRepo) git(at time.Time, email string, args ...string) string {
+	cmd := exec.Command("git", append([]string{"-C", r.dir}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"HOME="+r.dir,
+		"GIT_CONFIG_NOSYSTEM=1",
+		"GIT_AUTHOR_NAME=test",
+		"GIT_COMMITTER_NAME=test",
+		"GIT_AUTHOR_EMAIL="+email,
+		"GIT_COMMITTER_EMAIL="+email,
+	)
+	if !at.IsZero() {
+		date := fmt.Sprintf("@%d +0000", at.Unix())
+		cmd.Env = append(cmd.Env, "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+	}
+	out, err := cmd.CombinedOutput()
+	require.NoError(r.t, err, "git %s: %s", strings.Join(args, " "), out)
+	return strings.TrimSpace(string(out))
+}
+
+// commit writes files and commits them as email at the given time.
+func (r *testRepo) commit(at time.Time, email string, files map[string]string) {
+	for name, content := range files {
+		require.NoError(r.t, os.WriteFile(filepath.Join(r.dir, name), []byte(content), 0o644))
+	}
+	r.git(at, email, "add", "-A")
+	r.git(at, email, "commit", "-q", "-m", "change by "+email)
+}
+
+func TestAnalyze(t *testing.T) {
+	head := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	r := newTestRepo(t)
+
+	// carol wrote a.go long ago, outside the window.
+	r.commit(head.Add(-400*day), "carol@example.com", map[string]string{
+		"a.go": "c1\nc2\nc3\nc4\n",
+	})
+	// alice rewrote its first three lines 90 days before head.
+	r.commit(head.Add(-90*day), "alice@example.com", map[string]string{
+		"a.go": "a1\na2\na3\nc4\n",
+	})
+	// bob rewrote the last line and added b.go 30 days before head.
+	r.commit(head.Add(-30*day), "Bob@Example.com", map[string]string{
+		"a.go": "a1\na2\na3\nb4\n",
+		"b.go": "b1\nb2\n",
+	})
+	r.git(time.Time{}, "", "checkout", "-q", "-b", "feature")
+	// dave changes every line of a.go and the first line of b.go.
+	r.commit(head, "dave@example.com", map[string]string{
+		"a.go": "d1\nd2\nd3\nd4\n",
+		"b.go": "d1\nb2\n",
+		"c.go": "new\n",
+	})
+
+	emails := map[string]string{"alice@example.com": "u_alice", "bob@example.com": "u_bob"}
+	res, err := Analyze(context.Background(), r.dir, "main", "feature", emails, DefaultOptions)
+	require.NoError(t, err)
+
+	assert.Equal(t, r.git(time.Time{}, "", "rev-parse", "main"), res.Base)
+	assert.Equal(t, r.git(time.Time{}, "", "rev-parse", "feature"), res.Head)
+	assert.ElementsMatch(t, []string{"a.go", "b.go", "c.go"}, res.ChangedFiles)
+	assert.Equal(t, []string{"dave@example.com"}, res.Authors)
+
+	// bob: 2 lines + 5*(1-30/180); alice: 3 lines + 5*(1-90/180). carol's
+	// only commit is outside the window and none of her lines remain.
+	require.Len(t, res.Candidates, 2)
+	bob, alice := res.Candidates[0], res.Candidates[1]
+
+	assert.Equal(t, "bob@example.com", bob.Email)
+	assert.Equal(t, "u_bob", bob.UserID)
+	assert.Equal(t, 2, bob.Lines)
+	assert.Equal(t, 1, bob.Commits)
+	assert.InDelta(t, 2+5*(1-30.0/180), bob.Score, 1e-9)
+
+	assert.Equal(t, "alice@example.com", alice.Email)
+	assert.Equal(t, "u_alice", alice.UserID)
+	assert.Equal(t, 3, alice.Lines)
+	assert.Equal(t, 1, alice.Commits)
+	assert.InDelta(t, 3+5*(1-90.0/180), alice.Score, 1e-9)
+
+	assert.Equal(t, []string{"u_bob"}, res.UserIDs(1))
+	assert.Equal(t, []string{"u_bob", "u_alice"}, res.UserIDs(0))
+
+	// Without recent commits only blamed lines count, so alice comes first.
+	res, err = Analyze(context.Background(), r.dir, "main", "feature", emails, Options{LineWeight: 1})
+	require.NoError(t, err)
+	require.Len(t, res.Candidates, 2)
+	assert.Equal(t, "alice@example.com", res.Candidates[0].Email)
+	assert.Equal(t, 3.0, res.Candidates[0].Score)
+	assert.Equal(t, 0, res.Candidates[0].Commits)
+}
+
+func TestAnalyzeUnknownRevision(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit(time.Now(), "alice@example.com", map[string]string{"a.go": "a\n"})
+
+	_, err := Analyze(context.Background(), r.dir, "main", "no-such-branch", nil, DefaultOptions)
+	assert.Error(t, err)
+}
+
+func TestCandidatesTieBreakOnEmail(t *testing.T) {
+	r := newTestRepo(t)
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r.commit(at, "zed@example.com", map[string]string{"a.go": "z\n"})
+	r.commit(at, "amy@example.com", map[string]string{"b.go": "a\n"})
+	r.git(time.Time{}, "", "checkout", "-q", "-b", "feature")
+	r.commit(at, "dave@example.com", map[string]string{"a.go": "d\n", "b.go": "d\n"})
+
+	res, err := Analyze(context.Background(), r.dir, "main", "feature", nil, DefaultOptions)
+	require.NoError(t, err)
+	require.Len(t, res.Candidates, 2)
+	assert.Equal(t, "amy@example.com", res.Candidates[0].Email)
+	assert.Equal(t, "zed@example.com", res.Candidates[1].Email)
+	assert.Equal(t, res.Candidates[0].Score, res.Candidates[1].Score)
+	assert.Empty(t, res.UserIDs(0))
+}
+
+func TestUserIDs(t *testing.T) {
+	res := &Result{Candidates: []Candidate{
+		{Email: "a@x", UserID: "u1"},
+		{Email: "b@x"},
+		{Email: "c@x", UserID: "u1"},
+		{Email: "d@x", UserID: "u2"},
+		{Email: "e@x", UserID: "u3"},
+	}}
+
+	assert.Equal(t, []string{"u1", "u2", "u3"}, res.UserIDs(0))
+	assert.Equal(t, []string{"u1", "u2"}, res.UserIDs(2))
+	assert.Equal(t, []string{}, (&Result{}).UserIDs(3))
+}
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		in       string
+		base     string
+		head     string
+		hasError bool
+	}{
+		{in: "main..feature", base: "main", head: "feature"},
+		{in: "main..", base: "main", head: "HEAD"},
+		{in: "v1.0..v1.1", base: "v1.0", head: "v1.1"},
+		{in: "main...feature", hasError: true},
+		{in: "..feature", hasError: true},
+		{in: "main", hasError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			base, head, err := ParseRange(tt.in)
+			if tt.hasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.base, base)
+			assert.Equal(t, tt.head, head)
+		})
+	}
+}
+
+func TestReadEmails(t *testing.T) {
+	emails, err := ReadEmails(strings.NewReader("# mapping\n\n  <Alice@Example.com>  u1\nbob@example.com u2\n"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"alice@example.com": "u1", "bob@example.com": "u2"}, emails)
+
+	_, err = ReadEmails(strings.NewReader("alice@example.com u1\nbob@example.com\n"))
+	assert.EqualError(t, err, `line 2: expected "email user_id"`)
+}
+
+func TestParseDiff(t *testing.T) {
+	diff := strings.Join([]string{
+		"diff --git a/mod.go b/mod.go",
+		"index 1111111..2222222 100644",
+		"--- a/mod.go",
+		"+++ b/mod.go",
+		"@@ -3,2 +3,2 @@ func f() {",
+		"-old",
+		"--- a/looks/like/a/header",
+		"+new",
+		"+new",
+		"@@ -10 +10 @@",
+		"-x",
+		"+y",
+		"@@ -20,0 +21,3 @@",
+		"+inserted",
+		"diff --git a/new.go b/new.go",
+		"new file mode 100644",
+		"index 0000000..3333333",
+		"--- /dev/null",
+		"+++ b/new.go",
+		"@@ -0,0 +1,2 @@",
+		"+a",
+		"+b",
+		"diff --git a/gone.go b/gone.go",
+		"deleted file mode 100644",
+		"--- a/gone.go",
+		"+++ /dev/null",
+		"@@ -1,2 +0,0 @@",
+		"-a",
+		"-b",
+		"diff --git a/old name.go b/new name.go",
+		"similarity index 90%",
+		"rename from old name.go",
+		"rename to new name.go",
+		"--- a/old name.go",
+		"+++ b/new name.go",
+		"@@ -5,0 +6 @@",
+		"+x",
+		"diff --git a/img.png b/img.png",
+		"index 4444444..5555555 100644",
+		"Binary files a/img.png and b/img.png differ",
+		"",
+	}, "\n")
+
+	changes, err := parseDiff(strings.NewReader(diff))
+	require.NoError(t, err)
+	assert.Equal(t, []fileChange{
+		{path: "mod.go", oldPath: "mod.go", ranges: []lineRange{{3, 4}, {10, 10}, {20, 20}}},
+		{path: "new.go"},
+		{path: "gone.go", oldPath: "gone.go", ranges: []lineRange{{1, 2}}},
+		{path: "new name.go", oldPath: "old name.go", ranges: []lineRange{{5, 5}}},
+		{path: "img.png", oldPath: "img.png"},
+	}, changes)
+
+	_, err = parseDiff(strings.NewReader("diff --git a/x b/x\n@@ -a,1 +1 @@\n"))
+	assert.Error(t, err)
+}
+
+func TestParseHunkOld(t *testing.T) {
+	tests := []struct {
+		line     string
+		start    int
+		count    int
+		hasError bool
+	}{
+		{line: "@@ -12,3 +12,4 @@ func f()", start: 12, count: 3},
+		{line: "@@ -7 +7 @@", start: 7, count: 1},
+		{line: "@@ -0,0 +1,5 @@", start: 0, count: 0},
+		{line: "@@ +1 -1 @@", hasError: true},
+		{line: "@@ -x,1 +1 @@", hasError: true},
+		{line: "@@ -1,y +1 @@", hasError: true},
+		{line: "@@", hasError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			start, count, err := parseHunkOld(tt.line)
+			if tt.hasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.start, start)
+			assert.Equal(t, tt.count, count)
+		})
+	}
+}
+
+func TestHeaderPath(t *testing.T) {
+	assert.Equal(t, "dir/file.go", headerPath("a/dir/file.go b/dir/file.go"))
+	assert.Equal(t, "a b.go", headerPath("a/a b.go b/a b.go"))
+	assert.Equal(t, "", headerPath("a/old.go b/new.go"))
+	assert.Equal(t, "", headerPath(`"a/t\tab.go" "b/t\tab.go"`))
+}
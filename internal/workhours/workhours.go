@@ -0,0 +1,136 @@
+// Package workhours answers questions about a person's working time: whether
+// they are working at a moment, when their next working window starts, and how
+// much working time lies between two moments or after a start.
+package workhours
+
+import (
+	"fmt"
+	"time"
+)
+
+// MinutesPerDay is the largest End: a window that lasts until midnight.
+const MinutesPerDay = 24 * 60
+
+// searchDays bounds how far ahead the next working window is looked for.
+const searchDays = 2 * 366
+
+// Schedule is a weekly working window in a time zone. Start and End are minutes
+// after local midnight, Days is indexed by time.Weekday. Holidays are local
+// dates in the form 2006-01-02 on which nobody works.
+type Schedule struct {
+	Location *time.Location
+	Days     [7]bool
+	Start    int
+	End      int
+	Holidays map[string]bool
+}
+
+// Always is the schedule of someone without working hours: every day, all day,
+// in UTC.
+func Always() Schedule {
+	return Schedule{Location: time.UTC, Days: [7]bool{true, true, true, true, true, true, true}, End: MinutesPerDay}
+}
+
+// Validate checks that the window is non-empty and there is a working day.
+func (s Schedule) Validate() error {
+	if s.Start < 0 || s.End > MinutesPerDay || s.Start >= s.End {
+		return fmt.Errorf("working hours must start before they end within a day")
+	}
+	for _, d := range s.Days {
+		if d {
+			return nil
+		}
+	}
+	return fmt.Errorf("at least one working day is required")
+}
+
+// window returns the working window of the local day that contains t, and
+// false if it is not a working day.
+func (s Schedule) window(t time.Time) (time.Time, time.Time, bool) {
+	loc := s.location()
+	y, m, d := t.In(loc).Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, loc)
+	if !s.Days[day.Weekday()] || s.Holidays[day.Format(time.DateOnly)] {
+		return time.Time{}, time.Time{}, false
+	}
+	return time.Date(y, m, d, 0, s.Start, 0, 0, loc), time.Date(y, m, d, 0, s.End, 0, 0, loc), true
+}
+
+func (s Schedule) location() *time.Location {
+	if s.Location == nil {
+		return time.UTC
+	}
+	return s.Location
+}
+
+// nextDay returns local midnight of the day after the one that contains t.
+func (s Schedule) nextDay(t time.Time) time.Time {
+	loc := s.location()
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+}
+
+// Working reports whether t is inside a working window.
+func (s Schedule) Working(t time.Time) bool {
+	start, end, ok := s.window(t)
+	return ok && !t.Before(start) && t.Before(end)
+}
+
+// NextStart returns t if it is inside a working window, or else the start of the
+// next one. It returns false if there is none within two years.
+func (s Schedule) NextStart(t time.Time) (time.Time, bool) {
+	day := t
+	for i := 0; i < searchDays; i++ {
+		start, end, ok := s.window(day)
+		if ok && t.Before(end) {
+			if t.Before(start) {
+				return start, true
+			}
+			return t, true
+		}
+		day = s.nextDay(day)
+	}
+	return time.Time{}, false
+}
+
+// Between returns the working time from from to to.
+func (s Schedule) Between(from, to time.Time) time.Duration {
+	var total time.Duration
+	for day := from; day.Before(to); day = s.nextDay(day) {
+		start, end, ok := s.window(day)
+		if !ok {
+			continue
+		}
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		if end.After(start) {
+			total += end.Sub(start)
+		}
+	}
+	return total
+}
+
+// Add returns the moment at which d of working time has passed since from. It
+// returns false if that does not happen within two years.
+func (s Schedule) Add(from time.Time, d time.Duration) (time.Time, bool) {
+	day := from
+	for i := 0; i < searchDays; i++ {
+		start, end, ok := s.window(day)
+		if ok && from.Before(end) {
+			if start.Before(from) {
+				start = from
+			}
+			left := end.Sub(start)
+			if d <= left {
+				return start.Add(d), true
+			}
+			d -= left
+		}
+		day = s.nextDay(day)
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,189 @@
+package workhours
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	berlin     = mustLoad("Europe/Berlin")
+	tokyo      = mustLoad("Asia/Tokyo")
+	losAngeles = mustLoad("America/Los_Angeles")
+	weekdays   = [7]bool{time.Monday: true, time.Tuesday: true, time.Wednesday: true, time.Thursday: true, time.Friday: true}
+	everyDay   = [7]bool{true, true, true, true, true, true, true}
+)
+
+func mustLoad(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// office works 09:00-17:00 on weekdays in Berlin.
+func office(holidays ...string) Schedule {
+	s := Schedule{Location: berlin, Days: weekdays, Start: 9 * 60, End: 17 * 60, Holidays: make(map[string]bool)}
+	for _, h := range holidays {
+		s.Holidays[h] = true
+	}
+	return s
+}
+
+// at is a local time in loc. 2024-03-04 is a Monday; Berlin switches to
+// summer time on 2024-03-31 and back on 2024-10-27.
+func at(loc *time.Location, month time.Month, day, hour, min int) time.Time {
+	return time.Date(2024, month, day, hour, min, 0, 0, loc)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		s    Schedule
+		err  bool
+	}{
+		{name: "office", s: office()},
+		{name: "always", s: Always()},
+		{name: "until midnight", s: Schedule{Days: weekdays, Start: 20 * 60, End: MinutesPerDay}},
+		{name: "empty window", s: Schedule{Days: weekdays, Start: 9 * 60, End: 9 * 60}, err: true},
+		{name: "window across midnight", s: Schedule{Days: weekdays, Start: 22 * 60, End: 6 * 60}, err: true},
+		{name: "negative start", s: Schedule{Days: weekdays, Start: -1, End: 60}, err: true},
+		{name: "end after midnight", s: Schedule{Days: weekdays, Start: 0, End: MinutesPerDay + 1}, err: true},
+		{name: "no working days", s: Schedule{Start: 9 * 60, End: 17 * 60}, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.s.Validate()
+			if tt.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestWorking(t *testing.T) {
+	s := office("2024-03-06")
+	assert.True(t, s.Working(at(berlin, 3, 4, 9, 0)))
+	assert.True(t, s.Working(at(berlin, 3, 4, 16, 59)))
+	assert.False(t, s.Working(at(berlin, 3, 4, 17, 0)))
+	assert.False(t, s.Working(at(berlin, 3, 4, 8, 59)))
+	assert.False(t, s.Working(at(berlin, 3, 6, 12, 0)))
+	assert.False(t, s.Working(at(berlin, 3, 9, 12, 0)))
+	// 08:30 UTC is 09:30 in Berlin.
+	assert.True(t, s.Working(at(time.UTC, 3, 4, 8, 30)))
+
+	// A schedule without a location is in UTC.
+	utc := Schedule{Days: everyDay, Start: 9 * 60, End: 17 * 60}
+	assert.False(t, utc.Working(at(berlin, 3, 4, 9, 30)))
+	assert.True(t, utc.Working(at(time.UTC, 3, 4, 9, 30)))
+}
+
+func TestNextStart(t *testing.T) {
+	tests := []struct {
+		name string
+		s    Schedule
+		t    time.Time
+		want time.Time
+		ok   bool
+	}{
+		{name: "inside window", s: office(), t: at(berlin, 3, 4, 10, 15), want: at(berlin, 3, 4, 10, 15), ok: true},
+		{name: "at start", s: office(), t: at(berlin, 3, 4, 9, 0), want: at(berlin, 3, 4, 9, 0), ok: true},
+		{name: "before start", s: office(), t: at(berlin, 3, 4, 7, 0), want: at(berlin, 3, 4, 9, 0), ok: true},
+		{name: "at end", s: office(), t: at(berlin, 3, 4, 17, 0), want: at(berlin, 3, 5, 9, 0), ok: true},
+		{name: "friday evening", s: office(), t: at(berlin, 3, 8, 18, 0), want: at(berlin, 3, 11, 9, 0), ok: true},
+		{name: "saturday", s: office(), t: at(berlin, 3, 9, 12, 0), want: at(berlin, 3, 11, 9, 0), ok: true},
+		{name: "holiday", s: office("2024-03-11"), t: at(berlin, 3, 9, 12, 0), want: at(berlin, 3, 12, 9, 0), ok: true},
+		{name: "holidays in a row", s: office("2024-03-04", "2024-03-05"), t: at(berlin, 3, 4, 10, 0), want: at(berlin, 3, 6, 9, 0), ok: true},
+		{name: "other zone", s: office(), t: at(tokyo, 3, 4, 20, 0), want: at(berlin, 3, 4, 12, 0), ok: true},
+		{name: "into summer time", s: office(), t: at(berlin, 3, 29, 18, 0), want: at(time.UTC, 4, 1, 7, 0), ok: true},
+		{name: "into winter time", s: office(), t: at(berlin, 10, 25, 18, 0), want: at(time.UTC, 10, 28, 8, 0), ok: true},
+		{name: "always", s: Always(), t: at(berlin, 3, 9, 3, 0), want: at(berlin, 3, 9, 3, 0), ok: true},
+		{name: "never", s: Schedule{Location: berlin, Start: 9 * 60, End: 17 * 60}, t: at(berlin, 3, 4, 10, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.s.NextStart(tt.t)
+			assert.Equal(t, tt.ok, ok)
+			assert.True(t, tt.want.Equal(got), "got %v, want %v", got, tt.want)
+		})
+	}
+}
+
+func TestBetween(t *testing.T) {
+	// 01:00-04:00 every day spans the hour that is skipped or repeated.
+	night := Schedule{Location: berlin, Days: everyDay, Start: 60, End: 4 * 60}
+	allDay := Schedule{Location: berlin, Days: everyDay, End: MinutesPerDay}
+	// 17:00-24:00 in Los Angeles crosses midnight in UTC.
+	evening := Schedule{Location: losAngeles, Days: [7]bool{time.Monday: true}, Start: 17 * 60, End: MinutesPerDay}
+
+	tests := []struct {
+		name     string
+		s        Schedule
+		from, to time.Time
+		want     time.Duration
+	}{
+		{name: "within a window", s: office(), from: at(berlin, 3, 4, 10, 0), to: at(berlin, 3, 4, 12, 30), want: 150 * time.Minute},
+		{name: "before the window", s: office(), from: at(berlin, 3, 4, 6, 0), to: at(berlin, 3, 4, 8, 0)},
+		{name: "overnight", s: office(), from: at(berlin, 3, 4, 16, 0), to: at(berlin, 3, 5, 10, 0), want: 2 * time.Hour},
+		{name: "over the weekend", s: office(), from: at(berlin, 3, 8, 16, 0), to: at(berlin, 3, 11, 10, 0), want: 2 * time.Hour},
+		{name: "whole week", s: office(), from: at(berlin, 3, 4, 0, 0), to: at(berlin, 3, 11, 0, 0), want: 40 * time.Hour},
+		{name: "holiday", s: office("2024-03-06"), from: at(berlin, 3, 4, 0, 0), to: at(berlin, 3, 11, 0, 0), want: 32 * time.Hour},
+		{name: "holiday is a local date", s: Schedule{Location: tokyo, Days: everyDay, End: MinutesPerDay, Holidays: map[string]bool{"2024-03-05": true}}, from: at(time.UTC, 3, 4, 12, 0), to: at(time.UTC, 3, 5, 12, 0), want: 3 * time.Hour},
+		{name: "reversed", s: office(), from: at(berlin, 3, 5, 12, 0), to: at(berlin, 3, 4, 12, 0)},
+		{name: "empty", s: office(), from: at(berlin, 3, 4, 12, 0), to: at(berlin, 3, 4, 12, 0)},
+		{name: "spring forward day", s: night, from: at(berlin, 3, 31, 0, 0), to: at(berlin, 3, 31, 12, 0), want: 2 * time.Hour},
+		{name: "fall back day", s: night, from: at(berlin, 10, 27, 0, 0), to: at(berlin, 10, 27, 12, 0), want: 4 * time.Hour},
+		{name: "all day into summer time", s: allDay, from: at(berlin, 3, 30, 0, 0), to: at(berlin, 4, 1, 0, 0), want: 47 * time.Hour},
+		{name: "all day into winter time", s: allDay, from: at(berlin, 10, 26, 0, 0), to: at(berlin, 10, 28, 0, 0), want: 49 * time.Hour},
+		{name: "office hours keep across the switch", s: office(), from: at(berlin, 3, 25, 0, 0), to: at(berlin, 4, 1, 0, 0), want: 40 * time.Hour},
+		{name: "local window across utc midnight", s: evening, from: at(time.UTC, 3, 5, 0, 0), to: at(time.UTC, 3, 5, 3, 0), want: 2 * time.Hour},
+		{name: "local weekday across utc midnight", s: evening, from: at(time.UTC, 3, 5, 0, 0), to: at(time.UTC, 3, 6, 0, 0), want: 7 * time.Hour},
+		{name: "always", s: Always(), from: at(berlin, 3, 29, 18, 0), to: at(berlin, 4, 2, 6, 30), want: at(berlin, 4, 2, 6, 30).Sub(at(berlin, 3, 29, 18, 0))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.s.Between(tt.from, tt.to))
+		})
+	}
+}
+
+func TestAdd(t *testing.T) {
+	tests := []struct {
+		name string
+		s    Schedule
+		from time.Time
+		d    time.Duration
+		want time.Time
+		ok   bool
+	}{
+		{name: "within a window", s: office(), from: at(berlin, 3, 4, 10, 0), d: 2 * time.Hour, want: at(berlin, 3, 4, 12, 0), ok: true},
+		{name: "up to the end", s: office(), from: at(berlin, 3, 4, 16, 0), d: time.Hour, want: at(berlin, 3, 4, 17, 0), ok: true},
+		{name: "into the next day", s: office(), from: at(berlin, 3, 4, 16, 0), d: 2 * time.Hour, want: at(berlin, 3, 5, 10, 0), ok: true},
+		{name: "from before the window", s: office(), from: at(berlin, 3, 4, 6, 0), d: time.Hour, want: at(berlin, 3, 4, 10, 0), ok: true},
+		{name: "zero from the weekend", s: office(), from: at(berlin, 3, 9, 12, 0), want: at(berlin, 3, 11, 9, 0), ok: true},
+		{name: "over the weekend", s: office(), from: at(berlin, 3, 8, 16, 0), d: 3 * time.Hour, want: at(berlin, 3, 11, 11, 0), ok: true},
+		{name: "over a holiday", s: office("2024-03-05"), from: at(berlin, 3, 4, 16, 0), d: 2 * time.Hour, want: at(berlin, 3, 6, 10, 0), ok: true},
+		{name: "a working week", s: office(), from: at(berlin, 3, 4, 9, 0), d: 40 * time.Hour, want: at(berlin, 3, 8, 17, 0), ok: true},
+		{name: "into summer time", s: office(), from: at(berlin, 3, 29, 16, 0), d: 2 * time.Hour, want: at(time.UTC, 4, 1, 8, 0), ok: true},
+		{name: "through the skipped hour", s: Schedule{Location: berlin, Days: everyDay, Start: 60, End: 4 * 60}, from: at(berlin, 3, 31, 1, 0), d: 90 * time.Minute, want: at(berlin, 3, 31, 3, 30), ok: true},
+		{name: "local window across utc midnight", s: Schedule{Location: losAngeles, Days: [7]bool{time.Monday: true}, Start: 17 * 60, End: MinutesPerDay}, from: at(time.UTC, 3, 4, 12, 0), d: 8 * time.Hour, want: at(time.UTC, 3, 12, 1, 0), ok: true},
+		{name: "always", s: Always(), from: at(berlin, 3, 9, 12, 0), d: 50 * time.Hour, want: at(berlin, 3, 11, 14, 0), ok: true},
+		{name: "never", s: Schedule{Location: berlin, Start: 9 * 60, End: 17 * 60}, from: at(berlin, 3, 4, 10, 0), d: time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.s.Add(tt.from, tt.d)
+			require.Equal(t, tt.ok, ok)
+			assert.True(t, tt.want.Equal(got), "got %v, want %v", got, tt.want)
+			if ok {
+				assert.Equal(t, tt.d, tt.s.Between(tt.from, got))
+			}
+		})
+	}
+}
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS pull_requests_reviewers_pr_reviewer_idx;
@@ -0,0 +1,6 @@
+DELETE FROM pull_requests_reviewers a
+USING pull_requests_reviewers b
+WHERE a.ctid < b.ctid AND a.pr_id = b.pr_id AND a.reviewer_id = b.reviewer_id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS pull_requests_reviewers_pr_reviewer_idx
+    ON pull_requests_reviewers (pr_id, reviewer_id);
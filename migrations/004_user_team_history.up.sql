@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS user_team_history (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(100) NOT NULL REFERENCES users(user_id),
+    team_id INT NOT NULL REFERENCES teams(team_id),
+    joined_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    left_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS user_team_history_user_idx ON user_team_history (user_id, joined_at);
+
+INSERT INTO user_team_history (user_id, team_id)
+SELECT user_id, team_id FROM users WHERE team_id IS NOT NULL;
@@ -0,0 +1,8 @@
+ALTER TABLE user_team_history DROP CONSTRAINT IF EXISTS user_team_history_team_id_fkey;
+DELETE FROM user_team_history WHERE team_id IS NULL;
+ALTER TABLE user_team_history ALTER COLUMN team_id SET NOT NULL;
+ALTER TABLE user_team_history ADD CONSTRAINT user_team_history_team_id_fkey
+    FOREIGN KEY (team_id) REFERENCES teams(team_id);
+ALTER TABLE user_team_history DROP COLUMN IF EXISTS team_name;
+
+ALTER TABLE teams DROP COLUMN IF EXISTS archived_at;
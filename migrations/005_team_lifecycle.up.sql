@@ -0,0 +1,9 @@
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
+
+ALTER TABLE user_team_history ADD COLUMN IF NOT EXISTS team_name VARCHAR(100);
+UPDATE user_team_history h SET team_name = t.name FROM teams t WHERE t.team_id = h.team_id;
+
+ALTER TABLE user_team_history ALTER COLUMN team_id DROP NOT NULL;
+ALTER TABLE user_team_history DROP CONSTRAINT IF EXISTS user_team_history_team_id_fkey;
+ALTER TABLE user_team_history ADD CONSTRAINT user_team_history_team_id_fkey
+    FOREIGN KEY (team_id) REFERENCES teams(team_id) ON DELETE SET NULL;
@@ -0,0 +1,6 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS team_id INT REFERENCES teams(team_id);
+UPDATE users u SET team_id = tm.team_id FROM team_members tm WHERE tm.user_id = u.user_id AND tm.is_primary;
+
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS team_id;
+
+DROP TABLE IF EXISTS team_members;
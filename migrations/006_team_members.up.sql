@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS team_members (
+    user_id VARCHAR(100) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    team_id INT NOT NULL REFERENCES teams(team_id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL DEFAULT 'member' CHECK (role IN ('member', 'lead')),
+    is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+    joined_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, team_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS team_members_primary_idx ON team_members (user_id) WHERE is_primary;
+CREATE INDEX IF NOT EXISTS team_members_team_idx ON team_members (team_id);
+
+INSERT INTO team_members (user_id, team_id, is_primary)
+SELECT user_id, team_id, TRUE FROM users WHERE team_id IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS team_id INT REFERENCES teams(team_id) ON DELETE SET NULL;
+UPDATE pull_requests pr SET team_id = u.team_id FROM users u WHERE u.user_id = pr.author_id AND pr.team_id IS NULL;
+
+ALTER TABLE users DROP COLUMN IF EXISTS team_id;
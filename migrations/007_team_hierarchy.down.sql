@@ -0,0 +1,7 @@
+ALTER TABLE pull_requests_reviewers DROP COLUMN IF EXISTS pool_team_id;
+
+DROP INDEX IF EXISTS teams_parent_idx;
+
+ALTER TABLE teams DROP CONSTRAINT IF EXISTS teams_parent_not_self;
+ALTER TABLE teams DROP COLUMN IF EXISTS fallback_to_siblings;
+ALTER TABLE teams DROP COLUMN IF EXISTS parent_team_id;
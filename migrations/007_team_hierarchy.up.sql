@@ -0,0 +1,7 @@
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS parent_team_id INT REFERENCES teams(team_id) ON DELETE SET NULL;
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS fallback_to_siblings BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE teams ADD CONSTRAINT teams_parent_not_self CHECK (parent_team_id IS NULL OR parent_team_id != team_id);
+
+CREATE INDEX IF NOT EXISTS teams_parent_idx ON teams (parent_team_id);
+
+ALTER TABLE pull_requests_reviewers ADD COLUMN IF NOT EXISTS pool_team_id INT REFERENCES teams(team_id) ON DELETE SET NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE pull_requests_reviewers DROP COLUMN IF EXISTS rule_team_id;
+
+DROP TABLE IF EXISTS label_rules;
+DROP TABLE IF EXISTS pull_request_labels;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS pull_request_labels (
+    pr_id VARCHAR(100) NOT NULL REFERENCES pull_requests(pr_id) ON DELETE CASCADE,
+    label VARCHAR(100) NOT NULL,
+    PRIMARY KEY (pr_id, label)
+);
+
+CREATE TABLE IF NOT EXISTS label_rules (
+    label VARCHAR(100) NOT NULL,
+    team_id INT NOT NULL REFERENCES teams(team_id) ON DELETE CASCADE,
+    reviewers_count INT NOT NULL CHECK (reviewers_count > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (label, team_id)
+);
+
+ALTER TABLE pull_requests_reviewers ADD COLUMN IF NOT EXISTS rule_team_id INT REFERENCES teams(team_id) ON DELETE SET NULL;
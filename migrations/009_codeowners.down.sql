@@ -0,0 +1,7 @@
+ALTER TABLE pull_requests_reviewers DROP COLUMN IF EXISTS codeowners_owner;
+ALTER TABLE pull_requests_reviewers DROP COLUMN IF EXISTS codeowners_pattern;
+ALTER TABLE pull_requests_reviewers DROP COLUMN IF EXISTS codeowners_line;
+
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS repository;
+
+DROP TABLE IF EXISTS codeowners_files;
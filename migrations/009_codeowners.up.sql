@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS codeowners_files (
+    repository VARCHAR(200) PRIMARY KEY,
+    content TEXT NOT NULL,
+    uploaded_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS repository VARCHAR(200);
+
+ALTER TABLE pull_requests_reviewers ADD COLUMN IF NOT EXISTS codeowners_line INT;
+ALTER TABLE pull_requests_reviewers ADD COLUMN IF NOT EXISTS codeowners_pattern VARCHAR(500);
+ALTER TABLE pull_requests_reviewers ADD COLUMN IF NOT EXISTS codeowners_owner VARCHAR(200);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS user_skills (
+    user_id VARCHAR(100) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    skill VARCHAR(100) NOT NULL,
+    PRIMARY KEY (user_id, skill)
+);
+
+CREATE INDEX IF NOT EXISTS user_skills_skill_idx ON user_skills (skill);
+
+CREATE TABLE IF NOT EXISTS pull_request_tags (
+    pr_id VARCHAR(100) NOT NULL REFERENCES pull_requests(pr_id) ON DELETE CASCADE,
+    tag VARCHAR(100) NOT NULL,
+    PRIMARY KEY (pr_id, tag)
+);
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS events;
+DROP TABLE IF EXISTS review_queue;
+
+UPDATE pull_requests SET pr_status='OPEN' WHERE pr_status='CLOSED';
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS closed_at;
+
+ALTER TABLE teams DROP COLUMN IF EXISTS max_open_reviews;
+ALTER TABLE users DROP COLUMN IF EXISTS max_open_reviews;
@@ -0,0 +1,22 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS max_open_reviews INT CHECK (max_open_reviews > 0);
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS max_open_reviews INT CHECK (max_open_reviews > 0);
+
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS closed_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS review_queue (
+    pr_id VARCHAR(100) PRIMARY KEY REFERENCES pull_requests(pr_id) ON DELETE CASCADE,
+    missing_reviewers INT NOT NULL CHECK (missing_reviewers > 0),
+    priority INT NOT NULL DEFAULT 0,
+    enqueued_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS review_queue_fifo_idx ON review_queue (enqueued_at, pr_id);
+CREATE INDEX IF NOT EXISTS review_queue_priority_idx ON review_queue (priority DESC, enqueued_at, pr_id);
+
+CREATE TABLE IF NOT EXISTS events (
+    event_id BIGSERIAL PRIMARY KEY,
+    event_type VARCHAR(50) NOT NULL,
+    pr_id VARCHAR(100),
+    payload JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
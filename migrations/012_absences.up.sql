@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS user_absences (
+    absence_id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(100) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    reason VARCHAR(200) NOT NULL DEFAULT '',
+    reassign_reviews BOOLEAN NOT NULL DEFAULT FALSE,
+    calendar_uid VARCHAR(255),
+    handed_over_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS user_absences_user_idx ON user_absences (user_id, ends_at);
+CREATE INDEX IF NOT EXISTS user_absences_handover_idx ON user_absences (starts_at) WHERE reassign_reviews AND handed_over_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS user_absences_calendar_uid_idx ON user_absences (user_id, calendar_uid) WHERE calendar_uid IS NOT NULL;
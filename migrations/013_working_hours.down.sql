@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS team_holidays;
+
+ALTER TABLE users DROP COLUMN IF EXISTS work_end_minute;
+ALTER TABLE users DROP COLUMN IF EXISTS work_start_minute;
+ALTER TABLE users DROP COLUMN IF EXISTS work_days;
+ALTER TABLE users DROP COLUMN IF EXISTS time_zone;
@@ -0,0 +1,11 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS time_zone VARCHAR(64);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS work_days SMALLINT[];
+ALTER TABLE users ADD COLUMN IF NOT EXISTS work_start_minute SMALLINT CHECK (work_start_minute BETWEEN 0 AND 1440);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS work_end_minute SMALLINT CHECK (work_end_minute BETWEEN 0 AND 1440);
+
+CREATE TABLE IF NOT EXISTS team_holidays (
+    team_id INT NOT NULL REFERENCES teams(team_id) ON DELETE CASCADE,
+    holiday DATE NOT NULL,
+    name VARCHAR(200) NOT NULL DEFAULT '',
+    PRIMARY KEY (team_id, holiday)
+);
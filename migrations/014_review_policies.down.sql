@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS team_review_policies;
+
+DROP INDEX IF EXISTS users_seniority_idx;
+ALTER TABLE users DROP COLUMN IF EXISTS seniority;
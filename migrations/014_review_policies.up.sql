@@ -0,0 +1,11 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS seniority VARCHAR(10) NOT NULL DEFAULT 'mid'
+    CHECK (seniority IN ('junior', 'mid', 'senior', 'lead', 'bot'));
+
+CREATE INDEX IF NOT EXISTS users_seniority_idx ON users (seniority);
+
+CREATE TABLE IF NOT EXISTS team_review_policies (
+    team_id INT PRIMARY KEY REFERENCES teams(team_id) ON DELETE CASCADE,
+    min_seniors INT NOT NULL DEFAULT 0 CHECK (min_seniors >= 0),
+    no_sole_junior BOOLEAN NOT NULL DEFAULT FALSE,
+    exclude_bots BOOLEAN NOT NULL DEFAULT FALSE
+);
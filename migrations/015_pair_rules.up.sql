@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS pair_rules (
+    reviewer_id VARCHAR(100) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    author_id VARCHAR(100) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('never', 'prefer')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (reviewer_id, author_id),
+    CHECK (reviewer_id != author_id)
+);
+
+CREATE INDEX IF NOT EXISTS pair_rules_author_idx ON pair_rules (author_id);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS assignment_explanations (
+    explanation_id BIGSERIAL PRIMARY KEY,
+    pr_id VARCHAR(100) NOT NULL REFERENCES pull_requests(pr_id) ON DELETE CASCADE,
+    action VARCHAR(20) NOT NULL,
+    replaced_user_id VARCHAR(100),
+    reviewers JSONB NOT NULL DEFAULT '[]',
+    candidates JSONB NOT NULL DEFAULT '[]',
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS assignment_explanations_pr_idx ON assignment_explanations (pr_id, explanation_id);
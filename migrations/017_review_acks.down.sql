@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS review_declines;
+
+DROP INDEX IF EXISTS pull_requests_reviewers_unaccepted_idx;
+ALTER TABLE pull_requests_reviewers DROP COLUMN IF EXISTS accepted_at;
+ALTER TABLE pull_requests_reviewers DROP COLUMN IF EXISTS assigned_at;
@@ -0,0 +1,16 @@
+ALTER TABLE pull_requests_reviewers ADD COLUMN IF NOT EXISTS assigned_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+ALTER TABLE pull_requests_reviewers ADD COLUMN IF NOT EXISTS accepted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS pull_requests_reviewers_unaccepted_idx ON pull_requests_reviewers (assigned_at) WHERE accepted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS review_declines (
+    decline_id BIGSERIAL PRIMARY KEY,
+    pr_id VARCHAR(100) NOT NULL REFERENCES pull_requests(pr_id) ON DELETE CASCADE,
+    reviewer_id VARCHAR(100) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    reason TEXT NOT NULL DEFAULT '',
+    expired BOOLEAN NOT NULL DEFAULT FALSE,
+    declined_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS review_declines_pr_idx ON review_declines (pr_id);
+CREATE INDEX IF NOT EXISTS review_declines_reviewer_idx ON review_declines (reviewer_id);
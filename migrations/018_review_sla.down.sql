@@ -0,0 +1,4 @@
+ALTER TABLE pull_requests_reviewers DROP COLUMN IF EXISTS sla_escalation;
+ALTER TABLE pull_requests_reviewers DROP COLUMN IF EXISTS sla_breached_at;
+
+DROP TABLE IF EXISTS team_sla_policies;
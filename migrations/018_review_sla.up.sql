@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS team_sla_policies (
+    team_id INT PRIMARY KEY REFERENCES teams(team_id) ON DELETE CASCADE,
+    response_minutes INT NOT NULL CHECK (response_minutes > 0),
+    remind_after_minutes INT NOT NULL DEFAULT 0 CHECK (remind_after_minutes >= 0),
+    reassign_after_minutes INT NOT NULL DEFAULT 0 CHECK (reassign_after_minutes >= 0),
+    notify_lead_after_minutes INT NOT NULL DEFAULT 0 CHECK (notify_lead_after_minutes >= 0)
+);
+
+ALTER TABLE pull_requests_reviewers ADD COLUMN IF NOT EXISTS sla_breached_at TIMESTAMPTZ;
+ALTER TABLE pull_requests_reviewers ADD COLUMN IF NOT EXISTS sla_escalation VARCHAR(20);
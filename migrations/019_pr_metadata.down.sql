@@ -0,0 +1,8 @@
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS created_at;
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS files_count;
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS lines_removed;
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS lines_added;
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS description;
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS url;
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS target_branch;
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS source_branch;
@@ -0,0 +1,17 @@
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS source_branch VARCHAR(200);
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS target_branch VARCHAR(200);
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS url TEXT;
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS description TEXT;
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS lines_added INT NOT NULL DEFAULT 0 CHECK (lines_added >= 0);
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS lines_removed INT NOT NULL DEFAULT 0 CHECK (lines_removed >= 0);
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS files_count INT NOT NULL DEFAULT 0 CHECK (files_count >= 0);
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ;
+
+UPDATE pull_requests pr SET created_at = COALESCE(
+    (SELECT MIN(recorded_at) FROM assignment_explanations ae WHERE ae.pr_id = pr.pr_id),
+    (SELECT MIN(created_at) FROM events e WHERE e.pr_id = pr.pr_id),
+    pr.merged_at, pr.closed_at, NOW())
+WHERE pr.created_at IS NULL;
+
+ALTER TABLE pull_requests ALTER COLUMN created_at SET DEFAULT NOW();
+ALTER TABLE pull_requests ALTER COLUMN created_at SET NOT NULL;
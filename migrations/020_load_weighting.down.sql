@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS load_weight_buckets;
+DROP TABLE IF EXISTS load_weighting;
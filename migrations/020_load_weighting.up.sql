@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS load_weighting (
+    singleton BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (singleton),
+    mode VARCHAR(10) NOT NULL CHECK (mode IN ('buckets', 'log')),
+    log_base_lines INT NOT NULL DEFAULT 100 CHECK (log_base_lines > 0)
+);
+
+CREATE TABLE IF NOT EXISTS load_weight_buckets (
+    max_lines INT PRIMARY KEY CHECK (max_lines >= 0),
+    weight NUMERIC NOT NULL CHECK (weight > 0)
+);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS pull_requests_reviewers_reviewer_idx;
+DROP INDEX IF EXISTS pull_requests_team_idx;
+DROP INDEX IF EXISTS pull_requests_author_idx;
+DROP INDEX IF EXISTS pull_requests_created_idx;
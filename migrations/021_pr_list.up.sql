@@ -0,0 +1,4 @@
+CREATE INDEX IF NOT EXISTS pull_requests_created_idx ON pull_requests (created_at, pr_id);
+CREATE INDEX IF NOT EXISTS pull_requests_author_idx ON pull_requests (author_id, created_at);
+CREATE INDEX IF NOT EXISTS pull_requests_team_idx ON pull_requests (team_id, created_at);
+CREATE INDEX IF NOT EXISTS pull_requests_reviewers_reviewer_idx ON pull_requests_reviewers (reviewer_id);
@@ -0,0 +1,3 @@
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS priority INT NOT NULL DEFAULT 0 CHECK (priority >= 0);
+
+UPDATE pull_requests pr SET priority = rq.priority FROM review_queue rq WHERE rq.pr_id = pr.pr_id;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS teams_name_prefix_idx;
+DROP INDEX IF EXISTS users_name_prefix_idx;
+DROP INDEX IF EXISTS users_name_idx;
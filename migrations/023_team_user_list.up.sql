@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS users_name_idx ON users (name, user_id);
+CREATE INDEX IF NOT EXISTS users_name_prefix_idx ON users (lower(name) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS teams_name_prefix_idx ON teams (lower(name) text_pattern_ops);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS review_declines_declined_idx;
+DROP INDEX IF EXISTS pull_requests_reviewers_assigned_idx;
+DROP INDEX IF EXISTS pull_requests_merged_idx;
@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS pull_requests_merged_idx ON pull_requests (merged_at) WHERE merged_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS pull_requests_reviewers_assigned_idx ON pull_requests_reviewers (assigned_at);
+CREATE INDEX IF NOT EXISTS review_declines_declined_idx ON review_declines (declined_at);
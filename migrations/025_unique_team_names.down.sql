@@ -0,0 +1 @@
+DROP INDEX IF EXISTS teams_name_key;
@@ -0,0 +1,12 @@
+DO $$
+DECLARE
+    duplicates TEXT;
+BEGIN
+    SELECT string_agg(name, ', ' ORDER BY name) INTO duplicates
+    FROM (SELECT name FROM teams WHERE name IS NOT NULL GROUP BY name HAVING COUNT(*) > 1) d;
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'team names must be unique, rename the duplicate teams first: %', duplicates;
+    END IF;
+END $$;
+
+CREATE UNIQUE INDEX IF NOT EXISTS teams_name_key ON teams (name);
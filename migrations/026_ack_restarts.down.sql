@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS pull_requests_reviewers_unaccepted_idx;
+CREATE INDEX IF NOT EXISTS pull_requests_reviewers_unaccepted_idx ON pull_requests_reviewers (assigned_at) WHERE accepted_at IS NULL;
+
+ALTER TABLE pull_requests_reviewers DROP COLUMN IF EXISTS ack_restarted_at;
@@ -0,0 +1,4 @@
+ALTER TABLE pull_requests_reviewers ADD COLUMN IF NOT EXISTS ack_restarted_at TIMESTAMPTZ;
+
+DROP INDEX IF EXISTS pull_requests_reviewers_unaccepted_idx;
+CREATE INDEX IF NOT EXISTS pull_requests_reviewers_unaccepted_idx ON pull_requests_reviewers ((COALESCE(ack_restarted_at, assigned_at))) WHERE accepted_at IS NULL;
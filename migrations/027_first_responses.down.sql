@@ -0,0 +1,4 @@
+ALTER TABLE review_declines DROP COLUMN IF EXISTS first_response_at;
+ALTER TABLE review_declines DROP COLUMN IF EXISTS assigned_at;
+
+ALTER TABLE pull_requests_reviewers DROP COLUMN IF EXISTS first_response_at;
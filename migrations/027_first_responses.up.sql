@@ -0,0 +1,6 @@
+ALTER TABLE pull_requests_reviewers ADD COLUMN IF NOT EXISTS first_response_at TIMESTAMPTZ;
+UPDATE pull_requests_reviewers SET first_response_at=accepted_at WHERE first_response_at IS NULL;
+
+ALTER TABLE review_declines ADD COLUMN IF NOT EXISTS assigned_at TIMESTAMPTZ;
+ALTER TABLE review_declines ADD COLUMN IF NOT EXISTS first_response_at TIMESTAMPTZ;
+UPDATE review_declines SET first_response_at=declined_at WHERE first_response_at IS NULL AND NOT expired;
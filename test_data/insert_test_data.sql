@@ -12,66 +12,130 @@ INSERT INTO teams (name) VALUES
 ('Security'),
 ('Infrastructure');
 
-INSERT INTO users (user_id, name, is_active, team_id) VALUES
-('u001', 'Alice', true, 1),
-('u002', 'Bob', true, 1),
-('u003', 'Charlie', true, 1),
-('u004', 'David', true, 1),
-('u005', 'Eva', false, 1),
-
-('u006', 'Frank', true, 2),
-('u007', 'Grace', true, 2),
-('u008', 'Helen', true, 2),
-('u009', 'Ian', false, 2),
-('u010', 'Jane', true, 2),
-
-('u011', 'Kyle', true, 3),
-('u012', 'Laura', true, 3),
-('u013', 'Mike', true, 3),
-('u014', 'Nina', true, 3),
-('u015', 'Oscar', false, 3),
-
-('u016', 'Paul', true, 4),
-('u017', 'Quinn', true, 4),
-('u018', 'Rachel', true, 4),
-('u019', 'Steve', false, 4),
-('u020', 'Tina', true, 4),
-
-('u021', 'Uma', true, 5),
-('u022', 'Victor', true, 5),
-('u023', 'Wendy', true, 5),
-('u024', 'Xavier', false, 5),
-('u025', 'Yara', true, 5),
-
-('u026', 'Zack', true, 6),
-('u027', 'Anna', true, 6),
-('u028', 'Ben', true, 6),
-('u029', 'Cody', true, 6),
-('u030', 'Dina', false, 6),
-
-('u031', 'Evan', true, 7),
-('u032', 'Fiona', true, 7),
-('u033', 'Gina', true, 7),
-('u034', 'Hank', true, 7),
-('u035', 'Iris', false, 7),
-
-('u036', 'Jack', true, 8),
-('u037', 'Kelly', true, 8),
-('u038', 'Leo', true, 8),
-('u039', 'Mona', false, 8),
-('u040', 'Nick', true, 8),
-
-('u041', 'Olga', true, 9),
-('u042', 'Peter', true, 9),
-('u043', 'Queen', true, 9),
-('u044', 'Roger', true, 9),
-('u045', 'Sara', false, 9),
-
-('u046', 'Tim', true, 10),
-('u047', 'Ursula', true, 10),
-('u048', 'Vlad', true, 10),
-('u049', 'Will', false, 10),
-('u050', 'Zoe', true, 10);
+INSERT INTO users (user_id, name, is_active) VALUES
+('u001', 'Alice', true),
+('u002', 'Bob', true),
+('u003', 'Charlie', true),
+('u004', 'David', true),
+('u005', 'Eva', false),
+
+('u006', 'Frank', true),
+('u007', 'Grace', true),
+('u008', 'Helen', true),
+('u009', 'Ian', false),
+('u010', 'Jane', true),
+
+('u011', 'Kyle', true),
+('u012', 'Laura', true),
+('u013', 'Mike', true),
+('u014', 'Nina', true),
+('u015', 'Oscar', false),
+
+('u016', 'Paul', true),
+('u017', 'Quinn', true),
+('u018', 'Rachel', true),
+('u019', 'Steve', false),
+('u020', 'Tina', true),
+
+('u021', 'Uma', true),
+('u022', 'Victor', true),
+('u023', 'Wendy', true),
+('u024', 'Xavier', false),
+('u025', 'Yara', true),
+
+('u026', 'Zack', true),
+('u027', 'Anna', true),
+('u028', 'Ben', true),
+('u029', 'Cody', true),
+('u030', 'Dina', false),
+
+('u031', 'Evan', true),
+('u032', 'Fiona', true),
+('u033', 'Gina', true),
+('u034', 'Hank', true),
+('u035', 'Iris', false),
+
+('u036', 'Jack', true),
+('u037', 'Kelly', true),
+('u038', 'Leo', true),
+('u039', 'Mona', false),
+('u040', 'Nick', true),
+
+('u041', 'Olga', true),
+('u042', 'Peter', true),
+('u043', 'Queen', true),
+('u044', 'Roger', true),
+('u045', 'Sara', false),
+
+('u046', 'Tim', true),
+('u047', 'Ursula', true),
+('u048', 'Vlad', true),
+('u049', 'Will', false),
+('u050', 'Zoe', true);
+
+INSERT INTO team_members (user_id, team_id, is_primary) VALUES
+('u001', 1, true),
+('u002', 1, true),
+('u003', 1, true),
+('u004', 1, true),
+('u005', 1, true),
+
+('u006', 2, true),
+('u007', 2, true),
+('u008', 2, true),
+('u009', 2, true),
+('u010', 2, true),
+
+('u011', 3, true),
+('u012', 3, true),
+('u013', 3, true),
+('u014', 3, true),
+('u015', 3, true),
+
+('u016', 4, true),
+('u017', 4, true),
+('u018', 4, true),
+('u019', 4, true),
+('u020', 4, true),
+
+('u021', 5, true),
+('u022', 5, true),
+('u023', 5, true),
+('u024', 5, true),
+('u025', 5, true),
+
+('u026', 6, true),
+('u027', 6, true),
+('u028', 6, true),
+('u029', 6, true),
+('u030', 6, true),
+
+('u031', 7, true),
+('u032', 7, true),
+('u033', 7, true),
+('u034', 7, true),
+('u035', 7, true),
+
+('u036', 8, true),
+('u037', 8, true),
+('u038', 8, true),
+('u039', 8, true),
+('u040', 8, true),
+
+('u041', 9, true),
+('u042', 9, true),
+('u043', 9, true),
+('u044', 9, true),
+('u045', 9, true),
+
+('u046', 10, true),
+('u047', 10, true),
+('u048', 10, true),
+('u049', 10, true),
+('u050', 10, true),
+
+('u016', 1, false),
+('u046', 4, false);
 
 INSERT INTO pull_requests (pr_id, name, author_id, pr_status, merged_at) VALUES
 ('pr001', 'Refactor backend module', 'u001', 'MERGED', '2024-11-10 12:00:00'),
@@ -103,6 +167,8 @@ INSERT INTO pull_requests (pr_id, name, author_id, pr_status, merged_at) VALUES
 
 ('pr020', 'Firewall rules update', 'u041', 'MERGED', '2024-11-06 12:00:00');
 
+UPDATE pull_requests pr SET team_id = tm.team_id FROM team_members tm WHERE tm.user_id = pr.author_id AND tm.is_primary;
+
 INSERT INTO pull_requests_reviewers (pr_id, reviewer_id) VALUES
 ('pr001', 'u002'),
 ('pr001', 'u003'),